@@ -1,14 +1,39 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/soppibb/practica-go-web/cmd/docs"
 	"github.com/soppibb/practica-go-web/cmd/server/handler"
 	"github.com/soppibb/practica-go-web/cmd/server/middleware"
+	"github.com/soppibb/practica-go-web/internal/alert"
+	"github.com/soppibb/practica-go-web/internal/audit"
+	"github.com/soppibb/practica-go-web/internal/category"
+	"github.com/soppibb/practica-go-web/internal/currency"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/expiration"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/markdown"
+	"github.com/soppibb/practica-go-web/internal/order"
+	"github.com/soppibb/practica-go-web/internal/price"
 	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/promotion"
+	"github.com/soppibb/practica-go-web/internal/purchase"
+	"github.com/soppibb/practica-go-web/internal/recall"
+	"github.com/soppibb/practica-go-web/internal/reservation"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/internal/stocktake"
+	"github.com/soppibb/practica-go-web/internal/supplier"
+	"github.com/soppibb/practica-go-web/internal/tag"
+	"github.com/soppibb/practica-go-web/pkg/scheduler"
 	"github.com/soppibb/practica-go-web/pkg/store"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -30,6 +55,12 @@ func main() {
 		panic(err)
 	}
 
+	// Load the catalog time zone (defaults to UTC)
+	catalogLocation, err := time.LoadLocation(os.Getenv("CATALOG_TIMEZONE"))
+	if err != nil {
+		panic(err)
+	}
+
 	// Extract products data from the JSON file
 	jsonStore := store.NewJsonStore("products.json")
 	productList, err := jsonStore.GetAll()
@@ -37,10 +68,203 @@ func main() {
 		panic(err)
 	}
 
+	// Extract the exchange rates from the JSON file
+	ratesStore := store.NewJsonDocumentStore[domain.ExchangeRates]("rates.json")
+	rates, err := ratesStore.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	if err := rates.Validate(); err != nil {
+		panic(err)
+	}
+
+	// Extract the stock reservations from the JSON file, if any
+	reservationStore := store.NewJsonDocumentStore[[]domain.Reservation]("reservations.json")
+	reservations, err := loadOptional(reservationStore)
+	if err != nil {
+		panic(err)
+	}
+	reservationRepository := reservation.NewRepository(reservations, reservationStore)
+
+	// Low-stock alerts initialization
+	notifier, err := newNotifier(os.Getenv("ALERT_NOTIFIER"))
+	if err != nil {
+		panic(err)
+	}
+	alertedStore := store.NewJsonDocumentStore[[]int]("low_stock_alerts.json")
+	alerted, err := loadOptional(alertedStore)
+	if err != nil {
+		panic(err)
+	}
+	lowStockAlerter := alert.NewLowStockAlerter(notifier, alertedStore, alerted)
+
 	// New product handler initialization
-	repository := product.NewRepository(productList)
-	service := product.NewService(repository)
-	productHandler := handler.NewProductHandler(service)
+	repository := product.NewRepository(productList, jsonStore)
+
+	// Extract the product recalls from the JSON file, if any
+	recallStore := store.NewJsonDocumentStore[[]domain.Recall]("recalls.json")
+	recalls, err := loadOptional(recallStore)
+	if err != nil {
+		panic(err)
+	}
+	recallService := recall.NewService(recall.NewRepository(recalls, recallStore), repository)
+	recallHandler := handler.NewRecallHandler(recallService)
+
+	// Extract the price history from the JSON lines file
+	priceChangeStore := store.NewJsonLinesStore[domain.PriceChange]("price_history.jsonl")
+	priceChanges, err := priceChangeStore.LoadAll()
+	if err != nil {
+		panic(err)
+	}
+	priceHistory := price.NewHistory(priceChanges, priceChangeStore)
+
+	// Extract the region tax table from the JSON file
+	taxes, err := store.NewJsonDocumentStore[domain.TaxTable]("taxes.json").Load()
+	if err != nil {
+		panic(err)
+	}
+	if err := taxes.Validate(); err != nil {
+		panic(err)
+	}
+
+	// Extract the promotions and the markdown policy from the JSON files, if any
+	promotionStore := store.NewJsonDocumentStore[[]domain.Promotion]("promotions.json")
+	promotions, err := loadOptional(promotionStore)
+	if err != nil {
+		panic(err)
+	}
+	promotionRepository := promotion.NewRepository(promotions, promotionStore)
+	markdownStore := store.NewJsonDocumentStore[domain.MarkdownPolicy]("markdown.json")
+	markdownPolicy, err := loadOptional(markdownStore)
+	if err != nil {
+		panic(err)
+	}
+	markdownRepository := markdown.NewRepository(markdownPolicy, markdownStore)
+
+	// New rates handler initialization, the products and promotions keep their currencies in the rates
+	ratesService := currency.NewService(currency.NewRepository(rates, ratesStore), repository, promotionRepository)
+	ratesHandler := handler.NewRatesHandler(ratesService)
+
+	// Extract the product categories from the JSON file, if any
+	categoryStore := store.NewJsonDocumentStore[[]domain.Category]("categories.json")
+	categories, err := loadOptional(categoryStore)
+	if err != nil {
+		panic(err)
+	}
+	categoryService := category.NewService(category.NewRepository(categories, categoryStore), repository, markdownRepository, promotionRepository)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+
+	promotionService := promotion.NewService(promotionRepository, catalogLocation, ratesService, categoryService)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+
+	service := product.NewService(repository, catalogLocation, ratesService, lowStockAlerter, promotionService, priceHistory, taxes, categoryService, reservationRepository, recallService)
+
+	// Extract the created product tags from the JSON file, if any
+	tagStore := store.NewJsonDocumentStore[[]domain.Tag]("tags.json")
+	tags, err := loadOptional(tagStore)
+	if err != nil {
+		panic(err)
+	}
+	tagService := tag.NewService(tag.NewRepository(tags, tagStore), repository)
+	tagHandler := handler.NewTagHandler(tagService)
+
+	productHandler := handler.NewProductHandler(service, categoryService, tagService)
+	priceHandler := handler.NewPriceHandler(service)
+	variantHandler := handler.NewVariantHandler(service)
+
+	// Extract the stock locations from the JSON file, if any
+	locationStore := store.NewJsonDocumentStore[[]domain.Location]("locations.json")
+	locations, err := loadOptional(locationStore)
+	if err != nil {
+		panic(err)
+	}
+	locationService := location.NewService(location.NewRepository(locations, locationStore))
+	locationHandler := handler.NewLocationHandler(locationService)
+
+	// Extract the stock movement ledger from the JSON lines file
+	movementStore := store.NewJsonLinesStore[domain.StockMovement]("stock_movements.jsonl")
+	movements, err := movementStore.LoadAll()
+	if err != nil {
+		panic(err)
+	}
+
+	// New stock handler initialization
+	stockService := stock.NewService(service, stock.NewLedger(movements, movementStore), locationService, catalogLocation, recallService)
+	stockHandler := handler.NewStockHandler(stockService)
+
+	// Extract the stocktake sessions from the JSON file, if any
+	stocktakeStore := store.NewJsonDocumentStore[[]domain.StocktakeSession]("stocktakes.json")
+	stocktakes, err := loadOptional(stocktakeStore)
+	if err != nil {
+		panic(err)
+	}
+	stocktakeService := stocktake.NewService(stocktake.NewRepository(stocktakes, stocktakeStore), repository, stockService)
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakeService)
+
+	// Extract the suppliers and purchase orders from their JSON files, if any
+	supplierStore := store.NewJsonDocumentStore[[]domain.Supplier]("suppliers.json")
+	suppliers, err := loadOptional(supplierStore)
+	if err != nil {
+		panic(err)
+	}
+	supplierRepository := supplier.NewRepository(suppliers, supplierStore)
+	supplierHandler := handler.NewSupplierHandler(supplier.NewService(supplierRepository, service))
+
+	purchaseOrderStore := store.NewJsonDocumentStore[[]domain.PurchaseOrder]("purchase_orders.json")
+	purchaseOrders, err := loadOptional(purchaseOrderStore)
+	if err != nil {
+		panic(err)
+	}
+	purchaseOrderService := purchase.NewService(purchase.NewRepository(purchaseOrders, purchaseOrderStore), repository, service, supplierRepository, stockService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+
+	// Extract the customer orders from the JSON file, if any
+	orderStore := store.NewJsonDocumentStore[[]domain.Order]("orders.json")
+	orders, err := loadOptional(orderStore)
+	if err != nil {
+		panic(err)
+	}
+	orderService := order.NewService(order.NewRepository(orders, orderStore), service, stockService, catalogLocation)
+	orderHandler := handler.NewOrderHandler(orderService)
+
+	// New reservation handler initialization
+	reservationService := reservation.NewService(reservationRepository, service, stockService)
+	reservationHandler := handler.NewReservationHandler(reservationService)
+
+	// Audit log initialization
+	auditLog := audit.NewLog(store.NewJsonLinesStore[domain.AuditEntry]("audit.log"))
+	auditHandler := handler.NewAuditHandler(auditLog)
+
+	// Expiration monitor initialization
+	expirationPolicy, err := expiration.ParsePolicy(os.Getenv("EXPIRATION_POLICY"))
+	if err != nil {
+		panic(err)
+	}
+	expirationMonitor := expiration.NewMonitor(repository, catalogLocation, expirationPolicy, auditLog)
+
+	markdownService := markdown.NewService(markdownRepository, repository, priceHistory, categoryService, catalogLocation)
+	markdownHandler := handler.NewMarkdownHandler(markdownService)
+
+	// Background jobs
+	jobs := scheduler.New()
+	jobs.Every("publication schedule", durationFromEnv("SCHEDULER_INTERVAL", time.Minute), func(now time.Time) error {
+		_, err := service.ApplySchedule(now)
+		return err
+	})
+	jobs.Every("expiration monitor", durationFromEnv("EXPIRATION_INTERVAL", time.Hour), func(now time.Time) error {
+		_, err := expirationMonitor.Run(now)
+		return err
+	})
+	jobs.Every("near-expiry markdown", durationFromEnv("MARKDOWN_INTERVAL", 24*time.Hour), func(now time.Time) error {
+		_, err := markdownService.Run(now)
+		return err
+	})
+	jobs.Every("reservation sweeper", durationFromEnv("RESERVATION_SWEEP_INTERVAL", time.Minute), func(now time.Time) error {
+		_, err := reservationService.ExpireStale(now)
+		return err
+	})
+	jobs.Start(context.Background())
 
 	// Create new router
 	router := gin.New()
@@ -69,6 +293,9 @@ func main() {
 		productGroup.GET("/all", productHandler.GetAll())
 		productGroup.GET("/:id", productHandler.GetById())
 		productGroup.GET("/search", productHandler.GetByPriceGt())
+		productGroup.GET("/expiring", productHandler.GetExpiring())
+		productGroup.POST("/quote", priceHandler.Quote())
+		productGroup.GET("/:id/variants", variantHandler.GetVariants())
 	}
 
 	protectedProductGroup := generalGroup.Group("/products")
@@ -78,11 +305,185 @@ func main() {
 		protectedProductGroup.PUT("/:id", productHandler.FullUpdate())
 		protectedProductGroup.PATCH("/:id", productHandler.PartialUpdate())
 		protectedProductGroup.DELETE("/:id", productHandler.Delete())
+		protectedProductGroup.POST("/:id/transitions", productHandler.Transition())
+		protectedProductGroup.GET("/:id/prices", priceHandler.GetPrices())
+		protectedProductGroup.POST("/:id/prices", priceHandler.SetPrice())
+		protectedProductGroup.POST("/:id/variants", variantHandler.CreateVariant())
+		protectedProductGroup.POST("/:id/stock/adjust", stockHandler.Adjust())
+		protectedProductGroup.GET("/:id/stock/movements", stockHandler.GetMovements())
+		protectedProductGroup.POST("/:id/stock/transfer", stockHandler.Transfer())
+		protectedProductGroup.POST("/:id/lots", stockHandler.ReceiveLot())
+		protectedProductGroup.GET("/:id/lots", stockHandler.GetLots())
+		protectedProductGroup.DELETE("/:id/lots/:code", stockHandler.RemoveLot())
+		protectedProductGroup.POST("/:id/reservations", reservationHandler.Create())
+	}
+
+	// Reservations endpoints
+	reservationGroup := generalGroup.Group("/reservations")
+	reservationGroup.Use(middleware.TokenValidator())
+	{
+		reservationGroup.GET("/:id", reservationHandler.GetById())
+		reservationGroup.POST("/:id/confirm", reservationHandler.Confirm())
+		reservationGroup.POST("/:id/release", reservationHandler.Release())
+	}
+
+	// Stocktake endpoints
+	stocktakeGroup := generalGroup.Group("/stocktakes")
+	stocktakeGroup.Use(middleware.TokenValidator())
+	{
+		stocktakeGroup.GET("", stocktakeHandler.GetAll())
+		stocktakeGroup.POST("", stocktakeHandler.Open())
+		stocktakeGroup.GET("/:id", stocktakeHandler.Review())
+		stocktakeGroup.POST("/:id/counts", stocktakeHandler.SubmitCounts())
+		stocktakeGroup.POST("/:id/commit", stocktakeHandler.Commit())
+		stocktakeGroup.POST("/:id/cancel", stocktakeHandler.Cancel())
+	}
+
+	// Orders endpoints
+	orderGroup := generalGroup.Group("/orders")
+	orderGroup.Use(middleware.TokenValidator())
+	{
+		orderGroup.GET("", orderHandler.GetAll())
+		orderGroup.POST("", orderHandler.Create())
+		orderGroup.GET("/:id", orderHandler.GetById())
+		orderGroup.POST("/:id/cancel", orderHandler.Cancel())
+	}
+
+	// Suppliers endpoints
+	supplierGroup := generalGroup.Group("/suppliers")
+	supplierGroup.Use(middleware.TokenValidator())
+	{
+		supplierGroup.GET("", supplierHandler.GetAll())
+		supplierGroup.POST("", supplierHandler.Create())
+		supplierGroup.GET("/:id", supplierHandler.GetById())
+		supplierGroup.PUT("/:id", supplierHandler.Update())
+		supplierGroup.POST("/:id/products", supplierHandler.LinkProduct())
+	}
+
+	// Purchase orders endpoints
+	purchaseOrderGroup := generalGroup.Group("/purchase-orders")
+	purchaseOrderGroup.Use(middleware.TokenValidator())
+	{
+		purchaseOrderGroup.GET("", purchaseOrderHandler.GetAll())
+		purchaseOrderGroup.POST("/proposals", purchaseOrderHandler.Propose())
+		purchaseOrderGroup.GET("/:id", purchaseOrderHandler.GetById())
+		purchaseOrderGroup.POST("/:id/approve", purchaseOrderHandler.Approve())
+		purchaseOrderGroup.POST("/:id/receive", purchaseOrderHandler.Receive())
+		purchaseOrderGroup.POST("/:id/cancel", purchaseOrderHandler.Cancel())
+	}
+
+	// Categories endpoints
+	categoryGroup := generalGroup.Group("/categories")
+	{
+		categoryGroup.GET("", categoryHandler.GetAll())
+		categoryGroup.GET("/:id", categoryHandler.GetById())
+		categoryGroup.POST("", middleware.TokenValidator(), categoryHandler.Create())
+		categoryGroup.POST("/:id/move", middleware.TokenValidator(), categoryHandler.Move())
+		categoryGroup.DELETE("/:id", middleware.TokenValidator(), categoryHandler.Delete())
+		categoryGroup.GET("/:id/schema", categoryHandler.GetSchema())
+		categoryGroup.PUT("/:id/schema", middleware.TokenValidator(), categoryHandler.SetSchema())
+		categoryGroup.DELETE("/:id/schema", middleware.TokenValidator(), categoryHandler.DeleteSchema())
+		categoryGroup.POST("/:id/products", middleware.TokenValidator(), categoryHandler.AssignProduct())
+		categoryGroup.DELETE("/:id/products/:productId", middleware.TokenValidator(), categoryHandler.UnassignProduct())
+	}
+
+	// Tags endpoints
+	tagGroup := generalGroup.Group("/tags")
+	{
+		tagGroup.GET("", tagHandler.GetAll())
+		tagGroup.GET("/:name", tagHandler.GetByName())
+		tagGroup.POST("", middleware.TokenValidator(), tagHandler.Create())
+		tagGroup.PUT("/:name", middleware.TokenValidator(), tagHandler.Rename())
+		tagGroup.POST("/:name/merge", middleware.TokenValidator(), tagHandler.Merge())
+		tagGroup.DELETE("/:name", middleware.TokenValidator(), tagHandler.Delete())
+	}
+
+	// Locations endpoints
+	generalGroup.GET("/locations", locationHandler.GetAll())
+	generalGroup.POST("/locations", middleware.TokenValidator(), locationHandler.Create())
+
+	// Recalls endpoints
+	recallGroup := generalGroup.Group("/recalls")
+	recallGroup.Use(middleware.TokenValidator())
+	{
+		recallGroup.GET("", recallHandler.GetAll())
+		recallGroup.POST("", recallHandler.Create())
+		recallGroup.GET("/:id", recallHandler.GetById())
+		recallGroup.POST("/:id/close", recallHandler.Close())
+		recallGroup.GET("/:id/report", recallHandler.Report())
+	}
+
+	// Promotions endpoints
+	promotionGroup := generalGroup.Group("/promotions")
+	promotionGroup.Use(middleware.TokenValidator())
+	{
+		promotionGroup.GET("", promotionHandler.GetAll())
+		promotionGroup.POST("", promotionHandler.Create())
+		promotionGroup.GET("/:id", promotionHandler.GetById())
+		promotionGroup.DELETE("/:id", promotionHandler.Delete())
 	}
 
+	// Markdown policy endpoints
+	generalGroup.GET("/markdown", markdownHandler.Get())
+	generalGroup.PUT("/markdown", middleware.TokenValidator(), markdownHandler.Update())
+
+	// Exchange rates endpoints
+	generalGroup.GET("/rates", ratesHandler.Get())
+	generalGroup.PUT("/rates", middleware.TokenValidator(), ratesHandler.Update())
+
+	// Audit log endpoints
+	generalGroup.GET("/audit", middleware.TokenValidator(), auditHandler.GetAll())
+
 	// Start server
 	err = router.Run(":8080")
 	if err != nil {
 		panic(err)
 	}
 }
+
+// Auxiliary function that reads a duration (such as "30s" or "5m") from an environment variable.
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		panic(err)
+	}
+	return duration
+}
+
+// Auxiliary function that loads a document that may not have been saved yet, returning its zero value if the file does not exist.
+func loadOptional[T any](documentStore store.DocumentStore[T]) (T, error) {
+	document, err := documentStore.Load()
+	if errors.Is(err, os.ErrNotExist) {
+		var empty T
+		return empty, nil
+	}
+	return document, err
+}
+
+/*
+Auxiliary function that builds the low-stock notifier with the given name (log, webhook or smtp),
+configured from environment variables. An empty name defaults to the log notifier.
+*/
+func newNotifier(name string) (alert.Notifier, error) {
+	switch name {
+	case "", "log":
+		return alert.NewLogNotifier(), nil
+	case "webhook":
+		return alert.NewWebhookNotifier(os.Getenv("ALERT_WEBHOOK_URL")), nil
+	case "smtp":
+		return alert.NewSMTPNotifier(
+			os.Getenv("SMTP_ADDR"),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("SMTP_FROM"),
+			strings.Split(os.Getenv("SMTP_TO"), ","),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown alert notifier %q", name)
+	}
+}
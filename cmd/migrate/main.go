@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+/*
+The migrate command rewrites a products JSON file using the current data format. Legacy values
+(such as DD/MM/YYYY expiration dates) are accepted when loading and saved in their canonical form.
+*/
+func main() {
+	filepath := flag.String("file", "products.json", "products JSON file to migrate")
+	flag.Parse()
+
+	// Load the products using the legacy-aware decoders
+	jsonStore := store.NewJsonStore(*filepath)
+	products, err := jsonStore.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Save them back in the canonical format
+	if err := jsonStore.Save(products); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("migrated %d products in %s\n", len(products), *filepath)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryHandler_AttributeSchema(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	categoriesUrl := "https://localhost:8080/api/v1/categories"
+	productsUrl := "https://localhost:8080/api/v1/products"
+
+	// Tools > Drills, with product 1 as a drill
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Tools"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Drills","parent_id":1}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, categoriesUrl+"/2/products", `{"product_id":1}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodGet, categoriesUrl+"/1/schema", "", nil))
+
+	// Unsupported keywords and malformed schemas are rejected
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPut, categoriesUrl+"/1/schema", `{"type":"object","$ref":"#/definitions/brand"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPut, categoriesUrl+"/1/schema", `{"type":"thing"}`, nil))
+
+	// A schema the existing drill does not match is rejected
+	brandSchema := `{"type":"object","required":["brand"],"properties":{"brand":{"type":"string","minLength":1}}}`
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPut, categoriesUrl+"/1/schema", brandSchema, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"attributes":{"brand":"Acme","voltage":18}}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPut, categoriesUrl+"/1/schema", brandSchema, nil))
+
+	var attributeSchema map[string]any
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, categoriesUrl+"/1/schema", "", &attributeSchema))
+	assert.Equal(t, "object", attributeSchema["type"])
+
+	// The schemas of the category and its ancestors all apply
+	voltageSchema := `{"type":"object","properties":{"voltage":{"type":"integer","minimum":12,"maximum":36}}}`
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPut, categoriesUrl+"/2/schema", voltageSchema, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"attributes":{"brand":"Acme","voltage":220}}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"attributes":{"voltage":24}}`, nil))
+
+	var drill domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"attributes":{"brand":"Acme","voltage":24}}`, &drill))
+	assert.JSONEq(t, `"Acme"`, string(drill.Attributes["brand"]))
+
+	// Products without matching attributes cannot be assigned
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, categoriesUrl+"/2/products", `{"product_id":5}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/5", `{"attributes":{"brand":"Bolt","voltage":12}}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, categoriesUrl+"/2/products", `{"product_id":5}`, nil))
+
+	// Listings filter by attribute, case-insensitively for strings and numerically for numbers
+	var products []domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?attributes.brand=acme", "", &products))
+	assert.Len(t, products, 1)
+	assert.Equal(t, 1, products[0].Id)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?category=1&attributes.voltage=12.0", "", &products))
+	assert.Len(t, products, 1)
+	assert.Equal(t, 5, products[0].Id)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?attributes.brand=Acme&attributes.voltage=12", "", &products))
+	assert.Len(t, products, 0)
+
+	// Once the schema is deleted, the attributes are free
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/2/schema", "", nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/2/schema", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"attributes":{"brand":"Acme","voltage":220}}`, &drill))
+	var voltage json.Number
+	assert.NoError(t, json.Unmarshal(drill.Attributes["voltage"], &voltage))
+	assert.Equal(t, json.Number("220"), voltage)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/audit"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// AuditHandler is a handler for the audit log endpoints.
+type AuditHandler struct {
+	auditLog audit.Log
+}
+
+// The NewAuditHandler function returns a new AuditHandler that reads from the provided audit log.
+func NewAuditHandler(auditLog audit.Log) *AuditHandler {
+	return &AuditHandler{
+		auditLog: auditLog,
+	}
+}
+
+// GetAll godoc
+// @Summary List the audit log
+// @Tags Audit
+// @Description List every action recorded in the audit log, oldest first
+// @Produce json
+// @Param token header string true "Token"
+// @Success 200 {object} web.Response
+// @Failure 401 {object} web.ErrorResponse
+// @Failure 500 {object} web.ErrorResponse
+// @Router /audit [get]
+func (h *AuditHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, err := h.auditLog.GetAll()
+		if err != nil {
+			web.Failure(c, 500, err)
+			return
+		}
+
+		web.Success(c, 200, entries)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductHandler_Bundles(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productsUrl := "https://localhost:8080/api/v1/products"
+
+	// A gift basket with 2 bottles of wine (10 in stock) and 1 cheese (9 in stock)
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productsUrl+"/new",
+		`{"name":"Wine","quantity":10,"code_value":"WINE1","is_published":true,"expiration":"2030-01-01","price":12.5}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productsUrl+"/new",
+		`{"name":"Cheese","quantity":9,"code_value":"CHEESE1","is_published":true,"expiration":"2030-01-01","price":8}`, nil))
+	var basket domain.Product
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productsUrl+"/new",
+		`{"name":"Gift Basket","quantity":1,"code_value":"BASKET1","is_published":true,"expiration":"2030-01-01","price":40,"components":[{"product_id":501,"quantity":2},{"product_id":502,"quantity":1}]}`, &basket))
+	assert.Equal(t, 503, basket.Id)
+	assert.Equal(t, 5, basket.Quantity)
+	assert.Equal(t, 5, *basket.AvailableQuantity)
+
+	// Components must exist and cannot be bundles themselves
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/new",
+		`{"name":"Broken","quantity":1,"code_value":"BROKEN1","expiration":"2030-01-01","price":1,"components":[{"product_id":9999,"quantity":1}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/new",
+		`{"name":"Nested","quantity":1,"code_value":"NESTED1","expiration":"2030-01-01","price":1,"components":[{"product_id":503,"quantity":1}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/501", `{"components":[{"product_id":502,"quantity":1}]}`, nil))
+
+	// Bundles hold no stock of their own
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/503/stock/adjust", `{"delta":5,"reason":"restock"}`, nil))
+
+	// Selling a bundle takes the units of its components
+	var placed domain.Order
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":503,"quantity":2}]}`, &placed))
+	assert.Equal(t, "80", placed.Total.Amount.String())
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/501", "", &product))
+	assert.Equal(t, 6, product.Quantity)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/502", "", &product))
+	assert.Equal(t, 7, product.Quantity)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/503", "", &basket))
+	assert.Equal(t, 3, *basket.AvailableQuantity)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":503,"quantity":4}]}`, nil))
+
+	// Cancelling the order puts the units of the components back
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders/1/cancel", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/503", "", &basket))
+	assert.Equal(t, 5, *basket.AvailableQuantity)
+
+	// Units reserved for a bundle are held from its components, and released when the reservation is confirmed
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productsUrl+"/503/reservations", `{"quantity":2}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/501", "", &product))
+	assert.Equal(t, 6, *product.AvailableQuantity)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/503", "", &basket))
+	assert.Equal(t, 3, *basket.AvailableQuantity)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, productsUrl+"/501/stock/adjust", `{"delta":-7,"reason":"sale"}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/reservations/1/confirm", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/501", "", &product))
+	assert.Equal(t, 6, product.Quantity)
+	assert.Equal(t, 6, *product.AvailableQuantity)
+
+	// Products with stock of their own cannot turn into bundles
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"components":[{"product_id":502,"quantity":1}]}`, nil))
+
+	// Components cannot be deleted while a bundle includes them
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, productsUrl+"/501", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/503", `{"components":[{"product_id":502,"quantity":3}]}`, &basket))
+	assert.Equal(t, 2, basket.Quantity)
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, productsUrl+"/501", "", nil))
+}
@@ -0,0 +1,347 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/category"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/schema"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+var ErrInvalidCategory = errors.New("invalid category id")
+
+// CategoryHandler is a handler for the product category endpoints.
+type CategoryHandler struct {
+	service category.Service
+}
+
+// The NewCategoryHandler function returns a new CategoryHandler that uses the provided service.
+func NewCategoryHandler(service category.Service) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the categories
+// @Tags Categories
+// @Description List every category with its parent and its product counts, with and without its descendants
+// @Produce json
+// @Success 200 {object} web.Response
+// @Router /categories [get]
+func (h *CategoryHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// GetById godoc
+// @Summary Get a category
+// @Tags Categories
+// @Description Get a specific category based on its ID
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) GetById() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+
+		targetCategory, err := h.service.GetById(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, targetCategory)
+	}
+}
+
+// Create godoc
+// @Summary Create a category
+// @Tags Categories
+// @Description Create a category at the top of the tree or under an existing parent. Names are unique among the categories with the same parent
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param category body domain.Category true "category"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /categories [post]
+func (h *CategoryHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request domain.Category
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		newCategory, err := h.service.Create(request)
+		if err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, 201, newCategory)
+	}
+}
+
+// Move godoc
+// @Summary Move a category
+// @Tags Categories
+// @Description Move a category, with its subcategories and products, under another parent or to the top of the tree (parent 0). A category cannot be moved under one of its descendants
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Category ID"
+// @Param move body domain.CategoryMoveRequest true "new parent"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /categories/{id}/move [post]
+func (h *CategoryHandler) Move() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+
+		var request domain.CategoryMoveRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		movedCategory, err := h.service.Move(id, request.ParentId)
+		if err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, movedCategory)
+	}
+}
+
+// Delete godoc
+// @Summary Delete a category
+// @Tags Categories
+// @Description Delete permanently a category. Categories with subcategories or products, or used by the markdown policy or a promotion, cannot be deleted
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Category ID"
+// @Success 204 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+
+		if err := h.service.Delete(id); err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+// GetSchema godoc
+// @Summary Get the attribute schema of a category
+// @Tags Categories
+// @Description Get the JSON Schema that the attributes of the products in the category must match
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /categories/{id}/schema [get]
+func (h *CategoryHandler) GetSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+
+		attributeSchema, err := h.service.GetSchema(id)
+		if err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, attributeSchema)
+	}
+}
+
+// SetSchema godoc
+// @Summary Set the attribute schema of a category
+// @Tags Categories
+// @Description Set the JSON Schema that the attributes of the products in the category and its descendants must match.
+// @Description The schema is rejected if any of those products does not match it
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Category ID"
+// @Param schema body object true "JSON Schema of the attributes"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /categories/{id}/schema [put]
+func (h *CategoryHandler) SetSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+		var request json.RawMessage
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		updatedCategory, err := h.service.SetSchema(id, request)
+		if err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, updatedCategory)
+	}
+}
+
+// DeleteSchema godoc
+// @Summary Delete the attribute schema of a category
+// @Tags Categories
+// @Description Remove the JSON Schema of the category, so the attributes of its products are no longer checked against it
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Category ID"
+// @Success 204 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /categories/{id}/schema [delete]
+func (h *CategoryHandler) DeleteSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+
+		if err := h.service.DeleteSchema(id); err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+// AssignProduct godoc
+// @Summary Assign a product to a category
+// @Tags Categories
+// @Description Assign a product to the category, replacing any previous one
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Category ID"
+// @Param assignment body domain.CategoryAssignRequest true "product to assign"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /categories/{id}/products [post]
+func (h *CategoryHandler) AssignProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+
+		var request domain.CategoryAssignRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		updatedCategory, err := h.service.AssignProduct(id, request.ProductId)
+		if err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, updatedCategory)
+	}
+}
+
+// UnassignProduct godoc
+// @Summary Take a product out of a category
+// @Tags Categories
+// @Description Take a product out of the category it is assigned to
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Category ID"
+// @Param productId path int true "Product ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /categories/{id}/products/{productId} [delete]
+func (h *CategoryHandler) UnassignProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidCategory)
+			return
+		}
+		productId, err := strconv.Atoi(c.Param("productId"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		updatedCategory, err := h.service.UnassignProduct(id, productId)
+		if err != nil {
+			categoryFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, updatedCategory)
+	}
+}
+
+// Auxiliary function that maps the errors of the category service to their status codes.
+func categoryFailure(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, category.ErrNotFound), errors.Is(err, category.ErrNoSchema), errors.Is(err, product.ErrNotFound):
+		web.Failure(c, 404, err)
+	case errors.Is(err, category.ErrDuplicate), errors.Is(err, category.ErrNotEmpty), errors.Is(err, category.ErrInUse), errors.Is(err, category.ErrNotAssigned),
+		errors.Is(err, schema.ErrMismatch):
+		web.Failure(c, 409, err)
+	default:
+		web.Failure(c, 400, err)
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryHandler_Tree(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	categoriesUrl := "https://localhost:8080/api/v1/categories"
+
+	// Food > Canned, and Drinks
+	var newCategory domain.Category
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Food"}`, &newCategory))
+	assert.Equal(t, 1, newCategory.Id)
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Canned","parent_id":1}`, &newCategory))
+	assert.Equal(t, 2, newCategory.Id)
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Drinks"}`, nil))
+
+	// Names are unique among siblings, and parents must exist
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"food"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Food","parent_id":3}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Frozen","parent_id":99}`, nil))
+
+	// A category cannot be moved under itself or its descendants
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, categoriesUrl+"/1/move", `{"parent_id":2}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, categoriesUrl+"/1/move", `{"parent_id":1}`, nil))
+
+	// Product 2 is canned pineapple, product 1 is just food
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, categoriesUrl+"/2/products", `{"product_id":2}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, categoriesUrl+"/1/products", `{"product_id":1}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, categoriesUrl+"/1/products", `{"product_id":9999}`, nil))
+
+	var food domain.Category
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, categoriesUrl+"/1", "", &food))
+	assert.Equal(t, 1, food.ProductCount)
+	assert.Equal(t, 2, food.TotalProductCount)
+
+	// Filtering by a category includes its descendants
+	var products []domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/all?category=1", "", &products))
+	assert.Len(t, products, 2)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/all?category=2", "", &products))
+	assert.Len(t, products, 1)
+	assert.Equal(t, 2, products[0].Id)
+	assert.Equal(t, 2, products[0].CategoryId)
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/all?category=99", "", nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/all?category=abc", "", nil))
+
+	// Moving a category carries its products along
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, categoriesUrl+"/2/move", `{"parent_id":3}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, categoriesUrl+"/1", "", &food))
+	assert.Equal(t, 1, food.TotalProductCount)
+
+	// Categories with products or subcategories cannot be deleted
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/2", "", nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/3", "", nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/1/products/2", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/2/products/2", "", nil))
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/2", "", nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodGet, categoriesUrl+"/2", "", nil))
+
+	// Neither can categories a promotion targets
+	var promotion domain.Promotion
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/promotions", `{"name":"10% off drinks","type":"percentage","value":10,"category_ids":[4]}`, &promotion))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/4", "", nil))
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, "https://localhost:8080/api/v1/promotions/"+strconv.Itoa(promotion.Id), "", nil))
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, categoriesUrl+"/4", "", nil))
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// LocationHandler is a handler for the stock location endpoints.
+type LocationHandler struct {
+	service location.Service
+}
+
+// The NewLocationHandler function returns a new LocationHandler that uses the provided service.
+func NewLocationHandler(service location.Service) *LocationHandler {
+	return &LocationHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the locations
+// @Tags Locations
+// @Description List every location where stock can be kept, such as warehouses
+// @Produce json
+// @Success 200 {object} web.Response
+// @Router /locations [get]
+func (h *LocationHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// Create godoc
+// @Summary Create a location
+// @Tags Locations
+// @Description Create a new location where stock can be kept
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param location body domain.Location true "location"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /locations [post]
+func (h *LocationHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the location from the request body
+		var newLocation domain.Location
+		if err := c.ShouldBindJSON(&newLocation); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		createdLocation, err := h.service.Create(newLocation)
+		switch {
+		case errors.Is(err, location.ErrDuplicate):
+			web.Failure(c, 409, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 201, createdLocation)
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocationHandler_Transfer(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	transferUrl := "https://localhost:8080/api/v1/products/5/stock/transfer"
+
+	// Create a second warehouse
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/locations", `{"code":"north","name":"North warehouse"}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/locations", `{"code":"north","name":"Again"}`, nil))
+	var locations []domain.Location
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/locations", "", &locations))
+	assert.Len(t, locations, 2)
+
+	// Move 10 of the 336 units of product 5 to the new warehouse
+	var movement domain.StockMovement
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, transferUrl, `{"from":"main","to":"north","quantity":10}`, &movement))
+	assert.Equal(t, domain.ReasonTransfer, movement.Reason)
+	assert.Equal(t, []domain.LocationMovement{{Location: "main", Delta: -10}, {Location: "north", Delta: 10}}, movement.Locations)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, transferUrl, `{"from":"north","to":"main","quantity":11}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, transferUrl, `{"from":"main","to":"south","quantity":1}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, transferUrl, `{"from":"main","to":"main","quantity":1}`, nil))
+
+	// Sales at a location cannot take more than it keeps
+	adjustUrl := "https://localhost:8080/api/v1/products/5/stock/adjust"
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, adjustUrl, `{"delta":-11,"reason":"sale","location":"north"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, adjustUrl, `{"delta":-4,"reason":"sale","location":"north"}`, nil))
+
+	// The product quantity is the sum of its locations
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/5", "", &product))
+	assert.Equal(t, 332, product.Quantity)
+	assert.Equal(t, []domain.LocationStock{{Location: "main", Quantity: 326}, {Location: "north", Quantity: 6}}, product.Stock)
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, "https://localhost:8080/api/v1/products/5", `{"quantity":100}`, nil))
+
+	// Only product 5 has stock at the new warehouse
+	var products []domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/all?location=north", "", &products))
+	assert.Len(t, products, 1)
+	assert.Equal(t, 5, products[0].Id)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/search?priceGt=900&location=north", "", &products))
+	assert.Len(t, products, 0)
+}
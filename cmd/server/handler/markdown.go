@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/markdown"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// MarkdownHandler is a handler for the near-expiry markdown endpoints.
+type MarkdownHandler struct {
+	service markdown.Service
+}
+
+// The NewMarkdownHandler function returns a new MarkdownHandler that uses the provided service.
+func NewMarkdownHandler(service markdown.Service) *MarkdownHandler {
+	return &MarkdownHandler{
+		service: service,
+	}
+}
+
+// Get godoc
+// @Summary Get the markdown policy
+// @Tags Markdown
+// @Description Get the near-expiry markdown schedule: the percentage taken off products expiring within each number of days
+// @Produce json
+// @Success 200 {object} web.Response
+// @Router /markdown [get]
+func (h *MarkdownHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetPolicy())
+	}
+}
+
+// Update godoc
+// @Summary Update the markdown policy
+// @Tags Markdown
+// @Description Replace the near-expiry markdown schedules, global and per category. Prices follow them on the next run of the daily markdown job
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param policy body domain.MarkdownPolicy true "markdown policy"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 401 {object} web.ErrorResponse
+// @Router /markdown [put]
+func (h *MarkdownHandler) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the policy from the request body
+		var policy domain.MarkdownPolicy
+		if err := c.ShouldBindJSON(&policy); err != nil {
+			web.Failure(c, 400, domain.ErrInvalidMarkdownPolicy)
+			return
+		}
+
+		// Store the new policy
+		updatedPolicy, err := h.service.UpdatePolicy(policy)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, updatedPolicy)
+	}
+}
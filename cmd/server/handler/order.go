@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/order"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// OrderHandler is a handler for the customer order endpoints.
+type OrderHandler struct {
+	service order.Service
+}
+
+// The NewOrderHandler function returns a new OrderHandler that uses the provided service.
+func NewOrderHandler(service order.Service) *OrderHandler {
+	return &OrderHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the orders
+// @Tags Orders
+// @Description List every customer order
+// @Produce json
+// @Param token header string true "Token"
+// @Success 200 {object} web.Response
+// @Router /orders [get]
+func (h *OrderHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// GetById godoc
+// @Summary Get an order
+// @Tags Orders
+// @Description Get a specific order based on its ID
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Order ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /orders/{id} [get]
+func (h *OrderHandler) GetById() gin.HandlerFunc {
+	return h.apply(h.service.GetById)
+}
+
+// Create godoc
+// @Summary Place an order
+// @Tags Orders
+// @Description Place an order of published, unexpired products. Prices are snapshotted and stock is taken out in the same operation that stores the order
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param order body domain.OrderRequest true "order"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /orders [post]
+func (h *OrderHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request domain.OrderRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		placedOrder, err := h.service.Create(request)
+		if err != nil {
+			orderFailure(c, err)
+			return
+		}
+
+		web.Success(c, 201, placedOrder)
+	}
+}
+
+// Cancel godoc
+// @Summary Cancel an order
+// @Tags Orders
+// @Description Cancel a placed order, putting its units back in stock
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Order ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) Cancel() gin.HandlerFunc {
+	return h.apply(h.service.Cancel)
+}
+
+// Auxiliary function that builds a handler applying an operation to the order in the URL.
+func (h *OrderHandler) apply(operation func(id int) (domain.Order, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		targetOrder, err := operation(id)
+		if err != nil {
+			orderFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, targetOrder)
+	}
+}
+
+// Auxiliary function that emits the failure response of an order operation.
+func orderFailure(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, order.ErrNotFound):
+		web.Failure(c, 404, err)
+	case errors.Is(err, order.ErrNotPlaced), errors.Is(err, order.ErrUnavailable):
+		web.Failure(c, 409, err)
+	default:
+		stockFailure(c, err)
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderHandler_CreateAndCancel(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+
+	// Product 501 is published and not expired, while product 1 has already expired
+	var newProduct domain.Product
+	status := serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/new",
+		`{"name":"Fresh Milk","quantity":10,"code_value":"MILK1","is_published":true,"expiration":"2030-01-01","price":12.5}`, &newProduct)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, 501, newProduct.Id)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":1,"quantity":1}]}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":501,"quantity":11}]}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":999,"quantity":1}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[]}`, nil))
+
+	// Units held by a reservation cannot be ordered
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/501/reservations", `{"quantity":7}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":501,"quantity":4}]}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/reservations/1/release", "", nil))
+
+	// Placing an order snapshots the price and takes the units out of stock
+	var placed domain.Order
+	status = serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":501,"quantity":3},{"product_id":501,"quantity":1}]}`, &placed)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, domain.OrderPlaced, placed.Status)
+	assert.Len(t, placed.Lines, 1)
+	assert.Equal(t, "50", placed.Total.Amount.String())
+
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/501", "", &product))
+	assert.Equal(t, 6, product.Quantity)
+
+	// A later price change does not change the order
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, "https://localhost:8080/api/v1/products/501", `{"price":20}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/orders/1", "", &placed))
+	assert.Equal(t, "12.5", placed.Lines[0].UnitPrice.Amount.String())
+
+	// Cancelling the order puts the units back, only once
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders/1/cancel", "", &placed))
+	assert.Equal(t, domain.OrderCancelled, placed.Status)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders/1/cancel", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/501", "", &product))
+	assert.Equal(t, 10, product.Quantity)
+
+	var movements []domain.StockMovement
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/501/stock/movements", "", &movements))
+	assert.Equal(t, []domain.MovementReason{domain.ReasonSale, domain.ReasonReturn}, []domain.MovementReason{movements[0].Reason, movements[1].Reason})
+	assert.Equal(t, "order-1", movements[1].Reference)
+}
+
+func TestOrderHandler_Lots(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	lotsUrl := "https://localhost:8080/api/v1/products/2/lots"
+
+	// Lots cannot be received already expired
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, lotsUrl, `{"code":"OLD","quantity":10,"expiration":"2021-01-01"}`, nil))
+
+	// Product 2 has expired, but a fresh lot makes it orderable again, next to its expired "initial" lot
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":2,"quantity":1}]}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, lotsUrl, `{"code":"A","quantity":10,"expiration":"2030-01-01"}`, nil))
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2", "", &product))
+	assert.Equal(t, "2030-01-01", product.Expiration.String())
+
+	var placed domain.Order
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":2,"quantity":3}]}`, &placed))
+	var movements []domain.StockMovement
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2/stock/movements", "", &movements))
+	assert.Equal(t, []domain.LotMovement{{Code: "A", Delta: -3}}, movements[len(movements)-1].Lots)
+
+	// Only the fresh units can be ordered
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/orders", `{"lines":[{"product_id":2,"quantity":8}]}`, nil))
+}
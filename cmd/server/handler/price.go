@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/cmd/server/middleware"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// PriceHandler is a handler for the product price timeline endpoints.
+type PriceHandler struct {
+	service product.Service
+}
+
+// The NewPriceHandler function returns a new PriceHandler that uses the provided product service.
+func NewPriceHandler(service product.Service) *PriceHandler {
+	return &PriceHandler{
+		service: service,
+	}
+}
+
+// GetPrices godoc
+// @Summary Get the price timeline of a product
+// @Tags Products
+// @Description Get the recorded price changes of a product (oldest first, with their timestamp and actor), its current price and its scheduled prices (soonest first)
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /products/{id}/prices [get]
+func (h *PriceHandler) GetPrices() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		timeline, err := h.service.GetPrices(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, timeline)
+	}
+}
+
+// SetPrice godoc
+// @Summary Change the price of a product
+// @Tags Products
+// @Description Change the price of a product right away or, with effective_from set to a future instant, schedule it to change automatically at that instant. A price without a currency keeps the product currency, and the change is recorded on behalf of the owner of the token
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Param price body domain.PriceRequest true "price"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /products/{id}/prices [post]
+func (h *PriceHandler) SetPrice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the price from the request body
+		var request domain.PriceRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		updatedProduct, err := h.service.SetPrice(id, request, middleware.Actor(c))
+		switch {
+		case err == nil:
+			web.Success(c, 200, updatedProduct)
+		case errors.Is(err, product.ErrNotFound):
+			web.Failure(c, 404, err)
+		default:
+			web.Failure(c, 400, err)
+		}
+	}
+}
+
+// Quote godoc
+// @Summary Quote the price of some products
+// @Tags Products
+// @Description Get the unit and total prices of some units of some products for sale, after their price tiers and the promotions running now, optionally with their taxes in a region
+// @Accept json
+// @Produce json
+// @Param quote body domain.QuoteRequest true "products and quantities"
+// @Param region query string false "Tax region (such as CL or AR) to break prices down into net, tax and gross amounts"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/quote [post]
+func (h *PriceHandler) Quote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the lines from the request body
+		var request domain.QuoteRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		quote, err := h.service.Quote(request.Lines, c.Query("region"))
+		switch {
+		case err == nil:
+			web.Success(c, 200, quote)
+		case errors.Is(err, product.ErrNotFound):
+			web.Failure(c, 404, err)
+		case errors.Is(err, product.ErrUnavailable):
+			web.Failure(c, 409, err)
+		default:
+			web.Failure(c, 400, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceHandler_Timeline(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	pricesUrl := "https://localhost:8080/api/v1/products/2/prices"
+
+	// A price changed through PATCH is recorded on behalf of the owner of the token, not of a header
+	t.Setenv("ACTOR_TOKENS", "alice:67890, bob:24680")
+	request, responseRecorder := createRequestTest(http.MethodPatch, "https://localhost:8080/api/v1/products/2", `{"price":360}`)
+	request.Header.Add("token", "67890")
+	request.Header.Add("actor", "mallory")
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	request, responseRecorder = createRequestTest(http.MethodPatch, "https://localhost:8080/api/v1/products/2", `{"price":365}`)
+	request.Header.Add("token", "alice")
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusUnauthorized, responseRecorder.Code)
+
+	// Future prices are scheduled, others change right away
+	effectiveFrom := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, pricesUrl, `{"price":370,"effective_from":"`+effectiveFrom+`"}`, &product))
+	assert.Equal(t, "360", product.Price.Amount.String())
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, pricesUrl, `{"price":380}`, &product))
+	assert.Equal(t, "380", product.Price.Amount.String())
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, pricesUrl, `{"price":-1}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/999/prices", `{"price":1}`, nil))
+
+	// Assertions
+	var timeline domain.PriceTimeline
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, pricesUrl, "", &timeline))
+	assert.Equal(t, "380", timeline.Price.Amount.String())
+	assert.Len(t, timeline.History, 2)
+	assert.Equal(t, "352.79", timeline.History[0].Previous.Amount.String())
+	assert.Equal(t, "360", timeline.History[0].Price.Amount.String())
+	assert.Equal(t, domain.PriceManual, timeline.History[0].Reason)
+	assert.Equal(t, "alice", timeline.History[0].Actor)
+	assert.Equal(t, "api", timeline.History[1].Actor)
+	assert.Len(t, timeline.Scheduled, 1)
+	assert.Equal(t, "370", timeline.Scheduled[0].Price.Amount.String())
+}
+
+func TestPriceHandler_Quote(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productUrl := "https://localhost:8080/api/v1/products/2"
+	quoteUrl := "https://localhost:8080/api/v1/products/quote"
+
+	// Tiers need increasing quantities and decreasing prices below the list price (352.79)
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productUrl, `{"price_tiers":[{"min_quantity":10,"price":300},{"min_quantity":5,"price":250}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productUrl, `{"price_tiers":[{"min_quantity":10,"price":300},{"min_quantity":50,"price":310}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productUrl, `{"price_tiers":[{"min_quantity":10,"price":400}]}`, nil))
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productUrl, `{"is_published":true,"price_tiers":[{"min_quantity":10,"price":300},{"min_quantity":50,"price":250}]}`, &product))
+	assert.Len(t, product.PriceTiers, 2)
+
+	// A new list price must still stay above every tier
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productUrl, `{"is_published":true,"price":280}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productUrl, `{"is_published":true,"price":330000,"currency":"CLP"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productUrl+"/prices", `{"price":280}`, nil))
+
+	// Every line gets the price of the tier its quantity reaches
+	var quote domain.Quote
+	status := serveAuthorized(router, http.MethodPost, quoteUrl, `{"lines":[{"id":2,"quantity":9},{"id":2,"quantity":10},{"id":2,"quantity":60}]}`, &quote)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "352.79", quote.Lines[0].UnitPrice.Amount.String())
+	assert.Equal(t, "3175.11", quote.Lines[0].Total.Amount.String())
+	assert.Equal(t, "300", quote.Lines[1].UnitPrice.Amount.String())
+	assert.Equal(t, "250", quote.Lines[2].UnitPrice.Amount.String())
+	assert.Equal(t, "21175.11", quote.Total.Amount.String())
+	assert.Equal(t, "USD", quote.Currency)
+
+	// Promotions apply on top of the tier price
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/promotions", `{"name":"10% off","type":"percentage","value":10,"product_ids":[2]}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, quoteUrl, `{"lines":[{"id":2,"quantity":10}]}`, &quote))
+	assert.Equal(t, "270", quote.Lines[0].UnitPrice.Amount.String())
+	assert.Equal(t, "2700", quote.Total.Amount.String())
+	assert.Len(t, quote.Lines[0].Promotions, 1)
+
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, quoteUrl, `{"lines":[{"id":999,"quantity":1}]}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, quoteUrl, `{"lines":[{"id":3,"quantity":1}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, quoteUrl, `{"lines":[{"id":2,"quantity":-1}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, quoteUrl, `{"lines":[]}`, nil))
+}
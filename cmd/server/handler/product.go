@@ -3,13 +3,17 @@ package handler
 import (
 	"errors"
 	"net/http"
-	"os"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/soppibb/practica-go-web/cmd/server/middleware"
+	"github.com/soppibb/practica-go-web/internal/category"
+	"github.com/soppibb/practica-go-web/internal/currency"
 	"github.com/soppibb/practica-go-web/internal/domain"
 	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/tag"
 	"github.com/soppibb/practica-go-web/pkg/web"
 )
 
@@ -19,20 +23,29 @@ var (
 	ErrInvalidData  = errors.New("invalid product data")
 	ErrNotFound     = errors.New("product not found")
 	ErrInvalidCode  = errors.New("invalid product code value")
+	ErrInvalidDays  = errors.New("invalid period, expected a number of days such as 30d or a number of weeks such as 2w")
+	ErrInvalidFlag  = errors.New("invalid collapse_variants flag, expected true or false")
 )
 
+// defaultExpiringWithin is the period used by the expiring products endpoint when none is given.
+const defaultExpiringWithin = "30d"
+
 // ProductHandler is a handler for the product endpoints.
 type ProductHandler struct {
-	service product.Service
+	service    product.Service
+	categories category.Service
+	tags       tag.Service
 }
 
 /*
 The NewProductHandler function returns a new ProductHandler. It uses the provided service for
-make CRUD operations for products.
+make CRUD operations for products, and the category and tag services to filter listings.
 */
-func NewProductHandler(service product.Service) *ProductHandler {
+func NewProductHandler(service product.Service, categories category.Service, tags tag.Service) *ProductHandler {
 	return &ProductHandler{
-		service: service,
+		service:    service,
+		categories: categories,
+		tags:       tags,
 	}
 }
 
@@ -41,11 +54,45 @@ func NewProductHandler(service product.Service) *ProductHandler {
 // @Tags Products
 // @Description List all available products
 // @Produce json
+// @Param currency query string false "Currency to present prices in"
+// @Param location query string false "Only products with stock at this location"
+// @Param region query string false "Tax region (such as CL or AR) to break prices down into net, tax and gross amounts"
+// @Param category query int false "Only products in this category or its descendants"
+// @Param tags query string false "Only products with these comma-separated tags"
+// @Param tags_mode query string false "Whether products need all the tags or any of them" Enums(all, any) default(all)
+// @Param attributes.{name} query string false "Only products whose attribute {name} has this value, or contains it if it is a list"
+// @Param collapse_variants query bool false "List parent products, with a summary of their variants, instead of the variants"
 // @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
 // @Router /products/all [get]
 func (h *ProductHandler) GetAll() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		products := h.service.GetAll()
+		products, err := h.inCategory(h.service.AtLocation(h.service.GetAll(), c.Query("location")), c)
+		if err != nil {
+			return
+		}
+		products, err = h.withTags(products, c)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		products = h.withAttributes(products, c)
+		products, err = h.collapsed(products, c)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		products, err = h.service.ConvertPrices(products, c.Query("currency"))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		products, err = h.service.WithTax(products, c.Query("region"))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
 		web.Success(c, 200, products)
 	}
 }
@@ -56,6 +103,8 @@ func (h *ProductHandler) GetAll() gin.HandlerFunc {
 // @Description Get a specific product based on its ID
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param currency query string false "Currency to present prices in"
+// @Param region query string false "Tax region (such as CL or AR) to break prices down into net, tax and gross amounts"
 // @Success 200 {object} web.Response
 // @Failure 400 {object} web.ErrorResponse
 // @Failure 404 {object} web.ErrorResponse
@@ -75,16 +124,36 @@ func (h *ProductHandler) GetById() gin.HandlerFunc {
 			return
 		}
 
-		web.Success(c, 200, targetProduct)
+		converted, err := h.service.ConvertPrices([]domain.Product{targetProduct}, c.Query("currency"))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		converted, err = h.service.WithTax(converted, c.Query("region"))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, converted[0])
 	}
 }
 
 // GetByPriceGt godoc
 // @Summary Get all products based on its price
 // @Tags Products
-// @Description Get all products with a price greater than the provided value
+// @Description Get all products with a price greater than the provided value. The price and the
+// @Description results are expressed in the requested currency (the default currency if omitted).
 // @Produce json
-// @Param priceGt query int true "Price"
+// @Param priceGt query number true "Price"
+// @Param currency query string false "Currency of the price filter and the results"
+// @Param location query string false "Only products with stock at this location"
+// @Param region query string false "Tax region (such as CL or AR) to break prices down into net, tax and gross amounts"
+// @Param category query int false "Only products in this category or its descendants"
+// @Param tags query string false "Only products with these comma-separated tags"
+// @Param tags_mode query string false "Whether products need all the tags or any of them" Enums(all, any) default(all)
+// @Param attributes.{name} query string false "Only products whose attribute {name} has this value, or contains it if it is a list"
+// @Param collapse_variants query bool false "List parent products, with a summary of their variants, instead of the variants"
 // @Success 200 {object} web.Response
 // @Failure 400 {object} web.ErrorResponse
 // @Failure 404 {object} web.ErrorResponse
@@ -92,22 +161,81 @@ func (h *ProductHandler) GetById() gin.HandlerFunc {
 func (h *ProductHandler) GetByPriceGt() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		stringPriceGt := c.Query("priceGt")
-		priceGt, err := strconv.ParseFloat(stringPriceGt, 64)
+		priceGt, err := domain.ParseDecimal(stringPriceGt)
 		if err != nil {
 			web.Failure(c, 400, ErrInvalidPrice)
 			return
 		}
 
-		filteredProducts, err := h.service.GetByPriceGt(priceGt)
+		requestedCurrency := c.Query("currency")
+		filteredProducts, err := h.service.GetByPriceGt(domain.NewMoney(priceGt, requestedCurrency))
+		if errors.Is(err, currency.ErrUnsupportedCurrency) {
+			web.Failure(c, 400, err)
+			return
+		}
 		if err != nil {
 			web.Failure(c, 404, err)
 			return
 		}
 
+		filteredProducts, err = h.inCategory(h.service.AtLocation(filteredProducts, c.Query("location")), c)
+		if err != nil {
+			return
+		}
+		filteredProducts, err = h.withTags(filteredProducts, c)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		filteredProducts = h.withAttributes(filteredProducts, c)
+		filteredProducts, err = h.collapsed(filteredProducts, c)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		filteredProducts, err = h.service.ConvertPrices(filteredProducts, requestedCurrency)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		filteredProducts, err = h.service.WithTax(filteredProducts, c.Query("region"))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
 		web.Success(c, 200, filteredProducts)
 	}
 }
 
+// GetExpiring godoc
+// @Summary List products expiring soon
+// @Tags Products
+// @Description List the products that have not expired yet but will do so within the given period, soonest first
+// @Produce json
+// @Param within query string false "Period in days (30d) or weeks (2w)" default(30d)
+// @Param region query string false "Tax region (such as CL or AR) to break prices down into net, tax and gross amounts"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Router /products/expiring [get]
+func (h *ProductHandler) GetExpiring() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days, err := parseDays(c.DefaultQuery("within", defaultExpiringWithin))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		products, err := h.service.WithTax(h.service.GetExpiring(days), c.Query("region"))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, products)
+	}
+}
+
 // Create godoc
 // @Summary Create a new product
 // @Tags Products
@@ -125,14 +253,7 @@ func (h *ProductHandler) Create() gin.HandlerFunc {
 		// Obtains the new product data from the request body
 		var newProduct domain.Product
 		if err := c.ShouldBindJSON(&newProduct); err != nil {
-			web.Failure(c, 400, ErrInvalidData)
-			return
-		}
-
-		// Checks if the product expiration date is valid (DD/MM/YYYY)
-		validDate, err := validateDate(newProduct.Expiration)
-		if !validDate {
-			web.Failure(c, 400, err)
+			web.Failure(c, 400, bindingError(err))
 			return
 		}
 
@@ -159,6 +280,7 @@ func (h *ProductHandler) Create() gin.HandlerFunc {
 // @Success 200 {object} web.Response
 // @Failure 400 {object} web.ErrorResponse
 // @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
 // @Router /products/{id} [put]
 func (h *ProductHandler) FullUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -177,29 +299,34 @@ func (h *ProductHandler) FullUpdate() gin.HandlerFunc {
 			return
 		}
 
-		// Extract the product data from the request body
+		// Extract the product data from the request body, which must hold every required field
 		var newProductData domain.Product
-		if err := c.ShouldBindJSON(&newProductData); err != nil {
-			web.Failure(c, 400, ErrInvalidData)
+		if err := c.ShouldBindBodyWith(&newProductData, binding.JSON); err != nil {
+			web.Failure(c, 400, bindingError(err))
 			return
 		}
-		// Checks if the product expiration date is valid (DD/MM/YYYY)
-		isValidDate, err := validateDate(newProductData.Expiration)
-		if !isValidDate {
-			web.Failure(c, 400, err)
+		// Binds it again to know which optional fields the request gives
+		var request domain.ProductRequest
+		if err := c.ShouldBindBodyWith(&request, binding.JSON); err != nil {
+			web.Failure(c, 400, bindingError(err))
 			return
 		}
+		// A full replacement unpublishes the product unless the body says otherwise
+		request.IsPublished = &newProductData.IsPublished
 
 		// Updates the product
-		updatedProduct, err := h.service.Update(id, newProductData)
+		updatedProduct, err := h.service.Update(id, request, middleware.Actor(c))
 
 		// Check for errors
 		if err != nil && err.Error() == ErrNotFound.Error() {
 			web.Failure(c, 404, err)
 			return
 		}
-
-		if err != nil && err.Error() == ErrInvalidCode.Error() {
+		if errors.Is(err, product.ErrIllegalTransition) {
+			web.Failure(c, 409, err)
+			return
+		}
+		if err != nil {
 			web.Failure(c, 400, err)
 			return
 		}
@@ -220,6 +347,7 @@ func (h *ProductHandler) FullUpdate() gin.HandlerFunc {
 // @Success 200 {object} web.Response
 // @Failure 400 {object} web.ErrorResponse
 // @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
 // @Router /products/{id} [patch]
 func (h *ProductHandler) PartialUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -241,37 +369,23 @@ func (h *ProductHandler) PartialUpdate() gin.HandlerFunc {
 		// Extract the product data from the request body
 		var partialUpdateData domain.ProductRequest
 		if err := c.ShouldBindJSON(&partialUpdateData); err != nil {
-			web.Failure(c, 400, ErrInvalidData)
+			web.Failure(c, 400, bindingError(err))
 			return
 		}
 
-		update := domain.Product{
-			Name:        partialUpdateData.Name,
-			Quantity:    partialUpdateData.Quantity,
-			CodeValue:   partialUpdateData.CodeValue,
-			IsPublished: partialUpdateData.IsPublished,
-			Expiration:  partialUpdateData.Expiration,
-			Price:       partialUpdateData.Price,
-		}
-
-		// Checks if the product expiration date is valid (DD/MM/YYYY)
-		if update.Expiration != "" {
-			isValidDate, err := validateDate(update.Expiration)
-			if !isValidDate {
-				web.Failure(c, 400, err)
-				return
-			}
-		}
-
 		// Updates the product
-		updatedProduct, err := h.service.Update(id, update)
+		updatedProduct, err := h.service.Update(id, partialUpdateData, middleware.Actor(c))
 
 		// Check for errors
 		if err != nil && err.Error() == ErrNotFound.Error() {
 			web.Failure(c, 404, err)
 			return
 		}
-		if err != nil && err.Error() == ErrInvalidCode.Error() {
+		if errors.Is(err, product.ErrIllegalTransition) {
+			web.Failure(c, 409, err)
+			return
+		}
+		if err != nil {
 			web.Failure(c, 400, err)
 			return
 		}
@@ -283,7 +397,7 @@ func (h *ProductHandler) PartialUpdate() gin.HandlerFunc {
 // Delete godoc
 // @Summary Delete a product
 // @Tags Products
-// @Description Delete permanently a product
+// @Description Delete permanently a product. Parent products cannot be deleted while they have variants, nor components while a bundle includes them
 // @Accept json
 // @Produce json
 // @Param token header string true "Token"
@@ -291,6 +405,7 @@ func (h *ProductHandler) PartialUpdate() gin.HandlerFunc {
 // @Success 204 {object} web.Response
 // @Failure 400 {object} web.ErrorResponse
 // @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
 // @Router /products/{id} [delete]
 func (h *ProductHandler) Delete() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -311,6 +426,10 @@ func (h *ProductHandler) Delete() gin.HandlerFunc {
 
 		// Deletes the product
 		err = h.service.Delete(id)
+		if errors.Is(err, product.ErrHasVariants) || errors.Is(err, product.ErrInBundle) {
+			web.Failure(c, 409, err)
+			return
+		}
 		if err != nil {
 			web.Failure(c, 404, err)
 			return
@@ -320,32 +439,165 @@ func (h *ProductHandler) Delete() gin.HandlerFunc {
 	}
 }
 
+// Transition godoc
+// @Summary Change the lifecycle state of a product
+// @Tags Products
+// @Description Move a product to a new lifecycle state (draft, in_review, published, discontinued or archived)
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Param transition body domain.TransitionRequest true "target state"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/{id}/transitions [post]
+func (h *ProductHandler) Transition() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		stringId := c.Param("id")
+		id, err := strconv.Atoi(stringId)
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the target state from the request body
+		var transition domain.TransitionRequest
+		if err := c.ShouldBindJSON(&transition); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		// Moves the product to the target state
+		updatedProduct, err := h.service.Transition(id, transition.Status)
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			web.Failure(c, 404, err)
+			return
+		case errors.Is(err, product.ErrIllegalTransition):
+			web.Failure(c, 409, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, updatedProduct)
+	}
+}
+
 /*
-A function that checks if a given date string is a valid date. It returns true if the
-date string is a valid date and occurs after the current date. Otherwise, it returns false with
-an error.
+A function that translates a request body binding error into the error reported to the client.
+Malformed dates keep their specific error, any other problem is reported as invalid data.
 */
-func validateDate(date string) (bool, error) {
-	parsedDate, err := time.Parse("02/01/2006", date)
+func bindingError(err error) error {
+	if errors.Is(err, domain.ErrInvalidDate) {
+		return domain.ErrInvalidDate
+	}
+	return ErrInvalidData
+}
+
+/*
+A function that parses a period such as "30d" (days), "2w" (weeks) or "30" (days) into a number
+of days. Negative periods are invalid.
+*/
+func parseDays(value string) (int, error) {
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(value, "d"):
+		value = strings.TrimSuffix(value, "d")
+	case strings.HasSuffix(value, "w"):
+		value = strings.TrimSuffix(value, "w")
+		multiplier = 7
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, ErrInvalidDays
+	}
+	return n * multiplier, nil
+}
+
+/*
+Auxiliary function that filters the given products by the category in the "category" query
+parameter, including its descendants. If the category is invalid, it writes the failure response
+and returns an error.
+*/
+func (h *ProductHandler) inCategory(products []domain.Product, c *gin.Context) ([]domain.Product, error) {
+	if c.Query("category") == "" {
+		return products, nil
+	}
+
+	id, err := strconv.Atoi(c.Query("category"))
+	if err != nil {
+		web.Failure(c, 400, ErrInvalidCategory)
+		return nil, err
+	}
+	products, err = h.categories.InCategory(products, id)
 	if err != nil {
-		return false, errors.New("invalid expiration date format")
+		web.Failure(c, 404, err)
+		return nil, err
+	}
+	return products, nil
+}
+
+// Auxiliary function that applies the tags and tags_mode query parameters, if any, to a product listing.
+func (h *ProductHandler) withTags(products []domain.Product, c *gin.Context) ([]domain.Product, error) {
+	if c.Query("tags") == "" {
+		return products, nil
+	}
+	return h.tags.Tagged(products, strings.Split(c.Query("tags"), ","), c.Query("tags_mode"))
+}
+
+/*
+Auxiliary function that applies the attributes.{name} query parameters, if any, to a product listing.
+Products must match every attribute filter.
+*/
+func (h *ProductHandler) withAttributes(products []domain.Product, c *gin.Context) []domain.Product {
+	filters := map[string][]string{}
+	for key, values := range c.Request.URL.Query() {
+		if name, found := strings.CutPrefix(key, "attributes."); found && name != "" {
+			filters[name] = values
+		}
+	}
+	if len(filters) == 0 {
+		return products
 	}
 
-	if err == nil && parsedDate.Before(time.Now()) {
-		return false, errors.New("expiration date must be after current date")
+	filteredProducts := []domain.Product{}
+	for _, product := range products {
+		matches := true
+		for name, values := range filters {
+			for _, value := range values {
+				matches = matches && product.MatchesAttribute(name, value)
+			}
+		}
+		if matches {
+			filteredProducts = append(filteredProducts, product)
+		}
 	}
+	return filteredProducts
+}
 
-	return true, nil
+// Auxiliary function that applies the collapse_variants query parameter, if any, to a product listing.
+func (h *ProductHandler) collapsed(products []domain.Product, c *gin.Context) ([]domain.Product, error) {
+	if c.Query("collapse_variants") == "" {
+		return products, nil
+	}
+	collapse, err := strconv.ParseBool(c.Query("collapse_variants"))
+	if err != nil {
+		return nil, ErrInvalidFlag
+	}
+	if !collapse {
+		return products, nil
+	}
+	return h.service.CollapseVariants(products), nil
 }
 
 // Auxiliary function that checks if the given token is valid.
 func isAuthorized(c *gin.Context) error {
-	// Get the token from the header
-	token := c.GetHeader("token")
-
-	// Authentication
-	if token != os.Getenv("TOKEN") {
-		return errors.New("invalid token")
-	}
-	return nil
+	// Authentication, which also tells who makes the changes of the request
+	return middleware.Authorize(c)
 }
@@ -3,21 +3,40 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/soppibb/practica-go-web/cmd/server/middleware"
+	"github.com/soppibb/practica-go-web/internal/category"
+	"github.com/soppibb/practica-go-web/internal/currency"
 	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/order"
+	"github.com/soppibb/practica-go-web/internal/price"
 	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/promotion"
+	"github.com/soppibb/practica-go-web/internal/purchase"
+	"github.com/soppibb/practica-go-web/internal/recall"
+	"github.com/soppibb/practica-go-web/internal/reservation"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/internal/stocktake"
+	"github.com/soppibb/practica-go-web/internal/supplier"
+	"github.com/soppibb/practica-go-web/internal/tag"
 	"github.com/soppibb/practica-go-web/pkg/store"
 	"github.com/soppibb/practica-go-web/pkg/web"
 	"github.com/stretchr/testify/assert"
 )
 
-func createServerForTestProducts(token string) *gin.Engine {
+func createServerForTestProducts(t *testing.T, token string) *gin.Engine {
+	// Every store of the server lives in a temporary directory of the test
+	dir := t.TempDir()
+
 	// Token settings
 	if token != "" {
 		err := os.Setenv("TOKEN", token)
@@ -35,10 +54,77 @@ func createServerForTestProducts(token string) *gin.Engine {
 		panic(err)
 	}
 
-	// Create a new product handler
-	repository := product.NewRepository(products)
-	service := product.NewService(repository)
-	productHandler := NewProductHandler(service)
+	// Create a currency service from the rates copy, saving updates in a temporary file
+	rates, err := store.NewJsonDocumentStore[domain.ExchangeRates]("rates_copy.json").Load()
+	if err != nil {
+		panic(err)
+	}
+	ratesStore := store.NewJsonDocumentStore[domain.ExchangeRates](filepath.Join(dir, "rates_test.json"))
+	ratesRepository := currency.NewRepository(rates, ratesStore)
+
+	// Create a reservation repository without reservations
+	reservationStore := store.NewJsonDocumentStore[[]domain.Reservation](filepath.Join(dir, "reservations_test.json"))
+	reservationRepository := reservation.NewRepository(nil, reservationStore)
+
+	// Create a new product handler, with a recall service without recalls
+	productStore := store.NewJsonStore(filepath.Join(dir, "products_test.json"))
+	repository := product.NewRepository(products, productStore)
+	recallStore := store.NewJsonDocumentStore[[]domain.Recall](filepath.Join(dir, "recalls_test.json"))
+	recallService := recall.NewService(recall.NewRepository(nil, recallStore), repository)
+	priceHistory := price.NewHistory(nil, store.NewJsonLinesStore[domain.PriceChange](filepath.Join(dir, "price_history_test.jsonl")))
+	taxes, err := store.NewJsonDocumentStore[domain.TaxTable]("taxes_copy.json").Load()
+	if err != nil {
+		panic(err)
+	}
+	// Create a new category handler without categories
+	categoryStore := store.NewJsonDocumentStore[[]domain.Category](filepath.Join(dir, "categories_test.json"))
+	promotionStore := store.NewJsonDocumentStore[[]domain.Promotion](filepath.Join(dir, "promotions_test.json"))
+	promotionRepository := promotion.NewRepository(nil, promotionStore)
+	ratesService := currency.NewService(ratesRepository, repository, promotionRepository)
+	categoryService := category.NewService(category.NewRepository(nil, categoryStore), repository, nil, promotionRepository)
+	categoryHandler := NewCategoryHandler(categoryService)
+	promotionService := promotion.NewService(promotionRepository, time.UTC, ratesService, categoryService)
+	service := product.NewService(repository, time.UTC, ratesService, nil, promotionService, priceHistory, taxes, categoryService, reservationRepository, recallService)
+	// Create a new tag handler without created tags
+	tagStore := store.NewJsonDocumentStore[[]domain.Tag](filepath.Join(dir, "tags_test.json"))
+	tagService := tag.NewService(tag.NewRepository(nil, tagStore), repository)
+	tagHandler := NewTagHandler(tagService)
+	productHandler := NewProductHandler(service, categoryService, tagService)
+	priceHandler := NewPriceHandler(service)
+	variantHandler := NewVariantHandler(service)
+
+	// Create a new location handler without locations other than the default one
+	locationStore := store.NewJsonDocumentStore[[]domain.Location](filepath.Join(dir, "locations_test.json"))
+	locationService := location.NewService(location.NewRepository(nil, locationStore))
+	locationHandler := NewLocationHandler(locationService)
+
+	// Create a new stock handler
+	ledger := stock.NewLedger(nil, store.NewJsonLinesStore[domain.StockMovement](filepath.Join(dir, "stock_movements_test.jsonl")))
+	stockService := stock.NewService(service, ledger, locationService, time.UTC, recallService)
+	stockHandler := NewStockHandler(stockService)
+
+	// Create a new reservation handler
+	reservationHandler := NewReservationHandler(reservation.NewService(reservationRepository, service, stockService))
+
+	// Create a new stocktake handler without sessions
+	stocktakeStore := store.NewJsonDocumentStore[[]domain.StocktakeSession](filepath.Join(dir, "stocktakes_test.json"))
+	stocktakeHandler := NewStocktakeHandler(stocktake.NewService(stocktake.NewRepository(nil, stocktakeStore), repository, stockService))
+
+	// Create new supplier and purchase order handlers without suppliers nor orders
+	supplierRepository := supplier.NewRepository(nil, store.NewJsonDocumentStore[[]domain.Supplier](filepath.Join(dir, "suppliers_test.json")))
+	supplierHandler := NewSupplierHandler(supplier.NewService(supplierRepository, service))
+	purchaseOrderRepository := purchase.NewRepository(nil, store.NewJsonDocumentStore[[]domain.PurchaseOrder](filepath.Join(dir, "purchase_orders_test.json")))
+	purchaseOrderHandler := NewPurchaseOrderHandler(purchase.NewService(purchaseOrderRepository, repository, service, supplierRepository, stockService))
+
+	// Create a new order handler without orders
+	orderRepository := order.NewRepository(nil, store.NewJsonDocumentStore[[]domain.Order](filepath.Join(dir, "orders_test.json")))
+	orderHandler := NewOrderHandler(order.NewService(orderRepository, service, stockService, time.UTC))
+
+	// Create a new recall handler
+	recallHandler := NewRecallHandler(recallService)
+
+	// Create a new promotion handler
+	promotionHandler := NewPromotionHandler(promotionService)
 
 	// Define a new router
 	router := gin.New()
@@ -52,6 +138,9 @@ func createServerForTestProducts(token string) *gin.Engine {
 		productGroup.GET("/all", productHandler.GetAll())
 		productGroup.GET("/:id", productHandler.GetById())
 		productGroup.GET("/search", productHandler.GetByPriceGt())
+		productGroup.GET("/expiring", productHandler.GetExpiring())
+		productGroup.POST("/quote", priceHandler.Quote())
+		productGroup.GET("/:id/variants", variantHandler.GetVariants())
 	}
 
 	protectedProductGroup := generalGroup.Group("/products")
@@ -61,6 +150,112 @@ func createServerForTestProducts(token string) *gin.Engine {
 		protectedProductGroup.PUT("/:id", productHandler.FullUpdate())
 		protectedProductGroup.PATCH("/:id", productHandler.PartialUpdate())
 		protectedProductGroup.DELETE("/:id", productHandler.Delete())
+		protectedProductGroup.POST("/:id/transitions", productHandler.Transition())
+		protectedProductGroup.GET("/:id/prices", priceHandler.GetPrices())
+		protectedProductGroup.POST("/:id/prices", priceHandler.SetPrice())
+		protectedProductGroup.POST("/:id/variants", variantHandler.CreateVariant())
+		protectedProductGroup.POST("/:id/stock/adjust", stockHandler.Adjust())
+		protectedProductGroup.GET("/:id/stock/movements", stockHandler.GetMovements())
+		protectedProductGroup.POST("/:id/stock/transfer", stockHandler.Transfer())
+		protectedProductGroup.POST("/:id/lots", stockHandler.ReceiveLot())
+		protectedProductGroup.GET("/:id/lots", stockHandler.GetLots())
+		protectedProductGroup.DELETE("/:id/lots/:code", stockHandler.RemoveLot())
+		protectedProductGroup.POST("/:id/reservations", reservationHandler.Create())
+	}
+
+	reservationGroup := generalGroup.Group("/reservations")
+	reservationGroup.Use(middleware.TokenValidator())
+	{
+		reservationGroup.GET("/:id", reservationHandler.GetById())
+		reservationGroup.POST("/:id/confirm", reservationHandler.Confirm())
+		reservationGroup.POST("/:id/release", reservationHandler.Release())
+	}
+
+	generalGroup.GET("/locations", locationHandler.GetAll())
+	generalGroup.POST("/locations", middleware.TokenValidator(), locationHandler.Create())
+
+	stocktakeGroup := generalGroup.Group("/stocktakes")
+	stocktakeGroup.Use(middleware.TokenValidator())
+	{
+		stocktakeGroup.GET("", stocktakeHandler.GetAll())
+		stocktakeGroup.POST("", stocktakeHandler.Open())
+		stocktakeGroup.GET("/:id", stocktakeHandler.Review())
+		stocktakeGroup.POST("/:id/counts", stocktakeHandler.SubmitCounts())
+		stocktakeGroup.POST("/:id/commit", stocktakeHandler.Commit())
+		stocktakeGroup.POST("/:id/cancel", stocktakeHandler.Cancel())
+	}
+
+	supplierGroup := generalGroup.Group("/suppliers")
+	supplierGroup.Use(middleware.TokenValidator())
+	{
+		supplierGroup.GET("", supplierHandler.GetAll())
+		supplierGroup.POST("", supplierHandler.Create())
+		supplierGroup.GET("/:id", supplierHandler.GetById())
+		supplierGroup.PUT("/:id", supplierHandler.Update())
+		supplierGroup.POST("/:id/products", supplierHandler.LinkProduct())
+	}
+
+	purchaseOrderGroup := generalGroup.Group("/purchase-orders")
+	purchaseOrderGroup.Use(middleware.TokenValidator())
+	{
+		purchaseOrderGroup.GET("", purchaseOrderHandler.GetAll())
+		purchaseOrderGroup.POST("/proposals", purchaseOrderHandler.Propose())
+		purchaseOrderGroup.GET("/:id", purchaseOrderHandler.GetById())
+		purchaseOrderGroup.POST("/:id/approve", purchaseOrderHandler.Approve())
+		purchaseOrderGroup.POST("/:id/receive", purchaseOrderHandler.Receive())
+		purchaseOrderGroup.POST("/:id/cancel", purchaseOrderHandler.Cancel())
+	}
+
+	orderGroup := generalGroup.Group("/orders")
+	orderGroup.Use(middleware.TokenValidator())
+	{
+		orderGroup.GET("", orderHandler.GetAll())
+		orderGroup.POST("", orderHandler.Create())
+		orderGroup.GET("/:id", orderHandler.GetById())
+		orderGroup.POST("/:id/cancel", orderHandler.Cancel())
+	}
+
+	recallGroup := generalGroup.Group("/recalls")
+	recallGroup.Use(middleware.TokenValidator())
+	{
+		recallGroup.GET("", recallHandler.GetAll())
+		recallGroup.POST("", recallHandler.Create())
+		recallGroup.GET("/:id", recallHandler.GetById())
+		recallGroup.POST("/:id/close", recallHandler.Close())
+		recallGroup.GET("/:id/report", recallHandler.Report())
+	}
+
+	categoryGroup := generalGroup.Group("/categories")
+	{
+		categoryGroup.GET("", categoryHandler.GetAll())
+		categoryGroup.GET("/:id", categoryHandler.GetById())
+		categoryGroup.POST("", middleware.TokenValidator(), categoryHandler.Create())
+		categoryGroup.POST("/:id/move", middleware.TokenValidator(), categoryHandler.Move())
+		categoryGroup.DELETE("/:id", middleware.TokenValidator(), categoryHandler.Delete())
+		categoryGroup.GET("/:id/schema", categoryHandler.GetSchema())
+		categoryGroup.PUT("/:id/schema", middleware.TokenValidator(), categoryHandler.SetSchema())
+		categoryGroup.DELETE("/:id/schema", middleware.TokenValidator(), categoryHandler.DeleteSchema())
+		categoryGroup.POST("/:id/products", middleware.TokenValidator(), categoryHandler.AssignProduct())
+		categoryGroup.DELETE("/:id/products/:productId", middleware.TokenValidator(), categoryHandler.UnassignProduct())
+	}
+
+	tagGroup := generalGroup.Group("/tags")
+	{
+		tagGroup.GET("", tagHandler.GetAll())
+		tagGroup.GET("/:name", tagHandler.GetByName())
+		tagGroup.POST("", middleware.TokenValidator(), tagHandler.Create())
+		tagGroup.PUT("/:name", middleware.TokenValidator(), tagHandler.Rename())
+		tagGroup.POST("/:name/merge", middleware.TokenValidator(), tagHandler.Merge())
+		tagGroup.DELETE("/:name", middleware.TokenValidator(), tagHandler.Delete())
+	}
+
+	promotionGroup := generalGroup.Group("/promotions")
+	promotionGroup.Use(middleware.TokenValidator())
+	{
+		promotionGroup.GET("", promotionHandler.GetAll())
+		promotionGroup.POST("", promotionHandler.Create())
+		promotionGroup.GET("/:id", promotionHandler.GetById())
+		promotionGroup.DELETE("/:id", promotionHandler.Delete())
 	}
 
 	return router
@@ -76,8 +271,23 @@ func createRequestTest(method string, url string, body string) (*http.Request, *
 	return request, httptest.NewRecorder()
 }
 
+// Auxiliary function that serves an authorized request and returns the status code and the response data.
+func serveAuthorized(router *gin.Engine, method string, url string, body string, data interface{}) int {
+	request, responseRecorder := createRequestTest(method, url, body)
+	request.Header.Add("token", "12345")
+	router.ServeHTTP(responseRecorder, request)
+
+	if data != nil {
+		response := web.Response{Data: data}
+		if err := json.Unmarshal(responseRecorder.Body.Bytes(), &response); err != nil {
+			panic(err)
+		}
+	}
+	return responseRecorder.Code
+}
+
 func TestProductHandler_GetAll_OK(t *testing.T) {
-	router := createServerForTestProducts("")
+	router := createServerForTestProducts(t, "")
 	request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/all", "")
 
 	// Expected response
@@ -89,6 +299,11 @@ func TestProductHandler_GetAll_OK(t *testing.T) {
 	if err != nil {
 		panic(err)
 	}
+	// Without reservations, every unit is available
+	for i := range expectedProductsData {
+		available := expectedProductsData[i].Quantity
+		expectedProductsData[i].AvailableQuantity = &available
+	}
 	expectedResponse.Data = expectedProductsData
 
 	// Actual response
@@ -103,7 +318,7 @@ func TestProductHandler_GetAll_OK(t *testing.T) {
 }
 
 func TestProductHandler_GetById_OK(t *testing.T) {
-	router := createServerForTestProducts("")
+	router := createServerForTestProducts(t, "")
 	request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/1", "")
 
 	// Expected response
@@ -115,6 +330,8 @@ func TestProductHandler_GetById_OK(t *testing.T) {
 	if err != nil {
 		panic(err)
 	}
+	available := expectedProductsData.Quantity
+	expectedProductsData.AvailableQuantity = &available
 	expectedResponse.Data = expectedProductsData
 
 	// Actual response
@@ -130,15 +347,18 @@ func TestProductHandler_GetById_OK(t *testing.T) {
 
 func TestProductHandler_Create_OK(t *testing.T) {
 	// Expected response
+	available := 100
 	expectedResponse := web.Response{
 		Data: domain.Product{
-			Id:          501,
-			Name:        "New Product",
-			Quantity:    100,
-			CodeValue:   "NewCode123",
-			IsPublished: true,
-			Expiration:  "25/10/2030",
-			Price:       900,
+			Id:                501,
+			Name:              "New Product",
+			Quantity:          100,
+			AvailableQuantity: &available,
+			CodeValue:         "NewCode123",
+			IsPublished:       true,
+			Status:            domain.StatusPublished,
+			Expiration:        domain.NewDate(2030, time.October, 25),
+			Price:             domain.NewMoney(domain.NewDecimal(900), "USD"),
 		},
 	}
 	expectedProductData, err := json.Marshal(expectedResponse.Data)
@@ -146,7 +366,7 @@ func TestProductHandler_Create_OK(t *testing.T) {
 		panic(err)
 	}
 
-	router := createServerForTestProducts("12345")
+	router := createServerForTestProducts(t, "12345")
 	request, responseRecorder := createRequestTest(
 		http.MethodPost,
 		"https://localhost:8080/api/v1/products/new",
@@ -168,7 +388,7 @@ func TestProductHandler_Create_OK(t *testing.T) {
 }
 
 func TestProductHandler_Delete_OK(t *testing.T) {
-	router := createServerForTestProducts("12345")
+	router := createServerForTestProducts(t, "12345")
 	request, responseRecorder := createRequestTest(
 		http.MethodDelete,
 		"https://localhost:8080/api/v1/products/1",
@@ -195,7 +415,7 @@ func TestProductHandler_BadRequest(t *testing.T) {
 		http.MethodDelete,
 	}
 	// Create a new router
-	router := createServerForTestProducts("12345")
+	router := createServerForTestProducts(t, "12345")
 
 	// Iterate through the http methods slice
 	for _, method := range httpMethods {
@@ -232,7 +452,7 @@ func TestProductHandler_NotFound(t *testing.T) {
 		http.MethodDelete,
 	}
 	// Create a new router
-	router := createServerForTestProducts("12345")
+	router := createServerForTestProducts(t, "12345")
 
 	// Create a body for the http methods that requires one
 	newProduct := domain.Product{
@@ -240,8 +460,8 @@ func TestProductHandler_NotFound(t *testing.T) {
 		Quantity:    100,
 		CodeValue:   "NewCode123",
 		IsPublished: true,
-		Expiration:  "25/10/2030",
-		Price:       900,
+		Expiration:  domain.NewDate(2030, time.October, 25),
+		Price:       domain.NewMoney(domain.NewDecimal(900), "USD"),
 	}
 	bodyProduct, err := json.Marshal(newProduct)
 	if err != nil {
@@ -283,7 +503,7 @@ func TestProductHandler_Unauthorized(t *testing.T) {
 			http.MethodDelete,
 		}
 		// Create a new router
-		router := createServerForTestProducts("12345")
+		router := createServerForTestProducts(t, "12345")
 
 		// Create a body for the http methods that requires one
 		newProduct := domain.Product{
@@ -291,8 +511,8 @@ func TestProductHandler_Unauthorized(t *testing.T) {
 			Quantity:    100,
 			CodeValue:   "NewCode123",
 			IsPublished: true,
-			Expiration:  "25/10/2030",
-			Price:       900,
+			Expiration:  domain.NewDate(2030, time.October, 25),
+			Price:       domain.NewMoney(domain.NewDecimal(900), "USD"),
 		}
 		bodyProduct, err := json.Marshal(newProduct)
 		if err != nil {
@@ -325,7 +545,7 @@ func TestProductHandler_Unauthorized(t *testing.T) {
 	})
 	t.Run("Unauthorized POST", func(t *testing.T) {
 		// Create a new router
-		router := createServerForTestProducts("12345")
+		router := createServerForTestProducts(t, "12345")
 
 		// Create a body for the POST request
 		newProduct := domain.Product{
@@ -333,8 +553,8 @@ func TestProductHandler_Unauthorized(t *testing.T) {
 			Quantity:    100,
 			CodeValue:   "NewCode123",
 			IsPublished: true,
-			Expiration:  "25/10/2030",
-			Price:       900,
+			Expiration:  domain.NewDate(2030, time.October, 25),
+			Price:       domain.NewMoney(domain.NewDecimal(900), "USD"),
 		}
 		bodyProduct, err := json.Marshal(newProduct)
 		if err != nil {
@@ -363,3 +583,271 @@ func TestProductHandler_Unauthorized(t *testing.T) {
 		assert.Equal(t, http.StatusText(http.StatusUnauthorized), actualResponse["code"])
 	})
 }
+
+func TestProductHandler_Create_LegacyDate(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	request, responseRecorder := createRequestTest(
+		http.MethodPost,
+		"https://localhost:8080/api/v1/products/new",
+		`{"name":"Legacy","quantity":10,"code_value":"Legacy123","expiration":"25/10/2030","price":10}`,
+	)
+	request.Header.Add("token", "12345")
+
+	// Actual response
+	router.ServeHTTP(responseRecorder, request)
+	actualResponse := map[string]map[string]interface{}{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+	if err != nil {
+		panic(err)
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusCreated, responseRecorder.Code)
+	assert.Equal(t, "2030-10-25", actualResponse["data"]["expiration"])
+}
+
+func TestProductHandler_Create_InvalidDate(t *testing.T) {
+	// Define a slice of invalid expiration dates
+	expirations := []string{"2030-13-45", "yesterday", "2001-01-01"}
+	router := createServerForTestProducts(t, "12345")
+
+	// Iterate through the expiration dates slice
+	for _, expiration := range expirations {
+		request, responseRecorder := createRequestTest(
+			http.MethodPost,
+			"https://localhost:8080/api/v1/products/new",
+			`{"name":"Invalid","quantity":10,"code_value":"Invalid123","expiration":"`+expiration+`","price":10}`,
+		)
+		request.Header.Add("token", "12345")
+		router.ServeHTTP(responseRecorder, request)
+
+		// Assertions
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	}
+}
+
+func TestProductHandler_GetByPriceGt_Exact(t *testing.T) {
+	router := createServerForTestProducts(t, "")
+	request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/search?priceGt=71.42", "")
+
+	// Actual response
+	router.ServeHTTP(responseRecorder, request)
+	actualResponse := map[string][]domain.Product{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+	if err != nil {
+		panic(err)
+	}
+
+	// Assertions
+	limit := domain.MustParseDecimal("71.42")
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	for _, product := range actualResponse["data"] {
+		assert.True(t, product.Price.Amount.GreaterThan(limit))
+		assert.NotEqual(t, 1, product.Id)
+	}
+}
+
+func TestProductHandler_GetById_Currency(t *testing.T) {
+	t.Run("Converted price", func(t *testing.T) {
+		router := createServerForTestProducts(t, "")
+		request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/1?currency=CLP", "")
+
+		// Actual response
+		router.ServeHTTP(responseRecorder, request)
+		actualResponse := map[string]domain.Product{}
+		err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+		if err != nil {
+			panic(err)
+		}
+
+		// Assertions (71.42 USD * 940, rounded to whole pesos)
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+		assert.Equal(t, domain.NewMoney(domain.NewDecimal(67135), "CLP"), actualResponse["data"].Price)
+	})
+	t.Run("Unsupported currency", func(t *testing.T) {
+		router := createServerForTestProducts(t, "")
+		request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/1?currency=XYZ", "")
+
+		// Serve the request
+		router.ServeHTTP(responseRecorder, request)
+
+		// Assertions
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	})
+	t.Run("Unsupported product currency", func(t *testing.T) {
+		router := createServerForTestProducts(t, "12345")
+		productsUrl := "https://localhost:8080/api/v1/products"
+
+		// Products priced in a currency without an exchange rate are rejected, so listings can always convert them
+		assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/new", `{"name":"Exotic","quantity":10,"code_value":"Exotic123","expiration":"2030-10-25","price":10,"currency":"XYZ"}`, nil))
+		assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"price":10,"currency":"XYZ"}`, nil))
+		assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/1/prices", `{"price":10,"currency":"XYZ"}`, nil))
+		assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?currency=CLP", "", nil))
+	})
+}
+
+func TestProductHandler_Transition(t *testing.T) {
+	t.Run("Allowed transition", func(t *testing.T) {
+		router := createServerForTestProducts(t, "12345")
+		request, responseRecorder := createRequestTest(
+			http.MethodPost,
+			"https://localhost:8080/api/v1/products/1/transitions",
+			`{"status":"discontinued"}`,
+		)
+		request.Header.Add("token", "12345")
+
+		// Actual response
+		router.ServeHTTP(responseRecorder, request)
+		actualResponse := map[string]domain.Product{}
+		err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+		if err != nil {
+			panic(err)
+		}
+
+		// Assertions
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+		assert.Equal(t, domain.StatusDiscontinued, actualResponse["data"].Status)
+		assert.False(t, actualResponse["data"].IsPublished)
+	})
+	t.Run("Illegal transition", func(t *testing.T) {
+		router := createServerForTestProducts(t, "12345")
+		request, responseRecorder := createRequestTest(
+			http.MethodPost,
+			"https://localhost:8080/api/v1/products/1/transitions",
+			`{"status":"archived"}`,
+		)
+		request.Header.Add("token", "12345")
+
+		// Serve the request
+		router.ServeHTTP(responseRecorder, request)
+
+		// Assertions
+		assert.Equal(t, http.StatusConflict, responseRecorder.Code)
+	})
+	t.Run("Legacy is_published flag", func(t *testing.T) {
+		router := createServerForTestProducts(t, "12345")
+		productsUrl := "https://localhost:8080/api/v1/products"
+
+		// New products start as drafts or published
+		assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/new", `{"name":"Archived","quantity":10,"code_value":"Archived123","expiration":"2030-10-25","price":10,"status":"archived"}`, nil))
+
+		// Unpublishing moves a published product back to draft, but drafts must be reviewed before being published
+		var product domain.Product
+		assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"is_published":false}`, &product))
+		assert.Equal(t, domain.StatusDraft, product.Status)
+		assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"is_published":true}`, nil))
+
+		// A discontinued product can be published again
+		assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, productsUrl+"/5/transitions", `{"status":"discontinued"}`, nil))
+		assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/5", `{"is_published":true}`, &product))
+		assert.Equal(t, domain.StatusPublished, product.Status)
+		assert.True(t, product.IsPublished)
+	})
+}
+
+func TestProductHandler_Update_Quantity(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productsUrl := "https://localhost:8080/api/v1/products"
+
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/5", `{"is_published":true,"allow_backorder":true}`, &product))
+	quantity := product.Quantity
+
+	// Quantities change through stock adjustments, and other updates keep the backorder setting
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/5", fmt.Sprintf(`{"is_published":true,"quantity":%d}`, quantity+1), nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/5", `{"is_published":true,"name":"Renamed"}`, &product))
+	assert.Equal(t, quantity, product.Quantity)
+	assert.True(t, product.AllowBackorder)
+
+	// A full update must give the current quantity
+	body := fmt.Sprintf(`{"name":"Renamed","quantity":%d,"code_value":"%s","is_published":true,"expiration":"2030-10-25","price":10}`, quantity, product.CodeValue)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPut, productsUrl+"/5", body, &product))
+	assert.Equal(t, "10", product.Price.Amount.String())
+}
+
+func TestProductHandler_Update_ReorderPoint(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productUrl := "https://localhost:8080/api/v1/products/5"
+
+	// A reorder point is kept by updates that do not give one, and zero clears it
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productUrl, `{"is_published":true,"reorder_point":50}`, &product))
+	assert.Equal(t, 50, product.ReorderPoint)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productUrl, `{"is_published":true,"name":"Vanilla"}`, &product))
+	assert.Equal(t, 50, product.ReorderPoint)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productUrl, `{"is_published":true,"reorder_point":0}`, &product))
+	assert.Equal(t, 0, product.ReorderPoint)
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productUrl, `{"is_published":true,"reorder_point":-1}`, nil))
+}
+
+func TestProductHandler_GetAll_PublicationWindow(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+
+	// Create a product that goes live tomorrow
+	publishAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	request, responseRecorder := createRequestTest(
+		http.MethodPost,
+		"https://localhost:8080/api/v1/products/new",
+		`{"name":"Campaign","quantity":10,"code_value":"Campaign123","is_published":true,"expiration":"2030-10-25","price":10,"publish_at":"`+publishAt+`"}`,
+	)
+	request.Header.Add("token", "12345")
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusCreated, responseRecorder.Code)
+
+	// List all the products
+	request, responseRecorder = createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/all", "")
+	router.ServeHTTP(responseRecorder, request)
+	actualResponse := map[string][]domain.Product{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+	if err != nil {
+		panic(err)
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	for _, product := range actualResponse["data"] {
+		assert.NotEqual(t, "Campaign123", product.CodeValue)
+	}
+}
+
+func TestProductHandler_GetExpiring(t *testing.T) {
+	t.Run("Expiring within the period", func(t *testing.T) {
+		router := createServerForTestProducts(t, "12345")
+
+		// Create a product that expires in ten days
+		expiration := domain.Today(time.UTC).AddDays(10).String()
+		request, responseRecorder := createRequestTest(
+			http.MethodPost,
+			"https://localhost:8080/api/v1/products/new",
+			`{"name":"Yogurt","quantity":10,"code_value":"Yogurt123","expiration":"`+expiration+`","price":10}`,
+		)
+		request.Header.Add("token", "12345")
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, http.StatusCreated, responseRecorder.Code)
+
+		// List the products expiring within a week and within a month
+		for within, expectedCount := range map[string]int{"7d": 0, "30d": 1} {
+			request, responseRecorder = createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/expiring?within="+within, "")
+			router.ServeHTTP(responseRecorder, request)
+			actualResponse := map[string][]domain.Product{}
+			err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+			if err != nil {
+				panic(err)
+			}
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+			assert.Len(t, actualResponse["data"], expectedCount)
+		}
+	})
+	t.Run("Invalid period", func(t *testing.T) {
+		router := createServerForTestProducts(t, "")
+		request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/expiring?within=soon", "")
+
+		// Serve the request
+		router.ServeHTTP(responseRecorder, request)
+
+		// Assertions
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	})
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/promotion"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// PromotionHandler is a handler for the promotion endpoints.
+type PromotionHandler struct {
+	service promotion.Service
+}
+
+// The NewPromotionHandler function returns a new PromotionHandler that uses the provided service.
+func NewPromotionHandler(service promotion.Service) *PromotionHandler {
+	return &PromotionHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the promotions
+// @Tags Promotions
+// @Description List every promotion, running or not
+// @Produce json
+// @Param token header string true "Token"
+// @Success 200 {object} web.Response
+// @Router /promotions [get]
+func (h *PromotionHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// GetById godoc
+// @Summary Get a promotion
+// @Tags Promotions
+// @Description Get a specific promotion based on its ID
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /promotions/{id} [get]
+func (h *PromotionHandler) GetById() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		targetPromotion, err := h.service.GetById(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, targetPromotion)
+	}
+}
+
+// Create godoc
+// @Summary Create a promotion
+// @Tags Promotions
+// @Description Create a discount rule: a percentage or a fixed amount off, "buy X pay Y" or a percentage off products about to expire. It applies to the given products (or to all of them) inside its validity window, ordered by priority
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param promotion body domain.Promotion true "promotion"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Router /promotions [post]
+func (h *PromotionHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the promotion from the request body
+		var request domain.Promotion
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		newPromotion, err := h.service.Create(request)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 201, newPromotion)
+	}
+}
+
+// Delete godoc
+// @Summary Delete a promotion
+// @Tags Promotions
+// @Description Delete permanently a promotion, so it stops applying to prices
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Promotion ID"
+// @Success 204 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /promotions/{id} [delete]
+func (h *PromotionHandler) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		if err := h.service.Delete(id); err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
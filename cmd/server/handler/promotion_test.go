@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromotionHandler_EffectivePrice(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	promotionsUrl := "https://localhost:8080/api/v1/promotions"
+
+	// Rules are validated
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"Too much","type":"percentage","value":150}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"Buy 2 pay 2","type":"quantity","buy_quantity":2,"pay_quantity":2}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"Unknown","type":"gift","value":1}`, nil))
+
+	// Product 2 costs 352.79
+	var newPromotion domain.Promotion
+	status := serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"20% off pineapple","type":"percentage","value":20,"product_ids":[2],"priority":10}`, &newPromotion)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, 1, newPromotion.Id)
+
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2", "", &product))
+	assert.Equal(t, "282.23", product.EffectivePrice.Amount.String())
+	assert.Equal(t, []domain.AppliedPromotion{{Id: 1, Name: "20% off pineapple", Type: domain.PromotionPercentage, Discount: product.Promotions[0].Discount}}, product.Promotions)
+	assert.Equal(t, "70.56", product.Promotions[0].Discount.Amount.String())
+
+	// A stackable promotion with a higher priority leaves out the non-stackable one, but not other stackable ones
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"10 off","type":"fixed","value":10,"code_values":["M4637"],"priority":20,"stackable":true}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"Extra 10%","type":"percentage","value":10,"product_ids":[2],"priority":5,"stackable":true}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2", "", &product))
+	assert.Equal(t, "308.51", product.EffectivePrice.Amount.String())
+	assert.Len(t, product.Promotions, 2)
+	assert.Equal(t, 2, product.Promotions[0].Id)
+	assert.Equal(t, 3, product.Promotions[1].Id)
+
+	// Promotions outside their validity window do not apply
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"Past","type":"fixed","value":1,"product_ids":[5],"ends_at":"2020-01-01T00:00:00Z"}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/5", "", &product))
+	assert.Nil(t, product.EffectivePrice)
+	assert.Empty(t, product.Promotions)
+
+	// Expiration-based promotions apply only to products about to expire, and not to expired ones
+	expiration := time.Now().UTC().AddDate(0, 0, 3).Format("2006-01-02")
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/new",
+		`{"name":"Fresh Milk","quantity":10,"code_value":"MILK1","is_published":true,"expiration":"`+expiration+`","price":10}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"Expiring soon","type":"expiration","value":50,"within_days":7}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/501", "", &product))
+	assert.Equal(t, "5", product.EffectivePrice.Amount.String())
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/5", "", &product))
+	assert.Nil(t, product.EffectivePrice)
+
+	// Deleted promotions stop applying
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, promotionsUrl+"/5", "", nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodGet, promotionsUrl+"/5", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/501", "", &product))
+	assert.Nil(t, product.EffectivePrice)
+}
+
+func TestPromotionHandler_CurrencyAndCategory(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	promotionsUrl := "https://localhost:8080/api/v1/promotions"
+	categoriesUrl := "https://localhost:8080/api/v1/categories"
+
+	// Fixed discounts must be in a known currency, and promotions must target known categories
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"10 off","type":"fixed","value":10,"currency":"XYZ"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"20% off dairy","type":"percentage","value":20,"category_ids":[1]}`, nil))
+
+	// A fixed discount in pesos is converted to the dollars of product 2, which costs 352.79
+	var newPromotion domain.Promotion
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"940 off","type":"fixed","value":940,"currency":"CLP","product_ids":[2]}`, &newPromotion))
+	assert.Equal(t, "CLP", newPromotion.Currency)
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2", "", &product))
+	assert.Equal(t, "351.79", product.EffectivePrice.Amount.String())
+
+	// A category promotion applies to the products of its subcategories, such as product 5, which costs 839.02
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Dairy"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, categoriesUrl, `{"name":"Cheese","parent_id":1}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, categoriesUrl+"/2/products", `{"product_id":5}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, promotionsUrl, `{"name":"20% off dairy","type":"percentage","value":20,"category_ids":[1]}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/5", "", &product))
+	assert.Equal(t, "671.22", product.EffectivePrice.Amount.String())
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/1", "", &product))
+	assert.Nil(t, product.EffectivePrice)
+}
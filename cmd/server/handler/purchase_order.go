@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/purchase"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// PurchaseOrderHandler is a handler for the purchase order endpoints.
+type PurchaseOrderHandler struct {
+	service purchase.Service
+}
+
+// The NewPurchaseOrderHandler function returns a new PurchaseOrderHandler that uses the provided service.
+func NewPurchaseOrderHandler(service purchase.Service) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the purchase orders
+// @Tags Purchase orders
+// @Description List every purchase order
+// @Produce json
+// @Param token header string true "Token"
+// @Success 200 {object} web.Response
+// @Router /purchase-orders [get]
+func (h *PurchaseOrderHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// GetById godoc
+// @Summary Get a purchase order
+// @Tags Purchase orders
+// @Description Get a specific purchase order based on its ID
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetById() gin.HandlerFunc {
+	return h.apply(h.service.GetById)
+}
+
+// Propose godoc
+// @Summary Propose purchase orders
+// @Tags Purchase orders
+// @Description Propose a purchase order per supplier for every product below its reorder point that is not already ordered. Bundles are restocked through their components
+// @Produce json
+// @Param token header string true "Token"
+// @Success 201 {object} web.Response
+// @Failure 500 {object} web.ErrorResponse
+// @Router /purchase-orders/proposals [post]
+func (h *PurchaseOrderHandler) Propose() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orders, err := h.service.Propose(time.Now())
+		if err != nil {
+			web.Failure(c, 500, err)
+			return
+		}
+
+		web.Success(c, 201, orders)
+	}
+}
+
+// Approve godoc
+// @Summary Approve a purchase order
+// @Tags Purchase orders
+// @Description Approve a proposed purchase order, expecting it after the supplier lead time
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /purchase-orders/{id}/approve [post]
+func (h *PurchaseOrderHandler) Approve() gin.HandlerFunc {
+	return h.apply(h.service.Approve)
+}
+
+// Receive godoc
+// @Summary Receive a purchase order
+// @Tags Purchase orders
+// @Description Add the units of an approved purchase order to stock and close it. Lot-tracked products need the lot the units are received in
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Purchase order ID"
+// @Param receipt body domain.ReceiveRequest false "location and lots"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /purchase-orders/{id}/receive [post]
+func (h *PurchaseOrderHandler) Receive() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// The body is optional
+		var request domain.ReceiveRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				web.Failure(c, 400, bindingError(err))
+				return
+			}
+		}
+
+		order, err := h.service.Receive(id, request)
+		if err != nil {
+			purchaseOrderFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, order)
+	}
+}
+
+// Cancel godoc
+// @Summary Cancel a purchase order
+// @Tags Purchase orders
+// @Description Cancel a purchase order that has not been received
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /purchase-orders/{id}/cancel [post]
+func (h *PurchaseOrderHandler) Cancel() gin.HandlerFunc {
+	return h.apply(h.service.Cancel)
+}
+
+// Auxiliary function that builds a handler applying an operation to the purchase order in the URL.
+func (h *PurchaseOrderHandler) apply(operation func(id int) (domain.PurchaseOrder, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		order, err := operation(id)
+		if err != nil {
+			purchaseOrderFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, order)
+	}
+}
+
+// Auxiliary function that emits the failure response of a purchase order operation.
+func purchaseOrderFailure(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, purchase.ErrNotFound):
+		web.Failure(c, 404, err)
+	case errors.Is(err, purchase.ErrIllegalStatus):
+		web.Failure(c, 409, err)
+	default:
+		stockFailure(c, err)
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurchaseOrderHandler_ProposeAndReceive(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+
+	// A supplier provides products 1 (439 units) and 2 (345 units), both below their new reorder points
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/suppliers", `{"name":"Fresh Farms","lead_time_days":7}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/suppliers/2/products", `{"product_id":1}`, nil))
+	for _, id := range []string{"1", "2"} {
+		assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/suppliers/1/products", `{"product_id":`+id+`}`, nil))
+	}
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, "https://localhost:8080/api/v1/products/1", `{"reorder_point":500}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, "https://localhost:8080/api/v1/products/2", `{"reorder_point":400}`, nil))
+
+	// 100 of the units of product 2 are held, so they are not counted as available
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/reservations", `{"quantity":100}`, nil))
+
+	// The proposal orders up to twice the reorder point, and is not repeated while it is open
+	var orders []domain.PurchaseOrder
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/purchase-orders/proposals", "", &orders))
+	assert.Len(t, orders, 1)
+	assert.Equal(t, []domain.PurchaseOrderLine{{ProductId: 1, CodeValue: "S82254D", Quantity: 561}, {ProductId: 2, CodeValue: "M4637", Quantity: 555}}, orders[0].Lines)
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/purchase-orders/proposals", "", &orders))
+	assert.Len(t, orders, 0)
+
+	// Only approved orders can be received
+	var order domain.PurchaseOrder
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/purchase-orders/1/receive", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/purchase-orders/1/approve", "", &order))
+	assert.Equal(t, 7*24.0, order.ExpectedAt.Sub(*order.ApprovedAt).Hours())
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/purchase-orders/1/receive", "", &order))
+	assert.Equal(t, domain.PurchaseOrderReceived, order.Status)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/purchase-orders/1/cancel", "", nil))
+
+	// The received units are in stock
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/1", "", &product))
+	assert.Equal(t, 1000, product.Quantity)
+	assert.Equal(t, 1, product.SupplierId)
+}
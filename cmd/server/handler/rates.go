@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/currency"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// RatesHandler is a handler for the exchange rates endpoints.
+type RatesHandler struct {
+	service currency.Service
+}
+
+// The NewRatesHandler function returns a new RatesHandler that uses the provided service.
+func NewRatesHandler(service currency.Service) *RatesHandler {
+	return &RatesHandler{
+		service: service,
+	}
+}
+
+// Get godoc
+// @Summary Get the exchange rates
+// @Tags Rates
+// @Description Get the currency conversion table and the rounding rules of every currency
+// @Produce json
+// @Success 200 {object} web.Response
+// @Router /rates [get]
+func (h *RatesHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetRates())
+	}
+}
+
+// Update godoc
+// @Summary Update the exchange rates
+// @Tags Rates
+// @Description Replace the currency conversion table and the rounding rules of every currency. Currencies products or fixed promotions are priced in cannot be removed
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param rates body domain.ExchangeRates true "exchange rates"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 401 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /rates [put]
+func (h *RatesHandler) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the rates from the request body
+		var rates domain.ExchangeRates
+		if err := c.ShouldBindJSON(&rates); err != nil {
+			web.Failure(c, 400, currency.ErrInvalidRates)
+			return
+		}
+
+		// Store the new rates
+		updatedRates, err := h.service.UpdateRates(rates)
+		if errors.Is(err, currency.ErrCurrencyInUse) {
+			web.Failure(c, 409, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, updatedRates)
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/recall"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// recallReportHeader lists the columns of the recall report CSV.
+var recallReportHeader = []string{"recall_id", "product_id", "code_value", "name", "lot", "expiration", "quantity"}
+
+// RecallHandler is a handler for the product recall endpoints.
+type RecallHandler struct {
+	service recall.Service
+}
+
+// The NewRecallHandler function returns a new RecallHandler that uses the provided service.
+func NewRecallHandler(service recall.Service) *RecallHandler {
+	return &RecallHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the recalls
+// @Tags Recalls
+// @Description List every product recall, active or closed
+// @Produce json
+// @Param token header string true "Token"
+// @Success 200 {object} web.Response
+// @Router /recalls [get]
+func (h *RecallHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// GetById godoc
+// @Summary Get a recall
+// @Tags Recalls
+// @Description Get a specific recall based on its ID
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Recall ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /recalls/{id} [get]
+func (h *RecallHandler) GetById() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		targetRecall, err := h.service.GetById(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, targetRecall)
+	}
+}
+
+// Create godoc
+// @Summary Open a recall
+// @Tags Recalls
+// @Description Open a recall of a product by its code value, optionally narrowed to some of its lots and to a range of expiration dates. Recalled stock is blocked for sale and transfers, and excluded from the available quantity
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param recall body domain.RecallRequest true "recall"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /recalls [post]
+func (h *RecallHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the recall from the request body
+		var request domain.RecallRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		newRecall, err := h.service.Create(request)
+		if errors.Is(err, product.ErrNotFound) || errors.Is(err, domain.ErrLotNotFound) {
+			web.Failure(c, 404, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 201, newRecall)
+	}
+}
+
+// Close godoc
+// @Summary Close a recall
+// @Tags Recalls
+// @Description Close an active recall, releasing the stock it blocked
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Recall ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /recalls/{id}/close [post]
+func (h *RecallHandler) Close() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		closedRecall, err := h.service.Close(id)
+		switch {
+		case errors.Is(err, recall.ErrNotFound):
+			web.Failure(c, 404, err)
+			return
+		case errors.Is(err, recall.ErrClosed):
+			web.Failure(c, 409, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, closedRecall)
+	}
+}
+
+// Report godoc
+// @Summary Export a recall report
+// @Tags Recalls
+// @Description Export the stock affected by a recall as CSV, one row per lot (or per product if it is not lot-tracked)
+// @Produce text/csv
+// @Param token header string true "Token"
+// @Param id path int true "Recall ID"
+// @Success 200 {string} string "CSV report"
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /recalls/{id}/report [get]
+func (h *RecallHandler) Report() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		items, err := h.service.Report(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=recall-%d.csv", id))
+		c.Status(200)
+
+		writer := csv.NewWriter(c.Writer)
+		_ = writer.Write(recallReportHeader)
+		for _, item := range items {
+			_ = writer.Write([]string{
+				strconv.Itoa(item.RecallId),
+				strconv.Itoa(item.ProductId),
+				item.CodeValue,
+				item.Name,
+				item.Lot,
+				item.Expiration.String(),
+				strconv.Itoa(item.Quantity),
+			})
+		}
+		writer.Flush()
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecallHandler_Lots(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	adjustUrl := "https://localhost:8080/api/v1/products/2/stock/adjust"
+
+	// Product 2 gets an "initial" lot (345 units) plus lots A and B
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/lots", `{"code":"A","quantity":10,"expiration":"2030-01-01"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/lots", `{"code":"B","quantity":5,"expiration":"2029-06-01"}`, nil))
+
+	// The "initial" lot has expired, so it is written off
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, adjustUrl, `{"delta":-345,"reason":"shrinkage","lot":"initial"}`, nil))
+
+	// Only lots the product has can be recalled
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/recalls", `{"code_value":"M4637","lots":["C"],"reason":"Supplier notice"}`, nil))
+
+	// Recall lot B
+	var newRecall domain.Recall
+	status := serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/recalls", `{"code_value":"M4637","lots":["B"],"reason":"Supplier notice"}`, &newRecall)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, domain.RecallActive, newRecall.Status)
+
+	// Recalled stock is not available and cannot be sold
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2", "", &product))
+	assert.Equal(t, 15, product.Quantity)
+	assert.Equal(t, 10, *product.AvailableQuantity)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, adjustUrl, `{"delta":-1,"reason":"sale","lot":"B"}`, nil))
+
+	// First-expired-first-out sales skip the recalled lot, but shrinkage can take it
+	var movement domain.StockMovement
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, adjustUrl, `{"delta":-5,"reason":"sale"}`, &movement))
+	assert.Equal(t, []domain.LotMovement{{Code: "A", Delta: -5}}, movement.Lots)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, adjustUrl, `{"delta":-6,"reason":"sale"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, adjustUrl, `{"delta":-1,"reason":"shrinkage","lot":"B"}`, nil))
+
+	// The report lists the recalled lot
+	request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/recalls/1/report", "")
+	request.Header.Add("token", "12345")
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, "text/csv", responseRecorder.Header().Get("Content-Type"))
+	assert.Equal(t, "recall_id,product_id,code_value,name,lot,expiration,quantity\n1,2,M4637,\"Pineapple - Canned, Rings\",B,2029-06-01,4\n", responseRecorder.Body.String())
+
+	// Closing the recall releases the stock
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/recalls/1/close", "", nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/recalls/1/close", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2", "", &product))
+	assert.Equal(t, product.Quantity, *product.AvailableQuantity)
+}
+
+func TestRecallHandler_ExpirationRange(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+
+	// Product 1 is not lot-tracked and expires on 2021-12-15
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/recalls", `{"code_value":"S82254D","expiration_from":"2021-12-31","expiration_to":"2021-12-01"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/recalls", `{"code_value":"S82254D","expiration_from":"2021-12-01","expiration_to":"2021-12-31"}`, nil))
+
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/1", "", &product))
+	assert.Equal(t, 0, *product.AvailableQuantity)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/1/stock/adjust", `{"delta":-1,"reason":"sale"}`, nil))
+
+	// Nor moved to another location
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/locations", `{"code":"north","name":"North warehouse"}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/1/stock/transfer", `{"from":"main","to":"north","quantity":1}`, nil))
+}
+
+func TestRecallHandler_UnknownProduct(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/recalls", `{"code_value":"UNKNOWN","reason":"Supplier notice"}`, nil))
+	var recalls []domain.Recall
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/recalls", "", &recalls))
+	assert.Empty(t, recalls)
+}
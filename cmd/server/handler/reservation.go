@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/reservation"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// ReservationHandler is a handler for the stock reservation endpoints.
+type ReservationHandler struct {
+	service reservation.Service
+}
+
+// The NewReservationHandler function returns a new ReservationHandler that uses the provided service.
+func NewReservationHandler(service reservation.Service) *ReservationHandler {
+	return &ReservationHandler{
+		service: service,
+	}
+}
+
+// Create godoc
+// @Summary Reserve units of a product
+// @Tags Reservations
+// @Description Hold units of a product for a while (15 minutes by default), so they cannot be sold to someone else. With a location, the units are held at that location
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Param reservation body domain.ReservationRequest true "reservation"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/{id}/reservations [post]
+func (h *ReservationHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the reservation from the request body
+		var request domain.ReservationRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+		var ttl time.Duration
+		if request.TTL != "" {
+			if ttl, err = time.ParseDuration(request.TTL); err != nil {
+				web.Failure(c, 400, reservation.ErrInvalidTTL)
+				return
+			}
+		}
+
+		// Creates the reservation
+		newReservation, err := h.service.Create(id, request.Quantity, request.Location, ttl)
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			web.Failure(c, 404, err)
+			return
+		case errors.Is(err, reservation.ErrInsufficientStock), errors.Is(err, domain.ErrInsufficientStockAt):
+			web.Failure(c, 409, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 201, newReservation)
+	}
+}
+
+// GetById godoc
+// @Summary Get a reservation
+// @Tags Reservations
+// @Description Get a specific reservation based on its ID
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /reservations/{id} [get]
+func (h *ReservationHandler) GetById() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		targetReservation, err := h.service.GetById(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, targetReservation)
+	}
+}
+
+// Confirm godoc
+// @Summary Confirm a reservation
+// @Tags Reservations
+// @Description Turn an active reservation into a sale, taking its units out of stock
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /reservations/{id}/confirm [post]
+func (h *ReservationHandler) Confirm() gin.HandlerFunc {
+	return h.change(h.service.Confirm)
+}
+
+// Release godoc
+// @Summary Release a reservation
+// @Tags Reservations
+// @Description Cancel an active reservation, making its units available again
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /reservations/{id}/release [post]
+func (h *ReservationHandler) Release() gin.HandlerFunc {
+	return h.change(h.service.Release)
+}
+
+// Auxiliary function that builds a handler applying a state change to the reservation in the URL.
+func (h *ReservationHandler) change(apply func(id int) (domain.Reservation, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		updatedReservation, err := apply(id)
+		switch {
+		case errors.Is(err, reservation.ErrNotFound):
+			web.Failure(c, 404, err)
+			return
+		case errors.Is(err, reservation.ErrNotActive), errors.Is(err, stock.ErrInsufficientStock), errors.Is(err, stock.ErrBlocked), errors.Is(err, stock.ErrExpiredLot),
+			errors.Is(err, domain.ErrInsufficientStockAt):
+			web.Failure(c, 409, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, updatedReservation)
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservationHandler_Lifecycle(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productUrl := "https://localhost:8080/api/v1/products/1"
+	reservationsUrl := "https://localhost:8080/api/v1/reservations/"
+
+	// Hold 400 of the 439 units of product 1
+	var held domain.Reservation
+	status := serveAuthorized(router, http.MethodPost, productUrl+"/reservations", `{"quantity":400,"ttl":"10m"}`, &held)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, domain.ReservationActive, held.Status)
+
+	// The held units are not available anymore
+	var targetProduct domain.Product
+	serveAuthorized(router, http.MethodGet, productUrl, "", &targetProduct)
+	assert.Equal(t, 439, targetProduct.Quantity)
+	assert.Equal(t, 39, *targetProduct.AvailableQuantity)
+	status = serveAuthorized(router, http.MethodPost, productUrl+"/reservations", `{"quantity":40}`, nil)
+	assert.Equal(t, http.StatusConflict, status)
+
+	// Nor can they be sold, but the units left can
+	status = serveAuthorized(router, http.MethodPost, productUrl+"/stock/adjust", `{"delta":-40,"reason":"sale"}`, nil)
+	assert.Equal(t, http.StatusConflict, status)
+	status = serveAuthorized(router, http.MethodPost, productUrl+"/stock/adjust", `{"delta":-39,"reason":"sale"}`, nil)
+	assert.Equal(t, http.StatusCreated, status)
+	status = serveAuthorized(router, http.MethodPost, productUrl+"/stock/adjust", `{"delta":39,"reason":"restock"}`, nil)
+	assert.Equal(t, http.StatusCreated, status)
+
+	// Releasing the hold makes the units available again, and it cannot be confirmed afterwards
+	status = serveAuthorized(router, http.MethodPost, reservationsUrl+"1/release", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	status = serveAuthorized(router, http.MethodPost, reservationsUrl+"1/confirm", "", nil)
+	assert.Equal(t, http.StatusConflict, status)
+
+	// Confirming a hold takes its units out of stock
+	status = serveAuthorized(router, http.MethodPost, productUrl+"/reservations", `{"quantity":10}`, nil)
+	assert.Equal(t, http.StatusCreated, status)
+	status = serveAuthorized(router, http.MethodPost, reservationsUrl+"2/confirm", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	serveAuthorized(router, http.MethodGet, productUrl, "", &targetProduct)
+	assert.Equal(t, 429, targetProduct.Quantity)
+	assert.Equal(t, 429, *targetProduct.AvailableQuantity)
+}
+
+func TestReservationHandler_Location(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productUrl := "https://localhost:8080/api/v1/products/5"
+
+	// Move 100 of the 336 units of product 5 to a second warehouse, and hold 80 of them there
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/locations", `{"code":"north","name":"North warehouse"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productUrl+"/stock/transfer", `{"from":"main","to":"north","quantity":100}`, nil))
+	var held domain.Reservation
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productUrl+"/reservations", `{"quantity":80,"location":"north"}`, &held))
+	assert.Equal(t, "north", held.Location)
+
+	// The location only has 20 units left to hold or sell
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, productUrl+"/reservations", `{"quantity":30,"location":"north"}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, productUrl+"/stock/adjust", `{"delta":-30,"reason":"sale","location":"north"}`, nil))
+
+	// Sales without a location take the units that are not held first
+	var movement domain.StockMovement
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productUrl+"/stock/adjust", `{"delta":-236,"reason":"sale"}`, &movement))
+	assert.Equal(t, []domain.LocationMovement{{Location: "main", Delta: -236}}, movement.Locations)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, productUrl+"/stock/adjust", `{"delta":-21,"reason":"sale"}`, nil))
+
+	// Confirming the hold takes its units from its location
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/reservations/1/confirm", "", nil))
+	var targetProduct domain.Product
+	serveAuthorized(router, http.MethodGet, productUrl, "", &targetProduct)
+	assert.Equal(t, []domain.LocationStock{{Location: "main", Quantity: 0}, {Location: "north", Quantity: 20}}, targetProduct.Stock)
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// StockHandler is a handler for the product stock endpoints.
+type StockHandler struct {
+	service stock.Service
+}
+
+// The NewStockHandler function returns a new StockHandler that uses the provided service.
+func NewStockHandler(service stock.Service) *StockHandler {
+	return &StockHandler{
+		service: service,
+	}
+}
+
+// Adjust godoc
+// @Summary Adjust the stock of a product
+// @Tags Stock
+// @Description Atomically add or remove units of a product and record the movement in the ledger. Units taken from a lot-tracked product come out of its lots first-expired-first-out, unless a lot is given. A location can be given to keep the stock per location
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Param adjustment body domain.StockAdjustmentRequest true "stock adjustment"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/{id}/stock/adjust [post]
+func (h *StockHandler) Adjust() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the adjustment from the request body
+		var adjustment domain.StockAdjustmentRequest
+		if err := c.ShouldBindJSON(&adjustment); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		// Applies the adjustment
+		movement, err := h.service.Apply(id, adjustment)
+		if err != nil {
+			stockFailure(c, err)
+			return
+		}
+
+		web.Success(c, 201, movement)
+	}
+}
+
+// Transfer godoc
+// @Summary Transfer stock between locations
+// @Tags Stock
+// @Description Atomically move units of a product from one location to another and record the transfer in the ledger. Recalled stock cannot be moved
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Param transfer body domain.TransferRequest true "stock transfer"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/{id}/stock/transfer [post]
+func (h *StockHandler) Transfer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the transfer from the request body
+		var transfer domain.TransferRequest
+		if err := c.ShouldBindJSON(&transfer); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		movement, err := h.service.Transfer(id, transfer)
+		if err != nil {
+			stockFailure(c, err)
+			return
+		}
+
+		web.Success(c, 201, movement)
+	}
+}
+
+// GetMovements godoc
+// @Summary List the stock movements of a product
+// @Tags Stock
+// @Description List every stock movement recorded for a product, oldest first
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /products/{id}/stock/movements [get]
+func (h *StockHandler) GetMovements() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		movements, err := h.service.GetMovements(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, movements)
+	}
+}
+
+// ReceiveLot godoc
+// @Summary Receive a lot of a product
+// @Tags Stock
+// @Description Add a new lot, with its own quantity and an expiration date after the current date, to a product. The first lot received turns the current stock into an "initial" lot
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Param lot body domain.LotRequest true "received lot"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/{id}/lots [post]
+func (h *StockHandler) ReceiveLot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the lot from the request body
+		var lot domain.LotRequest
+		if err := c.ShouldBindJSON(&lot); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		movement, err := h.service.ReceiveLot(id, lot)
+		if err != nil {
+			stockFailure(c, err)
+			return
+		}
+
+		web.Success(c, 201, movement)
+	}
+}
+
+// GetLots godoc
+// @Summary List the lots of a product
+// @Tags Stock
+// @Description List the lots of a product with their stock, soonest to expire first
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /products/{id}/lots [get]
+func (h *StockHandler) GetLots() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		lots, err := h.service.GetLots(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, lots)
+	}
+}
+
+// RemoveLot godoc
+// @Summary Remove an empty lot of a product
+// @Tags Stock
+// @Description Remove a lot that has no stock left
+// @Param token header string true "Token"
+// @Param id path int true "Product ID"
+// @Param code path string true "Lot code"
+// @Success 204 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/{id}/lots/{code} [delete]
+func (h *StockHandler) RemoveLot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obtains the product id from a URL parameter
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		err = h.service.RemoveLot(id, c.Param("code"))
+		switch {
+		case errors.Is(err, product.ErrNotFound), errors.Is(err, domain.ErrLotNotFound):
+			web.Failure(c, 404, err)
+			return
+		case errors.Is(err, domain.ErrLotNotEmpty), errors.Is(err, domain.ErrLastLot):
+			web.Failure(c, 409, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 204, nil)
+	}
+}
+
+// Auxiliary function that emits the failure response of a stock operation.
+func stockFailure(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, product.ErrNotFound), errors.Is(err, domain.ErrLotNotFound), errors.Is(err, location.ErrNotFound):
+		web.Failure(c, 404, err)
+	case errors.Is(err, stock.ErrInsufficientStock), errors.Is(err, stock.ErrBlocked), errors.Is(err, stock.ErrExpiredLot),
+		errors.Is(err, domain.ErrInsufficientStockAt), errors.Is(err, domain.ErrDuplicateLot):
+		web.Failure(c, 409, err)
+	default:
+		web.Failure(c, 400, err)
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockHandler_Adjust(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+
+	// Define a sequence of adjustments over product 1 (439 units) and the expected status codes
+	adjustments := []struct {
+		body           string
+		expectedStatus int
+	}{
+		{`{"delta":-439,"reason":"sale"}`, http.StatusCreated},
+		{`{"delta":-1,"reason":"sale"}`, http.StatusConflict},
+		{`{"delta":5,"reason":"sale"}`, http.StatusBadRequest},
+		{`{"delta":5,"reason":"gift"}`, http.StatusBadRequest},
+		{`{"delta":5,"reason":"restock","reference":"PO-1"}`, http.StatusCreated},
+	}
+
+	// Iterate through the adjustments
+	for _, adjustment := range adjustments {
+		request, responseRecorder := createRequestTest(
+			http.MethodPost,
+			"https://localhost:8080/api/v1/products/1/stock/adjust",
+			adjustment.body,
+		)
+		request.Header.Add("token", "12345")
+		router.ServeHTTP(responseRecorder, request)
+
+		// Assertions
+		assert.Equal(t, adjustment.expectedStatus, responseRecorder.Code, adjustment.body)
+	}
+
+	// Only the successful adjustments are recorded in the ledger
+	request, responseRecorder := createRequestTest(http.MethodGet, "https://localhost:8080/api/v1/products/1/stock/movements", "")
+	request.Header.Add("token", "12345")
+	router.ServeHTTP(responseRecorder, request)
+	actualResponse := map[string][]domain.StockMovement{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+	if err != nil {
+		panic(err)
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Len(t, actualResponse["data"], 2)
+	assert.Equal(t, 5, actualResponse["data"][1].QuantityAfter)
+}
+
+func TestStockHandler_Lots(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	lotsUrl := "https://localhost:8080/api/v1/products/2/lots"
+
+	// The first lot turns the 345 units of product 2 into an "initial" lot
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, lotsUrl, `{"code":"A","quantity":10,"expiration":"2030-01-01"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, lotsUrl, `{"code":"B","quantity":5,"expiration":"2029-01-01"}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, lotsUrl, `{"code":"A","quantity":1,"expiration":"2030-01-01"}`, nil))
+
+	// A sale consumes the lots first-expired-first-out, skipping the expired "initial" lot
+	var movement domain.StockMovement
+	status := serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/stock/adjust", `{"delta":-12,"reason":"sale"}`, &movement)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, 348, movement.QuantityAfter)
+	assert.Equal(t, []domain.LotMovement{{Code: "B", Delta: -5}, {Code: "A", Delta: -7}}, movement.Lots)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/stock/adjust", `{"delta":-1,"reason":"sale","lot":"initial"}`, nil))
+
+	// Expired units can still be written off
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/stock/adjust", `{"delta":-345,"reason":"shrinkage","lot":"initial"}`, nil))
+
+	// Restocks must name the lot
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/stock/adjust", `{"delta":2,"reason":"restock"}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/stock/adjust", `{"delta":2,"reason":"restock","lot":"Z"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/stock/adjust", `{"delta":2,"reason":"restock","lot":"A"}`, nil))
+
+	// The quantity of a lot-tracked product cannot be edited directly
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, "https://localhost:8080/api/v1/products/2", `{"quantity":100}`, nil))
+
+	// Only empty lots can be removed
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, lotsUrl+"/B", "", nil))
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, lotsUrl+"/initial", "", nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, lotsUrl+"/A", "", nil))
+
+	// The product reports the sum of its lots and the soonest expiration with stock
+	var lots []domain.Lot
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, lotsUrl, "", &lots))
+	assert.Len(t, lots, 1)
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/2", "", &product))
+	assert.Equal(t, 5, product.Quantity)
+	assert.Equal(t, "2030-01-01", product.Expiration.String())
+
+	// The last lot is kept even when empty, so the product stays lot-tracked
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/2/stock/adjust", `{"delta":-5,"reason":"shrinkage","lot":"A"}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, lotsUrl+"/A", "", nil))
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/stocktake"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// StocktakeHandler is a handler for the stocktake session endpoints.
+type StocktakeHandler struct {
+	service stocktake.Service
+}
+
+// The NewStocktakeHandler function returns a new StocktakeHandler that uses the provided service.
+func NewStocktakeHandler(service stocktake.Service) *StocktakeHandler {
+	return &StocktakeHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the stocktake sessions
+// @Tags Stocktakes
+// @Description List every stocktake session, open or closed
+// @Produce json
+// @Param token header string true "Token"
+// @Success 200 {object} web.Response
+// @Router /stocktakes [get]
+func (h *StocktakeHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// Open godoc
+// @Summary Open a stocktake session
+// @Tags Stocktakes
+// @Description Open a physical inventory count, of a single location or of the whole stock
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param session body domain.StocktakeRequest false "stocktake session"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /stocktakes [post]
+func (h *StocktakeHandler) Open() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The body is optional, without it the whole stock is counted
+		var request domain.StocktakeRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				web.Failure(c, 400, ErrInvalidData)
+				return
+			}
+		}
+
+		session, err := h.service.Open(request)
+		if err != nil {
+			stocktakeFailure(c, err)
+			return
+		}
+
+		web.Success(c, 201, session)
+	}
+}
+
+// Review godoc
+// @Summary Review a stocktake session
+// @Tags Stocktakes
+// @Description Get a stocktake session along with the variances between its counts and the system quantities
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Stocktake session ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /stocktakes/{id} [get]
+func (h *StocktakeHandler) Review() gin.HandlerFunc {
+	return h.apply(h.service.Review)
+}
+
+// SubmitCounts godoc
+// @Summary Submit counted quantities
+// @Tags Stocktakes
+// @Description Record counted quantities per code value in an open stocktake session, as a JSON array or as CSV with code_value and counted columns (Content-Type text/csv)
+// @Accept json,text/csv
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Stocktake session ID"
+// @Param counts body []domain.StockCount true "counted quantities"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /stocktakes/{id}/counts [post]
+func (h *StocktakeHandler) SubmitCounts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the counts from the request body, in CSV or JSON
+		var counts []domain.StockCount
+		if c.ContentType() == "text/csv" {
+			if counts, err = stocktake.ParseCounts(c.Request.Body); err != nil {
+				web.Failure(c, 400, err)
+				return
+			}
+		} else if err := c.ShouldBindJSON(&counts); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		review, err := h.service.SubmitCounts(id, counts)
+		if err != nil {
+			stocktakeFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, review)
+	}
+}
+
+// Commit godoc
+// @Summary Commit a stocktake session
+// @Tags Stocktakes
+// @Description Adjust the stock to match the counts, recording every variance in the stock ledger in a single atomic operation, and close the session
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Stocktake session ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /stocktakes/{id}/commit [post]
+func (h *StocktakeHandler) Commit() gin.HandlerFunc {
+	return h.apply(h.service.Commit)
+}
+
+// Cancel godoc
+// @Summary Cancel a stocktake session
+// @Tags Stocktakes
+// @Description Close an open stocktake session without adjusting any stock
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Stocktake session ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /stocktakes/{id}/cancel [post]
+func (h *StocktakeHandler) Cancel() gin.HandlerFunc {
+	return h.apply(func(id int) (domain.StocktakeReview, error) {
+		session, err := h.service.Cancel(id)
+		return domain.StocktakeReview{StocktakeSession: session}, err
+	})
+}
+
+// Auxiliary function that builds a handler applying an operation to the stocktake session in the URL.
+func (h *StocktakeHandler) apply(operation func(id int) (domain.StocktakeReview, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		review, err := operation(id)
+		if err != nil {
+			stocktakeFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, review)
+	}
+}
+
+// Auxiliary function that emits the failure response of a stocktake operation.
+func stocktakeFailure(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, stocktake.ErrNotFound):
+		web.Failure(c, 404, err)
+	case errors.Is(err, stocktake.ErrNotOpen), errors.Is(err, stocktake.ErrAlreadyOpen):
+		web.Failure(c, 409, err)
+	default:
+		stockFailure(c, err)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStocktakeHandler_Commit(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+
+	// Open a session, only one at a time
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/stocktakes", "", nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/stocktakes", "", nil))
+
+	// Submit counts as JSON and as CSV
+	countsUrl := "https://localhost:8080/api/v1/stocktakes/1/counts"
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, countsUrl, `[{"code_value":"S82254D","counted":430}]`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, countsUrl, `[{"code_value":"NOPE","counted":1}]`, nil))
+
+	request, responseRecorder := createRequestTest(http.MethodPost, countsUrl, "code_value,counted\nM4637,350\nT65812,367\n")
+	request.Header.Set("Content-Type", "text/csv")
+	request.Header.Add("token", "12345")
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Review the variances
+	var review domain.StocktakeReview
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/stocktakes/1", "", &review))
+	assert.Equal(t, []int{-9, 5, 0}, []int{review.Lines[0].Variance, review.Lines[1].Variance, review.Lines[2].Variance})
+
+	// Committing applies the variances as ledger movements and closes the session
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/stocktakes/1/commit", "", &review))
+	assert.Equal(t, domain.StocktakeCommitted, review.Status)
+	assert.Len(t, review.Movements, 2)
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/stocktakes/1/commit", "", nil))
+
+	var movements []domain.StockMovement
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/1/stock/movements", "", &movements))
+	assert.Len(t, movements, 1)
+	assert.Equal(t, domain.ReasonCount, movements[0].Reason)
+	assert.Equal(t, 430, movements[0].QuantityAfter)
+	assert.Equal(t, "stocktake-1", movements[0].Reference)
+}
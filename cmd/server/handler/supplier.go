@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/supplier"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// SupplierHandler is a handler for the supplier endpoints.
+type SupplierHandler struct {
+	service supplier.Service
+}
+
+// The NewSupplierHandler function returns a new SupplierHandler that uses the provided service.
+func NewSupplierHandler(service supplier.Service) *SupplierHandler {
+	return &SupplierHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the suppliers
+// @Tags Suppliers
+// @Description List every supplier
+// @Produce json
+// @Param token header string true "Token"
+// @Success 200 {object} web.Response
+// @Router /suppliers [get]
+func (h *SupplierHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// GetById godoc
+// @Summary Get a supplier
+// @Tags Suppliers
+// @Description Get a specific supplier based on its ID
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /suppliers/{id} [get]
+func (h *SupplierHandler) GetById() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		targetSupplier, err := h.service.GetById(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+
+		web.Success(c, 200, targetSupplier)
+	}
+}
+
+// Create godoc
+// @Summary Create a supplier
+// @Tags Suppliers
+// @Description Create a new supplier with its contact and lead time in days
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param supplier body domain.Supplier true "supplier"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Router /suppliers [post]
+func (h *SupplierHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var newSupplier domain.Supplier
+		if err := c.ShouldBindJSON(&newSupplier); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		createdSupplier, err := h.service.Create(newSupplier)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 201, createdSupplier)
+	}
+}
+
+// Update godoc
+// @Summary Update a supplier
+// @Tags Suppliers
+// @Description Replace the data of a supplier
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Supplier ID"
+// @Param supplier body domain.Supplier true "supplier"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /suppliers/{id} [put]
+func (h *SupplierHandler) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		var supplierData domain.Supplier
+		if err := c.ShouldBindJSON(&supplierData); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		updatedSupplier, err := h.service.Update(id, supplierData)
+		switch {
+		case errors.Is(err, supplier.ErrNotFound):
+			web.Failure(c, 404, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, updatedSupplier)
+	}
+}
+
+// LinkProduct godoc
+// @Summary Link a product to a supplier
+// @Tags Suppliers
+// @Description Make the supplier the supplier of a product, replacing any previous one
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Supplier ID"
+// @Param link body domain.SupplierLinkRequest true "product to link"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /suppliers/{id}/products [post]
+func (h *SupplierHandler) LinkProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		var link domain.SupplierLinkRequest
+		if err := c.ShouldBindJSON(&link); err != nil {
+			web.Failure(c, 400, ErrInvalidData)
+			return
+		}
+
+		linkedProduct, err := h.service.LinkProduct(id, link.ProductId)
+		switch {
+		case errors.Is(err, supplier.ErrNotFound), errors.Is(err, product.ErrNotFound):
+			web.Failure(c, 404, err)
+			return
+		case err != nil:
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, linkedProduct)
+	}
+}
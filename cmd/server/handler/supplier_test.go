@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupplierHandler_CRUD(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	suppliersUrl := "https://localhost:8080/api/v1/suppliers"
+
+	// Create a supplier, rejecting missing names and negative lead times
+	var created domain.Supplier
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, suppliersUrl, `{"name":"Fresh Farms","lead_time_days":7}`, &created))
+	assert.Equal(t, domain.Supplier{Id: 1, Name: "Fresh Farms", LeadTimeDays: 7}, created)
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, suppliersUrl, `{"lead_time_days":7}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, suppliersUrl, `{"name":"Late Farms","lead_time_days":-1}`, nil))
+
+	// Read it back, alone and in the list
+	var found domain.Supplier
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, suppliersUrl+"/1", "", &found))
+	assert.Equal(t, created, found)
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodGet, suppliersUrl+"/2", "", nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodGet, suppliersUrl+"/one", "", nil))
+	var suppliers []domain.Supplier
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, suppliersUrl, "", &suppliers))
+	assert.Equal(t, []domain.Supplier{created}, suppliers)
+
+	// Replace its data
+	var updated domain.Supplier
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPut, suppliersUrl+"/1", `{"name":"Fresh Farms","contact":"Jane Doe","lead_time_days":3}`, &updated))
+	assert.Equal(t, domain.Supplier{Id: 1, Name: "Fresh Farms", Contact: "Jane Doe", LeadTimeDays: 3}, updated)
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPut, suppliersUrl+"/2", `{"name":"Nobody"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPut, suppliersUrl+"/1", `{"name":"Fresh Farms","lead_time_days":-1}`, nil))
+
+	// Link a product to it
+	var linked domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, suppliersUrl+"/1/products", `{"product_id":5}`, &linked))
+	assert.Equal(t, 1, linked.SupplierId)
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, suppliersUrl+"/1/products", `{"product_id":9999}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, suppliersUrl+"/1/products", `{}`, nil))
+}
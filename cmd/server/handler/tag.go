@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/tag"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// TagHandler is a handler for the product tag endpoints.
+type TagHandler struct {
+	service tag.Service
+}
+
+// The NewTagHandler function returns a new TagHandler that uses the provided service.
+func NewTagHandler(service tag.Service) *TagHandler {
+	return &TagHandler{
+		service: service,
+	}
+}
+
+// GetAll godoc
+// @Summary List the tags
+// @Tags Tags
+// @Description List the created tags and those in use by any product, with their product counts
+// @Produce json
+// @Success 200 {object} web.Response
+// @Router /tags [get]
+func (h *TagHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		web.Success(c, 200, h.service.GetAll())
+	}
+}
+
+// GetByName godoc
+// @Summary Get a tag
+// @Tags Tags
+// @Description Get a specific tag based on its name
+// @Produce json
+// @Param name path string true "Tag name"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /tags/{name} [get]
+func (h *TagHandler) GetByName() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		targetTag, err := h.service.GetByName(c.Param("name"))
+		if err != nil {
+			tagFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, targetTag)
+	}
+}
+
+// Create godoc
+// @Summary Create a tag
+// @Tags Tags
+// @Description Create a tag with a description before any product has it. Names are case-insensitive
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param tag body domain.Tag true "tag"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /tags [post]
+func (h *TagHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request domain.Tag
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		newTag, err := h.service.Create(request)
+		if err != nil {
+			tagFailure(c, err)
+			return
+		}
+
+		web.Success(c, 201, newTag)
+	}
+}
+
+// Rename godoc
+// @Summary Rename a tag
+// @Tags Tags
+// @Description Rename a tag on every product that has it. Renaming to a tag in use is rejected, merge them instead
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param name path string true "Tag name"
+// @Param rename body domain.TagRenameRequest true "new name"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /tags/{name} [put]
+func (h *TagHandler) Rename() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request domain.TagRenameRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		renamedTag, err := h.service.Rename(c.Param("name"), request.Name)
+		if err != nil {
+			tagFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, renamedTag)
+	}
+}
+
+// Merge godoc
+// @Summary Merge tags
+// @Tags Tags
+// @Description Replace the source tags with this one on every product, and remove the sources
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param name path string true "Tag name"
+// @Param merge body domain.TagMergeRequest true "tags to merge"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /tags/{name}/merge [post]
+func (h *TagHandler) Merge() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request domain.TagMergeRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		mergedTag, err := h.service.Merge(c.Param("name"), request.Sources)
+		if err != nil {
+			tagFailure(c, err)
+			return
+		}
+
+		web.Success(c, 200, mergedTag)
+	}
+}
+
+// Delete godoc
+// @Summary Delete a tag
+// @Tags Tags
+// @Description Remove a tag from every product that has it
+// @Produce json
+// @Param token header string true "Token"
+// @Param name path string true "Tag name"
+// @Success 204 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /tags/{name} [delete]
+func (h *TagHandler) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h.service.Delete(c.Param("name")); err != nil {
+			tagFailure(c, err)
+			return
+		}
+
+		web.Success(c, 204, nil)
+	}
+}
+
+// Auxiliary function that maps the errors of the tag service to their status codes.
+func tagFailure(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, tag.ErrNotFound):
+		web.Failure(c, 404, err)
+	case errors.Is(err, tag.ErrDuplicate):
+		web.Failure(c, 409, err)
+	default:
+		web.Failure(c, 400, err)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagHandler_Tags(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productsUrl := "https://localhost:8080/api/v1/products"
+	tagsUrl := "https://localhost:8080/api/v1/tags"
+
+	// Tags are normalized when set on products
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/1", `{"tags":["Organic ","imported","organic"]}`, &product))
+	assert.Equal(t, []string{"imported", "organic"}, product.Tags)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/2", `{"tags":["organic"]}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/5", `{"tags":["bio","imported"]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/5", `{"tags":["a,b"]}`, nil))
+
+	// Filtering by tags, with all of them by default or any of them
+	var products []domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?tags=organic,imported", "", &products))
+	assert.Len(t, products, 1)
+	assert.Equal(t, 1, products[0].Id)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?tags=organic,imported&tags_mode=any", "", &products))
+	assert.Len(t, products, 3)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/search?priceGt=100&tags=organic,bio&tags_mode=any", "", &products))
+	assert.Len(t, products, 2)
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodGet, productsUrl+"/all?tags=organic&tags_mode=some", "", nil))
+
+	// Tags in use are listed with their counts, and created tags even without products
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, tagsUrl, `{"name":"Gluten-Free","description":"No gluten"}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, tagsUrl, `{"name":"gluten-free"}`, nil))
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, tagsUrl, `{"name":"organic"}`, nil))
+	var tags []domain.Tag
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, tagsUrl, "", &tags))
+	assert.Equal(t, []domain.Tag{
+		{Name: "bio", ProductCount: 1},
+		{Name: "gluten-free", Description: "No gluten", ProductCount: 0},
+		{Name: "imported", ProductCount: 2},
+		{Name: "organic", ProductCount: 2},
+	}, tags)
+
+	// Renaming rewrites the products, but cannot take the name of another tag
+	var tag domain.Tag
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPut, tagsUrl+"/bio", `{"name":"organic"}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPut, tagsUrl+"/bio", `{"name":"eco"}`, &tag))
+	assert.Equal(t, domain.Tag{Name: "eco", ProductCount: 1}, tag)
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodGet, tagsUrl+"/bio", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/5", "", &product))
+	assert.Equal(t, []string{"eco", "imported"}, product.Tags)
+
+	// Merging replaces the sources on every product
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, tagsUrl+"/organic/merge", `{"sources":["unknown"]}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, tagsUrl+"/organic/merge", `{"sources":["eco"]}`, &tag))
+	assert.Equal(t, 3, tag.ProductCount)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/5", "", &product))
+	assert.Equal(t, []string{"imported", "organic"}, product.Tags)
+
+	// Deleting removes the tag from every product
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, tagsUrl+"/imported", "", nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodDelete, tagsUrl+"/imported", "", nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/1", "", &product))
+	assert.Equal(t, []string{"organic"}, product.Tags)
+
+	// A target among the sources keeps its description and its products
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPost, tagsUrl+"/gluten-free/merge", `{"sources":["gluten-free","organic"]}`, &tag))
+	assert.Equal(t, domain.Tag{Name: "gluten-free", Description: "No gluten", ProductCount: 3}, tag)
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/1", "", &product))
+	assert.Equal(t, []string{"gluten-free"}, product.Tags)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductHandler_Tax(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productUrl := "https://localhost:8080/api/v1/products/2"
+
+	// Product 2 costs 352.79 and has the standard tax category
+	var product domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productUrl+"?region=CL", "", &product))
+	assert.Equal(t, "CL", product.Tax.Region)
+	assert.Equal(t, "19", product.Tax.Rate.String())
+	assert.Equal(t, "352.79", product.Tax.Net.Amount.String())
+	assert.Equal(t, "67.03", product.Tax.Tax.Amount.String())
+	assert.Equal(t, "419.82", product.Tax.Gross.Amount.String())
+
+	// Reduced rates apply where the region has them, and the standard rate elsewhere
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productUrl, `{"tax_category":"reduced_fod"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/new", `{"name":"Wine","quantity":1,"code_value":"WINE1","expiration":"2030-01-01","price":10,"tax_category":"luxury"}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productUrl, `{"tax_category":"reduced_food"}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productUrl+"?region=ar", "", &product))
+	assert.Equal(t, "reduced_food", product.Tax.Category)
+	assert.Equal(t, "10.5", product.Tax.Rate.String())
+	assert.Equal(t, "37.04", product.Tax.Tax.Amount.String())
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productUrl+"?region=CL", "", &product))
+	assert.Equal(t, "19", product.Tax.Rate.String())
+
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodGet, productUrl+"?region=XX", "", nil))
+	var products []domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, "https://localhost:8080/api/v1/products/all?region=CL", "", &products))
+	assert.NotNil(t, products[0].Tax)
+
+	// Quotes break every line and the total down
+	var quote domain.Quote
+	status := serveAuthorized(router, http.MethodPost, "https://localhost:8080/api/v1/products/quote?region=AR", `{"lines":[{"id":2,"quantity":2},{"id":1,"quantity":1}]}`, &quote)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "74.09", quote.Lines[0].Tax.Tax.Amount.String())
+	assert.Equal(t, "15", quote.Lines[1].Tax.Tax.Amount.String())
+	assert.Equal(t, "777", quote.Tax.Net.Amount.String())
+	assert.Equal(t, "89.09", quote.Tax.Tax.Amount.String())
+	assert.Equal(t, "866.09", quote.Tax.Gross.Amount.String())
+	assert.Nil(t, quote.Tax.Rate)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/web"
+)
+
+// VariantHandler is a handler for the product variant endpoints.
+type VariantHandler struct {
+	service product.Service
+}
+
+// The NewVariantHandler function returns a new VariantHandler that uses the provided product service.
+func NewVariantHandler(service product.Service) *VariantHandler {
+	return &VariantHandler{
+		service: service,
+	}
+}
+
+// GetVariants godoc
+// @Summary List the variants of a product
+// @Tags Products
+// @Description List the variants of a parent product, each one with its option values, code value, stock and price
+// @Produce json
+// @Param id path int true "Parent product ID"
+// @Param currency query string false "Currency to present prices in"
+// @Success 200 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Router /products/{id}/variants [get]
+func (h *VariantHandler) GetVariants() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		variants, err := h.service.GetVariants(id)
+		if err != nil {
+			web.Failure(c, 404, err)
+			return
+		}
+		variants, err = h.service.ConvertPrices(variants, c.Query("currency"))
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+
+		web.Success(c, 200, variants)
+	}
+}
+
+// CreateVariant godoc
+// @Summary Create a variant of a product
+// @Tags Products
+// @Description Create a variant of a parent product with one value of every option of the parent, such as a 750ml bottle. The variant has its own code value, stock and price, and takes the rest of its data from the parent
+// @Accept json
+// @Produce json
+// @Param token header string true "Token"
+// @Param id path int true "Parent product ID"
+// @Param variant body domain.VariantRequest true "variant"
+// @Success 201 {object} web.Response
+// @Failure 400 {object} web.ErrorResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 409 {object} web.ErrorResponse
+// @Router /products/{id}/variants [post]
+func (h *VariantHandler) CreateVariant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, 400, ErrInvalidId)
+			return
+		}
+
+		// Extract the variant from the request body
+		var request domain.VariantRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			web.Failure(c, 400, bindingError(err))
+			return
+		}
+
+		newVariant, err := h.service.CreateVariant(id, request)
+		switch {
+		case err == nil:
+			web.Success(c, 201, newVariant)
+		case errors.Is(err, product.ErrNotFound):
+			web.Failure(c, 404, err)
+		case errors.Is(err, product.ErrDuplicateVariant):
+			web.Failure(c, 409, err)
+		default:
+			web.Failure(c, 400, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantHandler_Variants(t *testing.T) {
+	router := createServerForTestProducts(t, "12345")
+	productsUrl := "https://localhost:8080/api/v1/products"
+	expiration := time.Now().UTC().AddDate(1, 0, 0).Format("2006-01-02")
+
+	// Options are validated
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/new",
+		`{"name":"Wine","quantity":1,"code_value":"WINE","expiration":"`+expiration+`","price":20,"options":[{"name":"size","values":["375ml","375ml"]}]}`, nil))
+
+	var parent domain.Product
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productsUrl+"/new",
+		`{"name":"Wine - Red Oakridge Merlot","quantity":1,"code_value":"WINE","is_published":true,"expiration":"`+expiration+`","price":20,"options":[{"name":"size","values":["375ml","750ml"]}]}`, &parent))
+	assert.Equal(t, 501, parent.Id)
+
+	// Variants have their own code value, stock and price, and take the rest from their parent
+	var variant domain.Product
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productsUrl+"/501/variants", `{"code_value":"WINE-375","option_values":{"size":"375ml"},"quantity":30,"price":12.5}`, &variant))
+	assert.Equal(t, 502, variant.Id)
+	assert.Equal(t, "Wine - Red Oakridge Merlot - 375ml", variant.Name)
+	assert.Equal(t, 501, variant.ParentId)
+	assert.Equal(t, "12.5", variant.Price.Amount.String())
+	assert.Equal(t, domain.StatusPublished, variant.Status)
+	assert.Equal(t, http.StatusCreated, serveAuthorized(router, http.MethodPost, productsUrl+"/501/variants", `{"code_value":"WINE-750","option_values":{"size":"750ml"},"quantity":60}`, &variant))
+	assert.Equal(t, "20", variant.Price.Amount.String())
+
+	// Option values must be valid and unique among siblings, and code values unique across all products
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodPost, productsUrl+"/501/variants", `{"code_value":"WINE-750B","option_values":{"size":"750ml"}}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/501/variants", `{"code_value":"WINE-3L","option_values":{"size":"3l"}}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/501", `{"options":[{"name":"size","values":["375ml","750ml","1.5l"]}]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/501/variants", `{"code_value":"S82254D","option_values":{"size":"1.5l"}}`, nil))
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPost, productsUrl+"/502/variants", `{"code_value":"WINE-X","option_values":{"size":"1.5l"}}`, nil))
+	assert.Equal(t, http.StatusNotFound, serveAuthorized(router, http.MethodPost, productsUrl+"/9999/variants", `{"code_value":"WINE-X","option_values":{"size":"1.5l"}}`, nil))
+
+	// Options in use by variants cannot be removed
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/501", `{"options":[{"name":"size","values":["750ml"]}]}`, nil))
+
+	var variants []domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/501/variants", "", &variants))
+	assert.Len(t, variants, 2)
+
+	// Listings can collapse variants into their parents
+	var all, collapsed []domain.Product
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all", "", &all))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?collapse_variants=true", "", &collapsed))
+	assert.Len(t, collapsed, len(all)-2)
+	wine := collapsed[len(collapsed)-1]
+	assert.Equal(t, 501, wine.Id)
+	assert.Equal(t, 2, wine.Variants.Count)
+	assert.Equal(t, 90, wine.Variants.AvailableQuantity)
+	assert.Equal(t, "12.5", wine.Variants.MinPrice.Amount.String())
+	assert.Equal(t, "20", wine.Variants.MaxPrice.Amount.String())
+
+	// Summaries only cover the variants in the listing
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/search?priceGt=15&collapse_variants=true", "", &collapsed))
+	wine = collapsed[len(collapsed)-1]
+	assert.Equal(t, 501, wine.Id)
+	assert.Equal(t, 1, wine.Variants.Count)
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodGet, productsUrl+"/all?collapse_variants=maybe", "", nil))
+
+	// Variants keep the currency of their parent, and those of a parent not for sale are not collapsed
+	assert.Equal(t, http.StatusBadRequest, serveAuthorized(router, http.MethodPatch, productsUrl+"/502", `{"price":12000,"currency":"CLP"}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodPatch, productsUrl+"/501", `{"is_published":false}`, nil))
+	assert.Equal(t, http.StatusOK, serveAuthorized(router, http.MethodGet, productsUrl+"/all?collapse_variants=true", "", &collapsed))
+	assert.Len(t, collapsed, len(all))
+	assert.Nil(t, collapsed[len(collapsed)-1].Variants)
+
+	// Parents cannot be deleted before their variants
+	assert.Equal(t, http.StatusConflict, serveAuthorized(router, http.MethodDelete, productsUrl+"/501", "", nil))
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, productsUrl+"/502", "", nil))
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, productsUrl+"/503", "", nil))
+	assert.Equal(t, http.StatusNoContent, serveAuthorized(router, http.MethodDelete, productsUrl+"/501", "", nil))
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,22 +13,15 @@ import (
 
 var ErrInvalidToken = errors.New("invalid token")
 
+// actorKey is the context key that holds who a request is authenticated as.
+const actorKey = "actor"
+
 func TokenValidator() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get the token from the request header
-		token := c.GetHeader("token")
-
-		// Check if the token is not empty
-		if token == "" {
-			c.Abort()
-			web.Failure(c, 401, ErrInvalidToken)
-			return
-		}
-
-		// Check if the token is valid
-		if token != os.Getenv("TOKEN") {
+		// Check if the token from the request header is valid
+		if err := Authorize(c); err != nil {
 			c.Abort()
-			web.Failure(c, 401, ErrInvalidToken)
+			web.Failure(c, 401, err)
 			return
 		}
 
@@ -35,6 +29,37 @@ func TokenValidator() gin.HandlerFunc {
 	}
 }
 
+/*
+The Authorize function checks the token of a request and keeps who it belongs to, for Actor. The
+shared TOKEN belongs to no one in particular, and every "name:token" pair of the comma-separated
+ACTOR_TOKENS belongs to its name.
+*/
+func Authorize(c *gin.Context) error {
+	// Get the token from the request header
+	token := c.GetHeader("token")
+	if token == "" {
+		return ErrInvalidToken
+	}
+
+	if token == os.Getenv("TOKEN") {
+		c.Set(actorKey, "")
+		return nil
+	}
+	for _, pair := range strings.Split(os.Getenv("ACTOR_TOKENS"), ",") {
+		name, actorToken, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && name != "" && token == actorToken {
+			c.Set(actorKey, name)
+			return nil
+		}
+	}
+	return ErrInvalidToken
+}
+
+// The Actor function returns who an authorized request is authenticated as, or "" if no one in particular.
+func Actor(c *gin.Context) string {
+	return c.GetString(actorKey)
+}
+
 func PanicLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
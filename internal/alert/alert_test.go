@@ -0,0 +1,183 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingNotifier keeps every alert it receives.
+type recordingNotifier struct {
+	alerts []domain.LowStockAlert
+}
+
+func (n *recordingNotifier) Notify(alert domain.LowStockAlert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+/*
+Auxiliary function that starts a fake SMTP server on a local port. It accepts a single message and
+sends its DATA section through the returned channel.
+*/
+func startFakeSMTPServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost fake SMTP")
+
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case command == "DATA":
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestLowStockAlerter_Check(t *testing.T) {
+	notifier := &recordingNotifier{}
+	alertedStore := store.NewJsonDocumentStore[[]int](filepath.Join(t.TempDir(), "low_stock_alerts.json"))
+	alerter := NewLowStockAlerter(notifier, alertedStore, nil)
+	product := domain.Product{Id: 1, CodeValue: "COD123", Quantity: 20, ReorderPoint: 10}
+
+	// Above the reorder point there is nothing to report
+	alerter.Check(product)
+	alerter.Flush()
+	assert.Len(t, notifier.alerts, 0)
+
+	// Dropping below it alerts once, no matter how many writes follow
+	product.Quantity = 9
+	alerter.Check(product)
+	product.Quantity = 5
+	alerter.Check(product)
+	alerter.Flush()
+	assert.Len(t, notifier.alerts, 1)
+	assert.Equal(t, 9, notifier.alerts[0].Quantity)
+
+	// Nor after a restart, since the alerted products are saved
+	alerted, err := alertedStore.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, alerted)
+	restarted := NewLowStockAlerter(notifier, alertedStore, alerted)
+	restarted.Check(product)
+	restarted.Flush()
+	assert.Len(t, notifier.alerts, 1)
+
+	// After recovering, a new drop alerts again
+	product.Quantity = 30
+	alerter.Check(product)
+	product.Quantity = 2
+	alerter.Check(product)
+	alerter.Flush()
+	assert.Len(t, notifier.alerts, 2)
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received domain.LowStockAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(domain.LowStockAlert{ProductId: 7, CodeValue: "COD7"})
+
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, 7, received.ProductId)
+	assert.Equal(t, "COD7", received.CodeValue)
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	addr, messages := startFakeSMTPServer(t)
+	notifier := NewSMTPNotifier(addr, "", "", "stock@example.com", []string{"buyer@example.com"})
+
+	err := notifier.Notify(domain.LowStockAlert{ProductId: 7, CodeValue: "COD7", Name: "Pineapple", Quantity: 3, ReorderPoint: 10})
+
+	// Assertions
+	assert.Nil(t, err)
+	message := <-messages
+	assert.Contains(t, message, "Subject: Low stock: COD7")
+	assert.Contains(t, message, "has 3 units, below its reorder point of 10")
+}
+
+func TestSMTPNotifier_Timeout(t *testing.T) {
+	// A server that accepts connections but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	notifier := NewSMTPNotifier(listener.Addr().String(), "", "", "stock@example.com", []string{"buyer@example.com"})
+	notifier.timeout = 100 * time.Millisecond
+	start := time.Now()
+	err = notifier.Notify(domain.LowStockAlert{ProductId: 7, CodeValue: "COD7"})
+
+	// Assertions
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
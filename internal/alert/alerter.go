@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+// queueSize is how many alerts can wait to be delivered before new ones are dropped.
+const queueSize = 100
+
+// Notifier is the interface definition for the ways of delivering low-stock alerts
+type Notifier interface {
+	Notify(alert domain.LowStockAlert) error
+}
+
+/*
+The LowStockAlerter struct sends a low-stock alert through a notifier the first time a product drops
+below its reorder point. Further writes do not repeat the alert until the product recovers. The
+alerted products are saved in a store, so a restart does not repeat their alerts either.
+*/
+type LowStockAlerter struct {
+	mu       sync.Mutex
+	notifier Notifier
+	alerted  map[int]bool
+	store    store.DocumentStore[[]int]
+	queue    chan domain.LowStockAlert
+	pending  sync.WaitGroup
+}
+
+/*
+The NewLowStockAlerter function returns a new alerter that delivers its alerts through the given
+notifier. It starts with the given alerted products and saves every change in the provided store.
+Alerts are delivered in the background, one at a time, so a slow notifier never delays a write.
+*/
+func NewLowStockAlerter(notifier Notifier, alertedStore store.DocumentStore[[]int], alerted []int) *LowStockAlerter {
+	a := &LowStockAlerter{
+		notifier: notifier,
+		alerted:  map[int]bool{},
+		store:    alertedStore,
+		queue:    make(chan domain.LowStockAlert, queueSize),
+	}
+	for _, id := range alerted {
+		a.alerted[id] = true
+	}
+	go a.deliver()
+	return a
+}
+
+/*
+The Check method looks at a product that has just been written. If it is low on stock and has not
+been alerted yet, it queues an alert. If it has recovered, it can be alerted again in the future.
+Delivery errors are logged, so they never undo the write.
+*/
+func (a *LowStockAlerter) Check(product domain.Product) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !product.IsLowOnStock() {
+		if a.alerted[product.Id] {
+			delete(a.alerted, product.Id)
+			a.save()
+		}
+		return
+	}
+	if a.alerted[product.Id] {
+		return
+	}
+
+	alert := domain.LowStockAlert{
+		ProductId:    product.Id,
+		CodeValue:    product.CodeValue,
+		Name:         product.Name,
+		Quantity:     product.Quantity,
+		ReorderPoint: product.ReorderPoint,
+		Time:         time.Now().UTC(),
+	}
+	a.pending.Add(1)
+	select {
+	case a.queue <- alert:
+		a.alerted[product.Id] = true
+		a.save()
+	default:
+		a.pending.Done()
+		log.Printf("alert: low-stock alert for product %d dropped, the queue is full\n", product.Id)
+	}
+}
+
+// The Flush method waits until every queued alert has been delivered or has failed.
+func (a *LowStockAlerter) Flush() {
+	a.pending.Wait()
+}
+
+/*
+Auxiliary function that delivers the queued alerts. A failed alert is logged and forgotten, so the
+next write of the product tries again.
+*/
+func (a *LowStockAlerter) deliver() {
+	for alert := range a.queue {
+		if err := a.notifier.Notify(alert); err != nil {
+			log.Printf("alert: low-stock alert for product %d failed: %v\n", alert.ProductId, err)
+			a.mu.Lock()
+			delete(a.alerted, alert.ProductId)
+			a.save()
+			a.mu.Unlock()
+		}
+		a.pending.Done()
+	}
+}
+
+// Auxiliary function that saves the alerted products, logging any error since alerts never undo a write.
+func (a *LowStockAlerter) save() {
+	alerted := make([]int, 0, len(a.alerted))
+	for id := range a.alerted {
+		alerted = append(alerted, id)
+	}
+	sort.Ints(alerted)
+	if err := a.store.Save(alerted); err != nil {
+		log.Printf("alert: saving the alerted products failed: %v\n", err)
+	}
+}
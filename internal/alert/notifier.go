@@ -0,0 +1,158 @@
+package alert
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+)
+
+// The LogNotifier struct writes low-stock alerts to the standard logger.
+type LogNotifier struct{}
+
+// The NewLogNotifier function returns a new LogNotifier.
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// The Notify method logs the alert.
+func (n *LogNotifier) Notify(alert domain.LowStockAlert) error {
+	log.Printf("low stock: %s\n", describe(alert))
+	return nil
+}
+
+// The WebhookNotifier struct posts low-stock alerts as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// The NewWebhookNotifier function returns a new WebhookNotifier that posts to the given URL.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// The Notify method posts the alert. Any response other than 2xx is an error.
+func (n *WebhookNotifier) Notify(alert domain.LowStockAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	response, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// The SMTPNotifier struct emails low-stock alerts.
+type SMTPNotifier struct {
+	addr    string
+	auth    smtp.Auth
+	from    string
+	to      []string
+	timeout time.Duration
+}
+
+/*
+The NewSMTPNotifier function returns a new SMTPNotifier that sends its emails through the server at
+addr (host:port). If username is empty, no authentication is used. Every email must be sent within
+10 seconds, connection included.
+*/
+func NewSMTPNotifier(addr string, username string, password string, from string, to []string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host := strings.Split(addr, ":")[0]
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPNotifier{
+		addr:    addr,
+		auth:    auth,
+		from:    from,
+		to:      to,
+		timeout: 10 * time.Second,
+	}
+}
+
+/*
+The Notify method emails the alert to every recipient. It works like smtp.SendMail, upgrading the
+connection to TLS when the server supports it, but gives up once the timeout has passed.
+*/
+func (n *SMTPNotifier) Notify(alert domain.LowStockAlert) error {
+	message := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: Low stock: %s\r\n\r\n%s\r\n",
+		n.from, strings.Join(n.to, ", "), alert.CodeValue, describe(alert),
+	)
+
+	conn, err := net.DialTimeout("tcp", n.addr, n.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(n.timeout)); err != nil {
+		return err
+	}
+
+	host := strings.Split(n.addr, ":")[0]
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, recipient := range n.to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write([]byte(message)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Auxiliary function that describes an alert in a single line.
+func describe(alert domain.LowStockAlert) string {
+	return fmt.Sprintf(
+		"product %d (%s, %s) has %d units, below its reorder point of %d",
+		alert.ProductId, alert.CodeValue, alert.Name, alert.Quantity, alert.ReorderPoint,
+	)
+}
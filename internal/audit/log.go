@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+// Log is the interface definition for the audit log
+type Log interface {
+	Record(actor string, action string, productId int, details string) error
+	GetAll() ([]domain.AuditEntry, error)
+}
+
+// LogImpl is the implementation of the log interface
+type LogImpl struct {
+	store store.AppendStore[domain.AuditEntry]
+}
+
+// The NewLog function returns a new audit log that appends its entries to the provided store.
+func NewLog(entryStore store.AppendStore[domain.AuditEntry]) Log {
+	return &LogImpl{
+		store: entryStore,
+	}
+}
+
+// The Record method appends a new entry, stamped with the current time, to the audit log.
+func (l *LogImpl) Record(actor string, action string, productId int, details string) error {
+	return l.store.Append(domain.AuditEntry{
+		Time:      time.Now().UTC(),
+		Actor:     actor,
+		Action:    action,
+		ProductId: productId,
+		Details:   details,
+	})
+}
+
+// The GetAll method returns every entry in the audit log, oldest first.
+func (l *LogImpl) GetAll() ([]domain.AuditEntry, error) {
+	return l.store.LoadAll()
+}
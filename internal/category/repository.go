@@ -0,0 +1,122 @@
+package category
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("category not found")
+
+// Repository is the interface definition for the category storage
+type Repository interface {
+	GetById(id int) (domain.Category, error)
+	GetAll() []domain.Category
+	Create(category domain.Category) (domain.Category, error)
+	Update(category domain.Category) (domain.Category, error)
+	Delete(id int) error
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu         sync.RWMutex
+	categories []domain.Category
+	store      store.DocumentStore[[]domain.Category]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+categories and saves every change in the provided store.
+*/
+func NewRepository(categories []domain.Category, categoryStore store.DocumentStore[[]domain.Category]) Repository {
+	return &RepositoryImpl{
+		categories: categories,
+		store:      categoryStore,
+	}
+}
+
+// The GetById method returns a category by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, category := range r.categories {
+		if category.Id == id {
+			return category, nil
+		}
+	}
+	return domain.Category{}, ErrNotFound
+}
+
+// The GetAll method returns all the categories
+func (r *RepositoryImpl) GetAll() []domain.Category {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Category{}, r.categories...)
+}
+
+// The Create method stores a new category with the next available ID and returns it.
+func (r *RepositoryImpl) Create(category domain.Category) (domain.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// IDs are not reused after a deletion
+	category.Id = 1
+	for _, existing := range r.categories {
+		if existing.Id >= category.Id {
+			category.Id = existing.Id + 1
+		}
+	}
+	if err := r.save(append(r.copyList(), category)); err != nil {
+		return domain.Category{}, err
+	}
+	return category, nil
+}
+
+// The Update method replaces a stored category with the same ID.
+func (r *RepositoryImpl) Update(category domain.Category) (domain.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.categories {
+		if r.categories[i].Id == category.Id {
+			categories := r.copyList()
+			categories[i] = category
+			if err := r.save(categories); err != nil {
+				return domain.Category{}, err
+			}
+			return category, nil
+		}
+	}
+	return domain.Category{}, ErrNotFound
+}
+
+// The Delete method removes a stored category.
+func (r *RepositoryImpl) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.categories {
+		if r.categories[i].Id == id {
+			categories := append(r.copyList()[:i], r.categories[i+1:]...)
+			return r.save(categories)
+		}
+	}
+	return ErrNotFound
+}
+
+// Auxiliary function that returns a copy of the category list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.Category {
+	return append([]domain.Category{}, r.categories...)
+}
+
+// Auxiliary function that saves the category list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(categories []domain.Category) error {
+	if err := r.store.Save(categories); err != nil {
+		return err
+	}
+	r.categories = categories
+	return nil
+}
@@ -0,0 +1,430 @@
+package category
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/schema"
+)
+
+var (
+	ErrDuplicate     = errors.New("a category with the same name already exists under the same parent")
+	ErrInvalidParent = errors.New("the parent category does not exist or is the category itself or one of its descendants")
+	ErrNotEmpty      = errors.New("category still has subcategories or products")
+	ErrInUse         = errors.New("category is still used by the markdown policy or a promotion")
+	ErrNotAssigned   = errors.New("product is not assigned to the category")
+	ErrNoSchema      = errors.New("category has no attribute schema")
+)
+
+// MarkdownPolicies gives the markdown policy, whose schedules can be specific to a category.
+type MarkdownPolicies interface {
+	Get() domain.MarkdownPolicy
+}
+
+// Promotions gives the promotions, which can target categories.
+type Promotions interface {
+	GetAll() []domain.Promotion
+}
+
+type Service interface {
+	GetAll() []domain.Category
+	GetById(id int) (domain.Category, error)
+	Create(category domain.Category) (domain.Category, error)
+	Move(id int, parentId int) (domain.Category, error)
+	Delete(id int) error
+	AssignProduct(id int, productId int) (domain.Category, error)
+	UnassignProduct(id int, productId int) (domain.Category, error)
+	InCategory(products []domain.Product, id int) ([]domain.Product, error)
+	Ancestors(id int) []int
+	GetSchema(id int) (json.RawMessage, error)
+	SetSchema(id int, attributeSchema json.RawMessage) (domain.Category, error)
+	DeleteSchema(id int) error
+	ValidateAttributes(categoryId int, attributes domain.Attributes) error
+}
+
+type ServiceImpl struct {
+	// mu makes the checks of the tree and its products atomic with the changes they allow
+	mu         sync.Mutex
+	repository Repository
+	products   product.Repository
+	policies   MarkdownPolicies
+	promotions Promotions
+}
+
+/*
+The NewService function returns a new instance of the service. The product repository is used to
+count the products of every category, and to assign products to categories. The markdown policy
+and the promotions (both optional) keep the categories they refer to from being deleted.
+*/
+func NewService(repository Repository, products product.Repository, policies MarkdownPolicies, promotions Promotions) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+		policies:   policies,
+		promotions: promotions,
+	}
+}
+
+// The GetAll method returns all the categories with their product counts
+func (s *ServiceImpl) GetAll() []domain.Category {
+	return s.withCounts(s.repository.GetAll())
+}
+
+// The GetById method returns a category by its ID with its product counts
+func (s *ServiceImpl) GetById(id int) (domain.Category, error) {
+	category, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.Category{}, err
+	}
+	return s.withCounts([]domain.Category{category})[0], nil
+}
+
+/*
+The Create method stores a new category, at the top of the tree or under an existing parent. Names
+must be unique among the categories with the same parent.
+*/
+func (s *ServiceImpl) Create(category domain.Category) (domain.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categories := s.repository.GetAll()
+	if category.ParentId != 0 && findById(categories, category.ParentId) < 0 {
+		return domain.Category{}, ErrInvalidParent
+	}
+	if hasSibling(categories, category) {
+		return domain.Category{}, ErrDuplicate
+	}
+
+	// Attribute schemas are managed through their own endpoints
+	category.ProductCount, category.TotalProductCount = 0, 0
+	category.AttributeSchema = nil
+	return s.repository.Create(category)
+}
+
+/*
+The Move method moves a category, along with its subcategories and products, under another parent
+(or to the top of the tree with parent 0). A category cannot be moved under itself or any of its
+descendants.
+*/
+func (s *ServiceImpl) Move(id int, parentId int) (domain.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categories := s.repository.GetAll()
+	i := findById(categories, id)
+	if i < 0 {
+		return domain.Category{}, ErrNotFound
+	}
+	if parentId != 0 && (findById(categories, parentId) < 0 || contains(subtree(categories, id), parentId)) {
+		return domain.Category{}, ErrInvalidParent
+	}
+
+	category := categories[i]
+	category.ParentId = parentId
+	if hasSibling(categories, category) {
+		return domain.Category{}, ErrDuplicate
+	}
+	// The products of the category must match the schemas of its new ancestors
+	categories[i] = category
+	if err := s.updateChecked(categories, category); err != nil {
+		return domain.Category{}, err
+	}
+	return s.withCounts([]domain.Category{category})[0], nil
+}
+
+// The Delete method removes a category. Categories with subcategories or products cannot be removed.
+func (s *ServiceImpl) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, err := s.repository.GetById(id)
+	if err != nil {
+		return err
+	}
+	category = s.withCounts([]domain.Category{category})[0]
+	if category.TotalProductCount > 0 || len(subtree(s.repository.GetAll(), id)) > 1 {
+		return ErrNotEmpty
+	}
+	if err := s.checkReferences(id); err != nil {
+		return err
+	}
+	return s.repository.Delete(id)
+}
+
+// Auxiliary function that checks that neither the markdown policy nor any promotion refers to the category.
+func (s *ServiceImpl) checkReferences(id int) error {
+	if s.policies != nil {
+		for _, schedule := range s.policies.Get().Categories {
+			if schedule.CategoryId == id {
+				return fmt.Errorf("%w: the markdown policy has a schedule for it", ErrInUse)
+			}
+		}
+	}
+	if s.promotions != nil {
+		for _, promotion := range s.promotions.GetAll() {
+			if contains(promotion.CategoryIds, id) {
+				return fmt.Errorf("%w: promotion %d targets it", ErrInUse, promotion.Id)
+			}
+		}
+	}
+	return nil
+}
+
+// The AssignProduct method assigns a product to a category, replacing any previous one, and returns the category.
+func (s *ServiceImpl) AssignProduct(id int, productId int) (domain.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.repository.GetById(id); err != nil {
+		return domain.Category{}, err
+	}
+	categories := s.repository.GetAll()
+	if _, err := s.products.Modify(productId, func(p *domain.Product) error {
+		if err := validateAttributes(categories, id, p.Attributes); err != nil {
+			return err
+		}
+		p.CategoryId = id
+		return nil
+	}); err != nil {
+		return domain.Category{}, err
+	}
+	return s.GetById(id)
+}
+
+// The UnassignProduct method takes a product out of the category it is assigned to, and returns the category.
+func (s *ServiceImpl) UnassignProduct(id int, productId int) (domain.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.repository.GetById(id); err != nil {
+		return domain.Category{}, err
+	}
+	if _, err := s.products.Modify(productId, func(p *domain.Product) error {
+		if p.CategoryId != id {
+			return ErrNotAssigned
+		}
+		p.CategoryId = 0
+		return nil
+	}); err != nil {
+		return domain.Category{}, err
+	}
+	return s.GetById(id)
+}
+
+// The InCategory method returns the given products assigned to a category or any of its descendants.
+func (s *ServiceImpl) InCategory(products []domain.Product, id int) ([]domain.Product, error) {
+	categories := s.repository.GetAll()
+	if findById(categories, id) < 0 {
+		return nil, ErrNotFound
+	}
+
+	ids := subtree(categories, id)
+	filtered := []domain.Product{}
+	for _, p := range products {
+		if contains(ids, p.CategoryId) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
+}
+
+// The Ancestors method returns a category followed by its ancestors, up to the top of the tree. Unknown categories have none.
+func (s *ServiceImpl) Ancestors(id int) []int {
+	categories := s.repository.GetAll()
+
+	var ancestors []int
+	for i := findById(categories, id); i >= 0; i = findById(categories, categories[i].ParentId) {
+		ancestors = append(ancestors, categories[i].Id)
+	}
+	return ancestors
+}
+
+// The GetSchema method returns the JSON Schema that the attributes of the products in a category must match.
+func (s *ServiceImpl) GetSchema(id int) (json.RawMessage, error) {
+	category, err := s.repository.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+	if category.AttributeSchema == nil {
+		return nil, ErrNoSchema
+	}
+	return category.AttributeSchema, nil
+}
+
+/*
+The SetSchema method sets the JSON Schema that the attributes of the products in a category, and in
+its descendants, must match. The schema is rejected if any of those products does not match it.
+*/
+func (s *ServiceImpl) SetSchema(id int, attributeSchema json.RawMessage) (domain.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := schema.Compile(attributeSchema); err != nil {
+		return domain.Category{}, err
+	}
+	categories := s.repository.GetAll()
+	i := findById(categories, id)
+	if i < 0 {
+		return domain.Category{}, ErrNotFound
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, attributeSchema); err != nil {
+		return domain.Category{}, err
+	}
+	categories[i].AttributeSchema = compacted.Bytes()
+	if err := s.updateChecked(categories, categories[i]); err != nil {
+		return domain.Category{}, err
+	}
+	return s.withCounts([]domain.Category{categories[i]})[0], nil
+}
+
+// The DeleteSchema method removes the JSON Schema of a category, so the attributes of its products are free.
+func (s *ServiceImpl) DeleteSchema(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, err := s.repository.GetById(id)
+	if err != nil {
+		return err
+	}
+	if category.AttributeSchema == nil {
+		return ErrNoSchema
+	}
+	category.AttributeSchema = nil
+	_, err = s.repository.Update(category)
+	return err
+}
+
+/*
+The ValidateAttributes method checks that the attributes of a product in the given category match
+the schemas of the category and its ancestors. Products without a category can have any attributes.
+*/
+func (s *ServiceImpl) ValidateAttributes(categoryId int, attributes domain.Attributes) error {
+	return validateAttributes(s.repository.GetAll(), categoryId, attributes)
+}
+
+/*
+Auxiliary function that saves a category of the given tree once the products of the category and its
+descendants match the schemas of the tree. The check and the save happen inside a single change of
+the products, the same one product updates validate their attributes in, so no product can take
+attributes the new schemas reject in between.
+*/
+func (s *ServiceImpl) updateChecked(categories []domain.Category, category domain.Category) error {
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		return checkProducts(products, categories, category.Id)
+	}, func() error {
+		_, err := s.repository.Update(category)
+		return err
+	})
+	return err
+}
+
+// Auxiliary function that checks the given products of a category and its descendants against the schemas of the given tree.
+func checkProducts(products []domain.Product, categories []domain.Category, id int) error {
+	ids := subtree(categories, id)
+	for _, p := range products {
+		if p.CategoryId == 0 || !contains(ids, p.CategoryId) {
+			continue
+		}
+		if err := validateAttributes(categories, p.CategoryId, p.Attributes); err != nil {
+			return fmt.Errorf("%w (product %s)", err, p.CodeValue)
+		}
+	}
+	return nil
+}
+
+// Auxiliary function that checks attributes against the schemas of a category and its ancestors in the given tree.
+func validateAttributes(categories []domain.Category, categoryId int, attributes domain.Attributes) error {
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+	// A product without attributes is validated as an empty object, so required attributes are reported
+	if attributes == nil {
+		data = []byte("{}")
+	}
+
+	for i := findById(categories, categoryId); i >= 0; i = findById(categories, categories[i].ParentId) {
+		if categories[i].AttributeSchema == nil {
+			continue
+		}
+		compiled, err := schema.Compile(categories[i].AttributeSchema)
+		if err != nil {
+			return err
+		}
+		if err := compiled.ValidateJSON(data); err != nil {
+			return fmt.Errorf("%w (schema of category %q)", err, categories[i].Name)
+		}
+	}
+	return nil
+}
+
+// Auxiliary function that sets the product counts of the given categories.
+func (s *ServiceImpl) withCounts(categories []domain.Category) []domain.Category {
+	direct := map[int]int{}
+	for _, p := range s.products.GetAll() {
+		if p.CategoryId != 0 {
+			direct[p.CategoryId]++
+		}
+	}
+
+	all := s.repository.GetAll()
+	for i := range categories {
+		categories[i].ProductCount = direct[categories[i].Id]
+		categories[i].TotalProductCount = 0
+		for _, id := range subtree(all, categories[i].Id) {
+			categories[i].TotalProductCount += direct[id]
+		}
+	}
+	return categories
+}
+
+// Auxiliary function that returns the position of a category in the list, or -1 if it is not there.
+func findById(categories []domain.Category, id int) int {
+	for i := range categories {
+		if categories[i].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Auxiliary function that returns the IDs of a category and all its descendants.
+func subtree(categories []domain.Category, id int) []int {
+	ids := []int{id}
+	for next := 0; next < len(ids); next++ {
+		for _, category := range categories {
+			if category.ParentId == ids[next] {
+				ids = append(ids, category.Id)
+			}
+		}
+	}
+	return ids
+}
+
+// Auxiliary function that checks if another category with the same parent has the same name, ignoring case.
+func hasSibling(categories []domain.Category, category domain.Category) bool {
+	for _, other := range categories {
+		if other.Id != category.Id && other.ParentId == category.ParentId && strings.EqualFold(other.Name, category.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Auxiliary function that checks if a list of IDs contains the given one.
+func contains(ids []int, id int) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
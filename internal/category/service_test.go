@@ -0,0 +1,122 @@
+package category
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// promotionList gives a fixed list of promotions.
+type promotionList []domain.Promotion
+
+func (p promotionList) GetAll() []domain.Promotion {
+	return p
+}
+
+func createServiceForTest(t *testing.T, promotions promotionList) (Service, product.Repository) {
+	dir := t.TempDir()
+
+	// Food > Dairy > Cheese, and Drinks
+	categories := []domain.Category{
+		{Id: 1, Name: "Food"},
+		{Id: 2, Name: "Dairy", ParentId: 1},
+		{Id: 3, Name: "Cheese", ParentId: 2},
+		{Id: 4, Name: "Drinks"},
+	}
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", CategoryId: 2, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 2, CodeValue: "BRIE1", CategoryId: 3, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 3, CodeValue: "WATER1", Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	repository := NewRepository(categories, store.NewJsonDocumentStore[[]domain.Category](filepath.Join(dir, "categories.json")))
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	return NewService(repository, productRepository, nil, promotions), productRepository
+}
+
+func TestService_Create(t *testing.T) {
+	service, _ := createServiceForTest(t, nil)
+
+	tests := []struct {
+		name     string
+		category domain.Category
+		err      error
+	}{
+		{"Unknown parent", domain.Category{Name: "Bread", ParentId: 9}, ErrInvalidParent},
+		{"Name taken under the same parent", domain.Category{Name: "Cheese", ParentId: 2}, ErrDuplicate},
+		{"Name taken at the top", domain.Category{Name: "Drinks"}, ErrDuplicate},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.Create(test.category)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	// The same name can be used under another parent
+	created, err := service.Create(domain.Category{Name: "Cheese", ParentId: 4, ProductCount: 7})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, created.Id)
+	assert.Equal(t, 0, created.ProductCount)
+}
+
+func TestService_Move(t *testing.T) {
+	service, _ := createServiceForTest(t, nil)
+
+	// A category cannot be moved under itself or its descendants
+	_, err := service.Move(1, 3)
+	assert.ErrorIs(t, err, ErrInvalidParent)
+	_, err = service.Move(2, 2)
+	assert.ErrorIs(t, err, ErrInvalidParent)
+	_, err = service.Move(9, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Subcategories and products move along
+	moved, err := service.Move(2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, moved.ParentId)
+	assert.Equal(t, []int{3, 2, 4}, service.Ancestors(3))
+	drinks, err := service.GetById(4)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, drinks.ProductCount)
+	assert.Equal(t, 2, drinks.TotalProductCount)
+}
+
+func TestService_Delete(t *testing.T) {
+	service, productRepository := createServiceForTest(t, promotionList{{Id: 1, Name: "10% off drinks", CategoryIds: []int{4}}})
+
+	// Categories with subcategories, products or promotions are kept
+	assert.ErrorIs(t, service.Delete(2), ErrNotEmpty)
+	assert.ErrorIs(t, service.Delete(4), ErrInUse)
+
+	_, err := service.UnassignProduct(3, 1)
+	assert.ErrorIs(t, err, ErrNotAssigned)
+	_, err = service.UnassignProduct(3, 2)
+	assert.Nil(t, err)
+	brie, _ := productRepository.GetById(2)
+	assert.Equal(t, 0, brie.CategoryId)
+	assert.Nil(t, service.Delete(3))
+	assert.ErrorIs(t, service.Delete(3), ErrNotFound)
+}
+
+func TestService_InCategory(t *testing.T) {
+	service, productRepository := createServiceForTest(t, nil)
+
+	// Products of the subcategories are included
+	products, err := service.InCategory(productRepository.GetAll(), 1)
+	assert.Nil(t, err)
+	assert.Len(t, products, 2)
+	_, err = service.InCategory(productRepository.GetAll(), 9)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assigned, err := service.AssignProduct(4, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, assigned.ProductCount)
+	products, err = service.InCategory(productRepository.GetAll(), 4)
+	assert.Nil(t, err)
+	assert.Equal(t, "WATER1", products[0].CodeValue)
+}
@@ -0,0 +1,51 @@
+package currency
+
+import (
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+// Repository is the interface definition for the exchange rates storage
+type Repository interface {
+	Get() domain.ExchangeRates
+	Update(rates domain.ExchangeRates) error
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu    sync.RWMutex
+	rates domain.ExchangeRates
+	store store.DocumentStore[domain.ExchangeRates]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given rates
+and saves every update in the provided store.
+*/
+func NewRepository(rates domain.ExchangeRates, ratesStore store.DocumentStore[domain.ExchangeRates]) Repository {
+	return &RepositoryImpl{
+		rates: rates,
+		store: ratesStore,
+	}
+}
+
+// The Get method returns the current exchange rates
+func (r *RepositoryImpl) Get() domain.ExchangeRates {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rates
+}
+
+// The Update method replaces the exchange rates, saving them in the store first.
+func (r *RepositoryImpl) Update(rates domain.ExchangeRates) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.store.Save(rates); err != nil {
+		return err
+	}
+	r.rates = rates
+	return nil
+}
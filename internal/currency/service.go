@@ -0,0 +1,132 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+)
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidRates        = domain.ErrInvalidRates
+	ErrCurrencyInUse       = errors.New("currency is still in use")
+)
+
+// Promotions gives the promotions, whose fixed discounts are in a currency.
+type Promotions interface {
+	GetAll() []domain.Promotion
+}
+
+type Service interface {
+	GetRates() domain.ExchangeRates
+	UpdateRates(rates domain.ExchangeRates) (domain.ExchangeRates, error)
+	Convert(amount domain.Money, currency string) (domain.Money, error)
+}
+
+type ServiceImpl struct {
+	repository Repository
+	products   product.Repository
+	promotions Promotions
+}
+
+/*
+The NewService function returns a new instance of the service. The products and the promotions keep
+the currencies they are priced in from being removed from the rates.
+*/
+func NewService(repository Repository, products product.Repository, promotions Promotions) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+		promotions: promotions,
+	}
+}
+
+// The GetRates method returns the current exchange rates
+func (s *ServiceImpl) GetRates() domain.ExchangeRates {
+	return s.repository.Get()
+}
+
+/*
+The UpdateRates method validates and stores a new exchange rates table. Every rate must be positive,
+the base currency must have a rate of 1 (it is added if missing) and every currency must have a
+rounding rule between 0 and 4 decimal places. Currencies that products or fixed promotions are
+priced in cannot be removed.
+*/
+func (s *ServiceImpl) UpdateRates(rates domain.ExchangeRates) (domain.ExchangeRates, error) {
+	// The base currency always converts to itself
+	if _, ok := rates.Rates[rates.Base]; !ok && rates.Base != "" && rates.Rates != nil {
+		rates.Rates[rates.Base] = domain.NewDecimal(1)
+	}
+	if err := rates.Validate(); err != nil {
+		return domain.ExchangeRates{}, err
+	}
+	rates.UpdatedAt = time.Now().UTC()
+
+	// The rates are stored inside a change of the products, so no product can take a removed currency in between
+	if _, err := s.products.ModifyAll(func(products []domain.Product) error {
+		for _, p := range products {
+			for _, currency := range pricedIn(p) {
+				if _, ok := rates.Rates[currency]; !ok {
+					return fmt.Errorf("%w: product %s is priced in %s", ErrCurrencyInUse, p.CodeValue, currency)
+				}
+			}
+		}
+		return s.checkPromotions(rates)
+	}, func() error {
+		return s.repository.Update(rates)
+	}); err != nil {
+		return domain.ExchangeRates{}, err
+	}
+	return rates, nil
+}
+
+/*
+The Convert method converts an amount of money to the given currency, rounding the result with the
+rules of the target currency. If any of the currencies is unknown, it returns an error.
+*/
+func (s *ServiceImpl) Convert(amount domain.Money, currency string) (domain.Money, error) {
+	rates := s.repository.Get()
+
+	fromRate, ok := rates.Rates[amount.Currency]
+	if !ok {
+		return domain.Money{}, ErrUnsupportedCurrency
+	}
+	toRate, ok := rates.Rates[currency]
+	if !ok {
+		return domain.Money{}, ErrUnsupportedCurrency
+	}
+
+	converted, err := amount.Amount.Mul(toRate).Div(fromRate)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return domain.NewMoney(converted.Round(rates.Decimals[currency]), currency), nil
+}
+
+// Auxiliary function that checks that the fixed promotions are in currencies of the given rates.
+func (s *ServiceImpl) checkPromotions(rates domain.ExchangeRates) error {
+	for _, promotion := range s.promotions.GetAll() {
+		if promotion.Type != domain.PromotionFixed {
+			continue
+		}
+		if _, ok := rates.Rates[promotion.Currency]; !ok {
+			return fmt.Errorf("%w: promotion %d is in %s", ErrCurrencyInUse, promotion.Id, promotion.Currency)
+		}
+	}
+	return nil
+}
+
+// Auxiliary function that returns the currencies of the prices of a product: its price, list price, tiers and scheduled prices.
+func pricedIn(p domain.Product) []string {
+	currencies := []string{p.Price.Currency, p.ListPrice().Currency}
+	for _, tier := range p.PriceTiers {
+		currencies = append(currencies, tier.Price.Currency)
+	}
+	for _, scheduled := range p.ScheduledPrices {
+		currencies = append(currencies, scheduled.Price.Currency)
+	}
+	return currencies
+}
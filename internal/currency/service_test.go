@@ -0,0 +1,103 @@
+package currency
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/promotion"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createServiceForTest(t *testing.T, products []domain.Product, promotions []domain.Promotion) (Service, Repository) {
+	dir := t.TempDir()
+
+	// Dollars, Chilean pesos without decimals and Argentine pesos
+	rates := domain.ExchangeRates{
+		Base:     "USD",
+		Rates:    map[string]domain.Decimal{"USD": domain.NewDecimal(1), "CLP": domain.NewDecimal(940), "ARS": domain.NewDecimal(1050)},
+		Decimals: map[string]int{"USD": 2, "CLP": 0, "ARS": 2},
+	}
+	repository := NewRepository(rates, store.NewJsonDocumentStore[domain.ExchangeRates](filepath.Join(dir, "rates.json")))
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	promotionRepository := promotion.NewRepository(promotions, store.NewJsonDocumentStore[[]domain.Promotion](filepath.Join(dir, "promotions.json")))
+
+	return NewService(repository, productRepository, promotionRepository), repository
+}
+
+func TestService_Convert(t *testing.T) {
+	service, _ := createServiceForTest(t, nil, nil)
+
+	tests := []struct {
+		amount   domain.Money
+		currency string
+		expected string
+	}{
+		{domain.NewMoney(domain.MustParseDecimal("10.5"), "USD"), "CLP", "9870"},
+		{domain.NewMoney(domain.MustParseDecimal("1000"), "CLP"), "USD", "1.06"},
+		{domain.NewMoney(domain.MustParseDecimal("1000"), "CLP"), "ARS", "1117.02"},
+		{domain.NewMoney(domain.MustParseDecimal("352.79"), "USD"), "USD", "352.79"},
+	}
+	for _, test := range tests {
+		converted, err := service.Convert(test.amount, test.currency)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, converted.Amount.String())
+		assert.Equal(t, test.currency, converted.Currency)
+	}
+
+	_, err := service.Convert(domain.NewMoney(domain.NewDecimal(1), "EUR"), "USD")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	_, err = service.Convert(domain.NewMoney(domain.NewDecimal(1), "USD"), "EUR")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestService_UpdateRates(t *testing.T) {
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Price: domain.NewMoney(domain.NewDecimal(900), "CLP"), Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	promotions := []domain.Promotion{
+		{Id: 1, Name: "100 ARS off", Type: domain.PromotionFixed, Value: domain.NewDecimal(100), Currency: "ARS", ProductIds: []int{1}},
+		{Id: 2, Name: "10% off", Type: domain.PromotionPercentage, Value: domain.NewDecimal(10), ProductIds: []int{1}},
+	}
+	service, repository := createServiceForTest(t, products, promotions)
+
+	// The base currency is added when missing, and every currency needs a positive rate and its decimals
+	for _, rates := range []domain.ExchangeRates{
+		{},
+		{Base: "USD", Rates: map[string]domain.Decimal{"USD": domain.NewDecimal(2)}, Decimals: map[string]int{"USD": 2}},
+		{Base: "USD", Rates: map[string]domain.Decimal{"CLP": domain.NewDecimal(-1)}, Decimals: map[string]int{"USD": 2, "CLP": 0}},
+		{Base: "USD", Rates: map[string]domain.Decimal{"CLP": domain.NewDecimal(940)}, Decimals: map[string]int{"USD": 2}},
+		{Base: "USD", Rates: map[string]domain.Decimal{"CLP": domain.NewDecimal(940)}, Decimals: map[string]int{"USD": 2, "CLP": 5}},
+	} {
+		_, err := service.UpdateRates(rates)
+		assert.ErrorIs(t, err, ErrInvalidRates)
+	}
+
+	// Currencies in use by products or fixed promotions cannot be removed
+	_, err := service.UpdateRates(domain.ExchangeRates{
+		Base:     "USD",
+		Rates:    map[string]domain.Decimal{"ARS": domain.NewDecimal(1100)},
+		Decimals: map[string]int{"USD": 2, "ARS": 2},
+	})
+	assert.ErrorIs(t, err, ErrCurrencyInUse)
+	_, err = service.UpdateRates(domain.ExchangeRates{
+		Base:     "USD",
+		Rates:    map[string]domain.Decimal{"CLP": domain.NewDecimal(950)},
+		Decimals: map[string]int{"USD": 2, "CLP": 0},
+	})
+	assert.ErrorIs(t, err, ErrCurrencyInUse)
+	assert.Equal(t, "940", repository.Get().Rates["CLP"].String())
+
+	updated, err := service.UpdateRates(domain.ExchangeRates{
+		Base:     "USD",
+		Rates:    map[string]domain.Decimal{"CLP": domain.NewDecimal(950), "ARS": domain.NewDecimal(1100)},
+		Decimals: map[string]int{"USD": 2, "CLP": 0, "ARS": 2},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", updated.Rates["USD"].String())
+	assert.False(t, updated.UpdatedAt.IsZero())
+	assert.Equal(t, "950", repository.Get().Rates["CLP"].String())
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// The LowStockAlert struct represents a warning that a product quantity dropped below its reorder point.
+type LowStockAlert struct {
+	ProductId    int       `json:"product_id" example:"1"`
+	CodeValue    string    `json:"code_value" example:"COD123"`
+	Name         string    `json:"name" example:"Pineapple"`
+	Quantity     int       `json:"quantity" example:"4"`
+	ReorderPoint int       `json:"reorder_point" example:"10"`
+	Time         time.Time `json:"time"`
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// The Attributes type holds the custom attributes of a product, such as its brand or origin, as written.
+type Attributes map[string]json.RawMessage
+
+/*
+The MatchesAttribute method reports whether the product has an attribute with the given value, as
+written in a query string. Strings are compared ignoring case, numbers and booleans by value, and
+arrays match if any of their items does.
+*/
+func (p Product) MatchesAttribute(name string, value string) bool {
+	raw, ok := p.Attributes[name]
+	if !ok {
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return false
+	}
+	return matchesValue(decoded, value)
+}
+
+// Auxiliary function that checks if a decoded attribute value matches a value written in a query string.
+func matchesValue(decoded interface{}, value string) bool {
+	switch v := decoded.(type) {
+	case string:
+		return strings.EqualFold(v, value)
+	case json.Number:
+		attribute, errA := v.Float64()
+		wanted, errB := strconv.ParseFloat(value, 64)
+		return errA == nil && errB == nil && attribute == wanted
+	case bool:
+		wanted, err := strconv.ParseBool(value)
+		return err == nil && v == wanted
+	case []interface{}:
+		for _, item := range v {
+			if matchesValue(item, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
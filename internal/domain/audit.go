@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+/*
+The AuditEntry struct represents an action recorded in the audit log.
+
+	Actor (string): Who performed the action. Example: "expiration-monitor".
+	Action (string): What was done. Example: "unpublish_expired".
+	ProductId (int): Affected product, if any.
+	Details (string): Human readable description of the action.
+*/
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor" example:"expiration-monitor"`
+	Action    string    `json:"action" example:"unpublish_expired"`
+	ProductId int       `json:"product_id,omitempty" example:"1"`
+	Details   string    `json:"details,omitempty"`
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidBundle = errors.New("invalid bundle components")
+
+/*
+The BundleComponent struct represents the units of another product that make up one unit of a
+bundle, such as the 2 bottles of wine in a gift basket.
+*/
+type BundleComponent struct {
+	ProductId int `json:"product_id" example:"1" binding:"required"`
+	Quantity  int `json:"quantity" example:"2" binding:"required"`
+}
+
+/*
+The IsBundle method reports whether the product is a bundle of other products. Bundles hold no
+stock of their own: their quantities come from the stock of their components.
+*/
+func (p Product) IsBundle() bool {
+	return len(p.Components) > 0
+}
+
+/*
+The ValidateComponents method checks that every component of the product has a positive quantity
+and appears only once, and that the product is not a component of itself.
+*/
+func (p Product) ValidateComponents() error {
+	seen := map[int]bool{}
+	for _, component := range p.Components {
+		if component.Quantity <= 0 {
+			return fmt.Errorf("%w: quantities must be greater than zero", ErrInvalidBundle)
+		}
+		if component.ProductId == p.Id || seen[component.ProductId] {
+			return fmt.Errorf("%w: product %d is repeated or the bundle itself", ErrInvalidBundle, component.ProductId)
+		}
+		seen[component.ProductId] = true
+	}
+	return nil
+}
+
+// The HasComponent method reports whether the product is a bundle that includes the given product.
+func (p Product) HasComponent(productId int) bool {
+	for _, component := range p.Components {
+		if component.ProductId == productId {
+			return true
+		}
+	}
+	return false
+}
+
+// The ComponentQuantity method returns the units of the given product in one unit of the bundle, 0 if it is not a component.
+func (p Product) ComponentQuantity(productId int) int {
+	for _, component := range p.Components {
+		if component.ProductId == productId {
+			return component.Quantity
+		}
+	}
+	return 0
+}
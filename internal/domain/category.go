@@ -0,0 +1,29 @@
+package domain
+
+import "encoding/json"
+
+/*
+The Category struct represents a node of the product taxonomy. Categories without a parent are at
+the top of the tree. Product counts are computed from the products when categories are read: the
+products assigned to the category itself, and those assigned to it or any of its descendants. The
+attributes of the products in a category must match its JSON Schema, if it has one, and those of
+its ancestors.
+*/
+type Category struct {
+	Id                int             `json:"id" example:"2"`
+	Name              string          `json:"name" example:"Dairy" binding:"required"`
+	ParentId          int             `json:"parent_id,omitempty" example:"1"`
+	ProductCount      int             `json:"product_count" example:"12"`
+	TotalProductCount int             `json:"total_product_count" example:"40"`
+	AttributeSchema   json.RawMessage `json:"attribute_schema,omitempty" swaggertype:"object"`
+}
+
+// CategoryMoveRequest is the body of a request that moves a category under another one, or to the top of the tree.
+type CategoryMoveRequest struct {
+	ParentId int `json:"parent_id" example:"1"`
+}
+
+// CategoryAssignRequest is the body of a request that assigns a product to a category.
+type CategoryAssignRequest struct {
+	ProductId int `json:"product_id" example:"1" binding:"required"`
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidDate = errors.New("invalid expiration date format")
+
+// ISO 8601 is the canonical layout. The DD/MM/YYYY layout is still accepted for legacy data.
+const (
+	isoDateLayout    = "2006-01-02"
+	legacyDateLayout = "02/01/2006"
+)
+
+/*
+The Date struct represents a civil date (year, month and day) without any time or time zone
+information. It is serialized as an ISO 8601 string (YYYY-MM-DD).
+*/
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// The NewDate function returns a normalized Date for the given year, month and day.
+func NewDate(year int, month time.Month, day int) Date {
+	return DateOf(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// The DateOf function returns the civil date of the given instant in its own location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// The Today function returns the current civil date in the given location.
+func Today(location *time.Location) Date {
+	return DateOf(time.Now().In(location))
+}
+
+/*
+The ParseDate function parses a date string. It accepts ISO 8601 dates (YYYY-MM-DD), RFC 3339
+timestamps and the legacy DD/MM/YYYY format. Otherwise, it returns ErrInvalidDate.
+*/
+func ParseDate(value string) (Date, error) {
+	for _, layout := range []string{isoDateLayout, legacyDateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return DateOf(t), nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return DateOf(t), nil
+	}
+	return Date{}, ErrInvalidDate
+}
+
+// The String method returns the date in ISO 8601 format.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// The IsZero method reports whether the date is unset.
+func (d Date) IsZero() bool {
+	return d.Year == 0 && d.Month == 0 && d.Day == 0
+}
+
+// The In method returns the instant at which the date starts in the given location.
+func (d Date) In(location *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, location)
+}
+
+// The AddDays method returns the date n days after d (or before, if n is negative).
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.In(time.UTC).AddDate(0, 0, n))
+}
+
+// The DaysUntil method returns the number of days from d to other. It is negative if other is before d.
+func (d Date) DaysUntil(other Date) int {
+	return int(other.In(time.UTC).Sub(d.In(time.UTC)).Hours() / 24)
+}
+
+/*
+The Compare method compares two dates. It returns -1 if d is before other, 1 if d is after
+other and 0 if both are the same date.
+*/
+func (d Date) Compare(other Date) int {
+	switch {
+	case d.Year != other.Year:
+		return sign(d.Year - other.Year)
+	case d.Month != other.Month:
+		return sign(int(d.Month - other.Month))
+	default:
+		return sign(d.Day - other.Day)
+	}
+}
+
+// The Before method reports whether d occurs before other.
+func (d Date) Before(other Date) bool {
+	return d.Compare(other) < 0
+}
+
+// The After method reports whether d occurs after other.
+func (d Date) After(other Date) bool {
+	return d.Compare(other) > 0
+}
+
+// The MarshalJSON method encodes the date as an ISO 8601 string. Unset dates are encoded as "".
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(d.String())
+}
+
+// The UnmarshalJSON method decodes a date from any of the formats accepted by ParseDate.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return ErrInvalidDate
+	}
+	if value == "" {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Auxiliary function that returns the sign of an integer.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal value")
+
+// Decimals are stored as an integer number of ten-thousandths, so every value with up to four decimal places is exact.
+const (
+	decimalPlaces = 4
+	decimalScale  = 10000
+)
+
+/*
+The Decimal struct represents an exact decimal number with four decimal places. It is serialized
+as a JSON number and also accepts JSON strings when decoding.
+*/
+type Decimal struct {
+	units int64
+}
+
+// The NewDecimal function returns a Decimal holding the given integer value.
+func NewDecimal(value int64) Decimal {
+	return Decimal{units: value * decimalScale}
+}
+
+/*
+The ParseDecimal function parses a decimal string such as "71.42" or "-3". Values with more than
+four decimal places are rounded half away from zero. Otherwise, it returns ErrInvalidDecimal.
+*/
+func ParseDecimal(value string) (Decimal, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || strings.ContainsAny(value, "/eE") {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	return decimalFromRat(rat)
+}
+
+// The MustParseDecimal function works like ParseDecimal but panics on invalid values.
+func MustParseDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// The Add method returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{units: d.units + other.units}
+}
+
+// The Sub method returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{units: d.units - other.units}
+}
+
+// The MulInt method returns d multiplied by an integer.
+func (d Decimal) MulInt(n int64) Decimal {
+	return Decimal{units: d.units * n}
+}
+
+// The Mul method returns d * other, rounded half away from zero to four decimal places.
+func (d Decimal) Mul(other Decimal) Decimal {
+	result, _ := decimalFromRat(new(big.Rat).Mul(d.rat(), other.rat()))
+	return result
+}
+
+/*
+The Div method returns d / other, rounded half away from zero to four decimal places. It returns
+an error if other is zero.
+*/
+func (d Decimal) Div(other Decimal) (Decimal, error) {
+	if other.IsZero() {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	return decimalFromRat(new(big.Rat).Quo(d.rat(), other.rat()))
+}
+
+// The Round method rounds d half away from zero to the given number of decimal places (0 to 4).
+func (d Decimal) Round(places int) Decimal {
+	if places >= decimalPlaces {
+		return d
+	}
+	step := int64(1)
+	for i := places; i < decimalPlaces; i++ {
+		step *= 10
+	}
+	quotient, remainder := d.units/step, d.units%step
+	if remainder*2 >= step {
+		quotient++
+	} else if remainder*2 <= -step {
+		quotient--
+	}
+	return Decimal{units: quotient * step}
+}
+
+// The Cmp method returns -1, 0 or 1 depending on whether d is less than, equal to or greater than other.
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.units < other.units:
+		return -1
+	case d.units > other.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// The GreaterThan method reports whether d > other.
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.units > other.units
+}
+
+// The LessThan method reports whether d < other.
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.units < other.units
+}
+
+// The IsZero method reports whether d is zero.
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// The IsPositive method reports whether d is greater than zero.
+func (d Decimal) IsPositive() bool {
+	return d.units > 0
+}
+
+// The IsNegative method reports whether d is less than zero.
+func (d Decimal) IsNegative() bool {
+	return d.units < 0
+}
+
+// The String method returns the shortest exact representation of d, such as "71.42".
+func (d Decimal) String() string {
+	units := d.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	integer := strconv.FormatInt(units/decimalScale, 10)
+	fraction := strings.TrimRight(strconv.FormatInt(decimalScale+units%decimalScale, 10)[1:], "0")
+	if fraction == "" {
+		return sign + integer
+	}
+	return sign + integer + "." + fraction
+}
+
+// The MarshalJSON method encodes d as a JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// The UnmarshalJSON method decodes d from a JSON number or a JSON string, without going through float64.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return ErrInvalidDecimal
+		}
+	}
+
+	parsed, err := ParseDecimal(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Auxiliary function that returns d as a rational number.
+func (d Decimal) rat() *big.Rat {
+	return new(big.Rat).SetFrac64(d.units, decimalScale)
+}
+
+// Auxiliary function that rounds a rational number half away from zero into a Decimal.
+func decimalFromRat(rat *big.Rat) (Decimal, error) {
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt64(decimalScale))
+	numerator, denominator := scaled.Num(), scaled.Denom()
+
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(denominator) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(numerator.Sign())))
+	}
+	if !quotient.IsInt64() {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	return Decimal{units: quotient.Int64()}, nil
+}
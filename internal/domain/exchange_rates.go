@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidRates = errors.New("invalid exchange rates")
+
+/*
+The ExchangeRates struct represents the currency conversion table.
+
+	Base (string): Currency all the rates are relative to. Example: "USD".
+	Rates (map): Units of each currency per unit of the base currency. Example: {"CLP": 950}.
+	Decimals (map): Number of decimal places prices are rounded to in each currency. Example: {"CLP": 0}.
+*/
+type ExchangeRates struct {
+	Base      string             `json:"base" example:"USD"`
+	Rates     map[string]Decimal `json:"rates" swaggertype:"object,number"`
+	Decimals  map[string]int     `json:"decimals" swaggertype:"object,integer"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+/*
+The Validate method checks that the base currency has a rate of 1, and that every currency has a
+positive rate and a rounding rule between 0 and 4 decimal places.
+*/
+func (r ExchangeRates) Validate() error {
+	if r.Base == "" || r.Rates == nil || r.Decimals == nil {
+		return ErrInvalidRates
+	}
+	if rate, ok := r.Rates[r.Base]; !ok || rate.Cmp(NewDecimal(1)) != 0 {
+		return fmt.Errorf("%w: the base currency %s must have a rate of 1", ErrInvalidRates, r.Base)
+	}
+	for currency, rate := range r.Rates {
+		decimals, ok := r.Decimals[currency]
+		if !rate.IsPositive() || !ok || decimals < 0 || decimals > 4 {
+			return fmt.Errorf("%w: %s needs a positive rate and between 0 and 4 decimals", ErrInvalidRates, currency)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package domain
+
+import "errors"
+
+var (
+	ErrInsufficientStockAt    = errors.New("insufficient stock at location")
+	ErrLocationTrackedProduct = errors.New("quantity of a product stocked in several locations is the sum of its locations")
+)
+
+// DefaultLocation is the location that holds the stock of a product before it is spread over several locations.
+const DefaultLocation = "main"
+
+// The Location struct represents a place where stock is kept, such as a warehouse.
+type Location struct {
+	Code    string `json:"code" example:"main" binding:"required"`
+	Name    string `json:"name" example:"Main warehouse" binding:"required"`
+	Address string `json:"address,omitempty" example:"Av. Siempre Viva 742"`
+}
+
+// The LocationStock struct represents the units of a product kept at a location.
+type LocationStock struct {
+	Location string `json:"location" example:"main"`
+	Quantity int    `json:"quantity" example:"40"`
+}
+
+// The LocationMovement struct represents the part of a stock movement applied to a single location.
+type LocationMovement struct {
+	Location string `json:"location" example:"main"`
+	Delta    int    `json:"delta" example:"-3"`
+}
+
+// TransferRequest is the body of a request that moves units of a product between two locations.
+type TransferRequest struct {
+	From      string `json:"from" example:"main" binding:"required"`
+	To        string `json:"to" example:"north"`
+	Quantity  int    `json:"quantity" example:"5" binding:"required"`
+	Reference string `json:"reference,omitempty" example:"TR-12"`
+}
+
+// The IsLocationTracked method reports whether the product stock is spread over locations.
+func (p Product) IsLocationTracked() bool {
+	return len(p.Stock) > 0
+}
+
+/*
+The QuantityAt method returns the units of the product kept at a location. The stock of a product
+that is not spread over locations is kept at DefaultLocation.
+*/
+func (p Product) QuantityAt(location string) int {
+	if !p.IsLocationTracked() {
+		if location == DefaultLocation {
+			return p.Quantity
+		}
+		return 0
+	}
+	for _, stock := range p.Stock {
+		if stock.Location == location {
+			return stock.Quantity
+		}
+	}
+	return 0
+}
+
+/*
+The TrackLocations method starts spreading the product stock over locations, assigning its current
+quantity to DefaultLocation. It does nothing if the product is already location-tracked.
+*/
+func (p *Product) TrackLocations() {
+	if !p.IsLocationTracked() {
+		p.Stock = []LocationStock{{Location: DefaultLocation, Quantity: p.Quantity}}
+	}
+}
+
+/*
+The AdjustAt method adds a signed delta to the stock of a location-tracked product at a location.
+The stock at a location only goes below zero if the product allows backorders. It does not change
+the product quantity, which is kept by the caller.
+*/
+func (p *Product) AdjustAt(location string, delta int) ([]LocationMovement, error) {
+	i := p.findLocation(location)
+	if i < 0 {
+		p.Stock = append(p.Stock, LocationStock{Location: location})
+		i = len(p.Stock) - 1
+	}
+	if p.Stock[i].Quantity+delta < 0 && !p.AllowBackorder {
+		return nil, ErrInsufficientStockAt
+	}
+	p.Stock[i].Quantity += delta
+	return []LocationMovement{{Location: location, Delta: delta}}, nil
+}
+
+/*
+The TakeFromLocations method takes units out of the locations of a location-tracked product, in
+order, and returns how many units were taken from each one. If available is not nil, the units it
+reports for each location are taken first, and the rest of the units of a location only after them.
+Any shortage (a backorder) is taken from DefaultLocation. It does not change the product quantity,
+which is kept by the caller.
+*/
+func (p *Product) TakeFromLocations(units int, available func(location string) int) []LocationMovement {
+	taken := make([]int, len(p.Stock))
+	take := func(limit func(i int) int) {
+		for i := range p.Stock {
+			n := limit(i) - taken[i]
+			if n > units {
+				n = units
+			}
+			if n > 0 {
+				taken[i] += n
+				units -= n
+			}
+		}
+	}
+	if available != nil {
+		take(func(i int) int {
+			if n := available(p.Stock[i].Location); n < p.Stock[i].Quantity {
+				return n
+			}
+			return p.Stock[i].Quantity
+		})
+	}
+	take(func(i int) int { return p.Stock[i].Quantity })
+
+	var movements []LocationMovement
+	for i := range taken {
+		if taken[i] > 0 {
+			p.Stock[i].Quantity -= taken[i]
+			movements = append(movements, LocationMovement{Location: p.Stock[i].Location, Delta: -taken[i]})
+		}
+	}
+
+	if units > 0 {
+		i := p.findLocation(DefaultLocation)
+		if i < 0 {
+			p.Stock = append(p.Stock, LocationStock{Location: DefaultLocation})
+			i = len(p.Stock) - 1
+		}
+		p.Stock[i].Quantity -= units
+		movements = append(movements, LocationMovement{Location: DefaultLocation, Delta: -units})
+	}
+	return movements
+}
+
+// Auxiliary method that returns the index of the stock kept at a location, or -1 if there is none.
+func (p Product) findLocation(location string) int {
+	for i, stock := range p.Stock {
+		if stock.Location == location {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrLotNotFound       = errors.New("lot not found")
+	ErrDuplicateLot      = errors.New("lot code already exists for this product")
+	ErrInsufficientLots  = errors.New("insufficient stock in lots")
+	ErrLotNotEmpty       = errors.New("lot still has stock")
+	ErrLastLot           = errors.New("the last lot of a product cannot be removed, it keeps the product lot-tracked")
+	ErrLotTrackedProduct = errors.New("quantity of a lot-tracked product is the sum of its lots")
+)
+
+/*
+The Lot struct represents a batch of a product received together, with its own quantity and
+expiration date.
+*/
+type Lot struct {
+	Code       string    `json:"code" example:"L2030-08"`
+	Quantity   int       `json:"quantity" example:"50"`
+	Expiration Date      `json:"expiration" example:"2030-08-25" swaggertype:"string" format:"date"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// The LotMovement struct represents the part of a stock movement applied to a single lot.
+type LotMovement struct {
+	Code  string `json:"code" example:"L2030-08"`
+	Delta int    `json:"delta" example:"-3"`
+}
+
+// LotRequest is the body of a request that receives a new lot.
+type LotRequest struct {
+	Code       string `json:"code" example:"L2030-08" binding:"required"`
+	Quantity   int    `json:"quantity" example:"50"`
+	Expiration Date   `json:"expiration" example:"2030-08-25" swaggertype:"string" format:"date"`
+	Location   string `json:"location,omitempty" example:"main"`
+}
+
+// The IsLotTracked method reports whether the product stock is kept in lots.
+func (p Product) IsLotTracked() bool {
+	return len(p.Lots) > 0
+}
+
+// The FindLot method returns the index of the lot with the given code, or -1 if there is none.
+func (p Product) FindLot(code string) int {
+	for i, lot := range p.Lots {
+		if lot.Code == code {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+The AddLot method adds a new lot to the product. If the product was not lot-tracked yet, its
+current quantity becomes an "initial" lot with the product expiration date, so no stock is lost.
+Today is used to sync the product expiration (see SyncLots).
+*/
+func (p *Product) AddLot(lot Lot, today Date) error {
+	if p.FindLot(lot.Code) >= 0 {
+		return ErrDuplicateLot
+	}
+	if !p.IsLotTracked() && p.Quantity > 0 {
+		p.Lots = append(p.Lots, Lot{Code: "initial", Quantity: p.Quantity, Expiration: p.Expiration, ReceivedAt: lot.ReceivedAt})
+	}
+
+	p.Lots = append(p.Lots, lot)
+	p.SyncLots(today)
+	return nil
+}
+
+/*
+The ConsumeLots method takes units out of the product lots, first-expired-first-out, and returns
+how many units were taken from each lot. Lots for which skip returns true are left untouched. If
+the other lots do not hold enough units, nothing changes. Today is used like in AddLot.
+*/
+func (p *Product) ConsumeLots(units int, today Date, skip func(lot Lot) bool) ([]LotMovement, error) {
+	total := 0
+	for _, lot := range p.Lots {
+		if !skip(lot) {
+			total += lot.Quantity
+		}
+	}
+	if total < units {
+		return nil, ErrInsufficientLots
+	}
+
+	// Visit the lots from the soonest to expire to the latest
+	order := make([]int, len(p.Lots))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return p.Lots[order[i]].Expiration.Before(p.Lots[order[j]].Expiration)
+	})
+
+	var movements []LotMovement
+	for _, i := range order {
+		if units == 0 {
+			break
+		}
+		taken := p.Lots[i].Quantity
+		if taken > units {
+			taken = units
+		}
+		if taken == 0 || skip(p.Lots[i]) {
+			continue
+		}
+		p.Lots[i].Quantity -= taken
+		units -= taken
+		movements = append(movements, LotMovement{Code: p.Lots[i].Code, Delta: -taken})
+	}
+
+	p.SyncLots(today)
+	return movements, nil
+}
+
+/*
+The SyncLots method makes the product quantity the sum of its lots, and its expiration the soonest
+expiration among the lots that still have stock and have not expired by today. Expired lots cannot
+be sold, so they only make the product expired when they are all the stock it has left.
+*/
+func (p *Product) SyncLots(today Date) {
+	if !p.IsLotTracked() {
+		return
+	}
+
+	quantity := 0
+	var expiration Date
+	for _, lot := range p.Lots {
+		quantity += lot.Quantity
+		if lot.Quantity > 0 && soonerExpiration(lot.Expiration, expiration, today) {
+			expiration = lot.Expiration
+		}
+	}
+
+	p.Quantity = quantity
+	if !expiration.IsZero() {
+		p.Expiration = expiration
+	}
+}
+
+// Auxiliary function that reports whether a lot expiration comes before the current one, unexpired lots first.
+func soonerExpiration(expiration Date, current Date, today Date) bool {
+	if current.IsZero() {
+		return true
+	}
+	if expired, currentExpired := expiration.Before(today), current.Before(today); expired != currentExpired {
+		return currentExpired
+	}
+	return expiration.Before(current)
+}
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrInvalidMarkdownPolicy = errors.New("invalid markdown policy")
+
+/*
+The MarkdownStep struct represents a step of a markdown schedule: products expiring within the
+given number of days get the given percentage off their list price.
+*/
+type MarkdownStep struct {
+	WithinDays int     `json:"within_days" example:"3"`
+	Percentage Decimal `json:"percentage" example:"30" swaggertype:"number"`
+}
+
+/*
+The MarkdownPolicy struct represents the near-expiry markdown schedules: the global one and those
+of some categories, which apply to their products and to those of their descendants instead.
+*/
+type MarkdownPolicy struct {
+	Steps      []MarkdownStep     `json:"steps"`
+	Categories []CategoryMarkdown `json:"categories,omitempty"`
+}
+
+// The CategoryMarkdown struct represents the near-expiry markdown schedule of a category.
+type CategoryMarkdown struct {
+	CategoryId int            `json:"category_id" example:"2"`
+	Steps      []MarkdownStep `json:"steps"`
+}
+
+/*
+The Markdown struct represents the markdown applied to a product: the percentage taken off, the
+list price it was taken from and the expiration date it was computed for.
+*/
+type Markdown struct {
+	Percentage Decimal   `json:"percentage" example:"30" swaggertype:"number"`
+	BasePrice  Money     `json:"base_price" example:"299.99" swaggertype:"number"`
+	Expiration Date      `json:"expiration" example:"2030-08-25" swaggertype:"string" format:"date"`
+	AppliedAt  time.Time `json:"applied_at"`
+}
+
+/*
+The Validate method checks every schedule of the policy and that no category has more than one.
+Every step must have a non-negative number of days and a percentage between 0 and 100 (excluded),
+and no two steps of a schedule can share the same number of days. Steps are sorted by days, so the
+deepest markdown comes first.
+*/
+func (p *MarkdownPolicy) Validate() error {
+	if err := validateSteps(p.Steps); err != nil {
+		return err
+	}
+
+	seen := map[int]bool{}
+	for _, category := range p.Categories {
+		if seen[category.CategoryId] {
+			return ErrInvalidMarkdownPolicy
+		}
+		seen[category.CategoryId] = true
+		if err := validateSteps(category.Steps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+The PercentageFor method returns the markdown percentage for a product expiring in the given number
+of days. The schedule is the one of the first of the given categories (the category of the product
+followed by its ancestors) that has one, or the global one otherwise. The percentage is the one of
+the closest step that covers the days, or zero if no step covers them.
+*/
+func (p MarkdownPolicy) PercentageFor(days int, categories []int) Decimal {
+	steps := p.Steps
+	for _, id := range categories {
+		if schedule, ok := p.scheduleOf(id); ok {
+			steps = schedule
+			break
+		}
+	}
+
+	var percentage Decimal
+	closest := -1
+	for _, step := range steps {
+		if days <= step.WithinDays && (closest < 0 || step.WithinDays < closest) {
+			percentage = step.Percentage
+			closest = step.WithinDays
+		}
+	}
+	return percentage
+}
+
+// Auxiliary function that returns the markdown schedule of a category, if it has one.
+func (p MarkdownPolicy) scheduleOf(categoryId int) ([]MarkdownStep, bool) {
+	for _, category := range p.Categories {
+		if category.CategoryId == categoryId {
+			return category.Steps, true
+		}
+	}
+	return nil, false
+}
+
+// Auxiliary function that checks the steps of a markdown schedule and sorts them by days.
+func validateSteps(steps []MarkdownStep) error {
+	hundred := NewDecimal(100)
+	for _, step := range steps {
+		if step.WithinDays < 0 || !step.Percentage.IsPositive() || !step.Percentage.LessThan(hundred) {
+			return ErrInvalidMarkdownPolicy
+		}
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].WithinDays < steps[j].WithinDays
+	})
+	for i := 1; i < len(steps); i++ {
+		if steps[i].WithinDays == steps[i-1].WithinDays {
+			return ErrInvalidMarkdownPolicy
+		}
+	}
+	return nil
+}
+
+// The ListPrice method returns the price of the product before its markdown, if it has one.
+func (p Product) ListPrice() Money {
+	if p.Markdown != nil {
+		return p.Markdown.BasePrice
+	}
+	return p.Price
+}
+
+/*
+The ApplyMarkdown method takes the given percentage off the list price of the product, rounded to
+cents. A zero percentage restores the list price.
+*/
+func (p *Product) ApplyMarkdown(percentage Decimal, now time.Time) {
+	base := p.ListPrice()
+	if percentage.IsZero() {
+		p.Price = base
+		p.Markdown = nil
+		return
+	}
+
+	discount, _ := base.Amount.Mul(percentage).Div(NewDecimal(100))
+	p.Price = NewMoney(base.Amount.Sub(discount).Round(2), base.Currency)
+	p.Markdown = &Markdown{
+		Percentage: percentage,
+		BasePrice:  base,
+		Expiration: p.Expiration,
+		AppliedAt:  now,
+	}
+}
@@ -0,0 +1,68 @@
+package domain
+
+import "errors"
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// DefaultCurrency is the currency assumed for prices stored without an explicit currency code.
+const DefaultCurrency = "USD"
+
+// The Money struct represents an exact amount of money in a given currency (ISO 4217 code).
+type Money struct {
+	Amount   Decimal
+	Currency string
+}
+
+// The NewMoney function returns a new Money value. An empty currency defaults to DefaultCurrency.
+func NewMoney(amount Decimal, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// The Add method returns m + other. Both values must share the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}, nil
+}
+
+// The MulInt method returns m multiplied by an integer, for example a unit price times a quantity.
+func (m Money) MulInt(n int64) Money {
+	return Money{Amount: m.Amount.MulInt(n), Currency: m.Currency}
+}
+
+// The IsZero method reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// The MarshalJSON method encodes only the amount, the currency is reported by the enclosing object.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return m.Amount.MarshalJSON()
+}
+
+// The UnmarshalJSON method decodes only the amount, the currency is set by the enclosing object.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	return m.Amount.UnmarshalJSON(data)
+}
+
+/*
+The Sum function adds up a list of money values. All of them must share the same currency.
+An empty list sums up to zero in DefaultCurrency.
+*/
+func Sum(values ...Money) (Money, error) {
+	total := NewMoney(Decimal{}, "")
+	if len(values) > 0 {
+		total.Currency = values[0].Currency
+	}
+	for _, value := range values {
+		var err error
+		if total, err = total.Add(value); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
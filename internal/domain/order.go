@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OrderStatus is the state of a customer order.
+type OrderStatus string
+
+const (
+	OrderPlaced    OrderStatus = "placed"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+/*
+The Order struct represents a customer order. Prices are a snapshot taken when the order was
+placed, all of them in the order currency.
+*/
+type Order struct {
+	Id          int         `json:"id" example:"1"`
+	Status      OrderStatus `json:"status" example:"placed"`
+	Lines       []OrderLine `json:"lines"`
+	Total       Money       `json:"total" example:"599.98" swaggertype:"number"`
+	Currency    string      `json:"currency" example:"USD"`
+	Reference   string      `json:"reference,omitempty" example:"web-1234"`
+	CreatedAt   time.Time   `json:"created_at"`
+	CancelledAt *time.Time  `json:"cancelled_at,omitempty"`
+}
+
+// The OrderLine struct represents the units of a product bought in an order.
+type OrderLine struct {
+	ProductId int    `json:"product_id" example:"1"`
+	CodeValue string `json:"code_value" example:"COD123"`
+	Name      string `json:"name" example:"Pineapple"`
+	Quantity  int    `json:"quantity" example:"2"`
+	UnitPrice Money  `json:"unit_price" example:"299.99" swaggertype:"number"`
+	Total     Money  `json:"total" example:"599.98" swaggertype:"number"`
+}
+
+// OrderRequest is the body of a request that places an order.
+type OrderRequest struct {
+	Lines     []StockItem `json:"lines" binding:"required,min=1,dive"`
+	Reference string      `json:"reference,omitempty" example:"web-1234"`
+}
+
+/*
+The UnmarshalJSON method decodes an order, restoring the currency of its prices from the order
+currency, since prices are encoded as plain amounts.
+*/
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type orderAlias Order
+	var decoded orderAlias
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*o = Order(decoded)
+	o.Total = NewMoney(o.Total.Amount, o.Currency)
+	for i := range o.Lines {
+		o.Lines[i].UnitPrice = NewMoney(o.Lines[i].UnitPrice.Amount, o.Currency)
+		o.Lines[i].Total = NewMoney(o.Lines[i].Total.Amount, o.Currency)
+	}
+	return nil
+}
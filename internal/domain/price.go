@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// PriceChangeReason is why the price of a product changed.
+type PriceChangeReason string
+
+const (
+	// PriceManual is a price set through the API.
+	PriceManual PriceChangeReason = "manual"
+	// PriceScheduled is a scheduled price that reached its effective instant.
+	PriceScheduled PriceChangeReason = "scheduled"
+	// PriceMarkdown is a near-expiry markdown applied by the markdown job.
+	PriceMarkdown PriceChangeReason = "markdown"
+	// PriceMarkdownReverted is a markdown undone because the expiration date of the product changed.
+	PriceMarkdownReverted PriceChangeReason = "markdown_reverted"
+)
+
+/*
+The PriceChange struct represents a change of the price of a product, recorded in the price history.
+
+	Previous (Money): Price before the change.
+	Price (Money): Price after the change.
+	Reason (PriceChangeReason): Why the price changed. Example: "markdown".
+	Actor (string): Who changed it, as reported in the "actor" header for changes made through the
+	API. Example: "markdown-job".
+*/
+type PriceChange struct {
+	ProductId int               `json:"product_id" example:"1"`
+	Previous  Money             `json:"previous" example:"299.99" swaggertype:"number"`
+	Price     Money             `json:"price" example:"209.99" swaggertype:"number"`
+	Currency  string            `json:"currency" example:"USD"`
+	Reason    PriceChangeReason `json:"reason" example:"markdown"`
+	Actor     string            `json:"actor" example:"markdown-job"`
+	ChangedAt time.Time         `json:"changed_at"`
+}
+
+/*
+The UnmarshalJSON method decodes a price change, restoring the currency of its prices from the
+change currency, since prices are encoded as plain amounts.
+*/
+func (c *PriceChange) UnmarshalJSON(data []byte) error {
+	type priceChangeAlias PriceChange
+	var decoded priceChangeAlias
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*c = PriceChange(decoded)
+	c.Previous = NewMoney(c.Previous.Amount, c.Currency)
+	c.Price = NewMoney(c.Price.Amount, c.Currency)
+	return nil
+}
+
+/*
+The ScheduledPrice struct represents a future price of a product, which replaces its list price
+automatically from the given instant.
+*/
+type ScheduledPrice struct {
+	Price         Money     `json:"price" example:"279.99" swaggertype:"number"`
+	Currency      string    `json:"currency" example:"USD"`
+	EffectiveFrom time.Time `json:"effective_from" example:"2030-09-01T00:00:00Z"`
+	Actor         string    `json:"actor" example:"pricing-team"`
+	ScheduledAt   time.Time `json:"scheduled_at"`
+}
+
+/*
+The UnmarshalJSON method decodes a scheduled price, restoring the currency of its price from the
+scheduled price currency, since prices are encoded as plain amounts.
+*/
+func (s *ScheduledPrice) UnmarshalJSON(data []byte) error {
+	type scheduledPriceAlias ScheduledPrice
+	var decoded scheduledPriceAlias
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*s = ScheduledPrice(decoded)
+	s.Price = NewMoney(s.Price.Amount, s.Currency)
+	return nil
+}
+
+// The PriceRequest struct represents a request to change the price of a product, now or from a future instant.
+type PriceRequest struct {
+	Price         Decimal    `json:"price" example:"279.99" swaggertype:"number" binding:"required"`
+	Currency      string     `json:"currency,omitempty" example:"USD"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty" example:"2030-09-01T00:00:00Z"`
+}
+
+/*
+The PriceTimeline struct represents the prices of a product over time: the past changes (oldest
+first), the current price and the scheduled ones (soonest first).
+*/
+type PriceTimeline struct {
+	ProductId int              `json:"product_id" example:"1"`
+	History   []PriceChange    `json:"history"`
+	Price     Money            `json:"price" example:"299.99" swaggertype:"number"`
+	Currency  string           `json:"currency" example:"USD"`
+	Scheduled []ScheduledPrice `json:"scheduled"`
+}
+
+// The SchedulePrice method adds a scheduled price to the product, keeping them sorted by effective instant.
+func (p *Product) SchedulePrice(scheduled ScheduledPrice) {
+	i := sort.Search(len(p.ScheduledPrices), func(i int) bool {
+		return p.ScheduledPrices[i].EffectiveFrom.After(scheduled.EffectiveFrom)
+	})
+	p.ScheduledPrices = append(p.ScheduledPrices, ScheduledPrice{})
+	copy(p.ScheduledPrices[i+1:], p.ScheduledPrices[i:])
+	p.ScheduledPrices[i] = scheduled
+}
+
+/*
+The TakeDuePrice method removes the scheduled prices whose effective instant has been reached and
+returns the latest of them, if any. Earlier ones were superseded before they could be applied.
+*/
+func (p *Product) TakeDuePrice(now time.Time) (ScheduledPrice, bool) {
+	due := 0
+	for due < len(p.ScheduledPrices) && !now.Before(p.ScheduledPrices[due].EffectiveFrom) {
+		due++
+	}
+	if due == 0 {
+		return ScheduledPrice{}, false
+	}
+
+	latest := p.ScheduledPrices[due-1]
+	p.ScheduledPrices = append([]ScheduledPrice(nil), p.ScheduledPrices[due:]...)
+	return latest, true
+}
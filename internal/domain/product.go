@@ -1,20 +1,156 @@
 package domain
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type Product struct {
-	Id          int     `json:"id" example:"1"`
-	Name        string  `json:"name" example:"Pineapple" binding:"required"`
-	Quantity    int     `json:"quantity" example:"100" binding:"required"`
-	CodeValue   string  `json:"code_value" example:"COD123" binding:"required"`
-	IsPublished bool    `json:"is_published" example:"true"`
-	Expiration  string  `json:"expiration" example:"25/08/2030" binding:"required"`
-	Price       float64 `json:"price" example:"299" binding:"required" format:"float64"`
+	Id                int                `json:"id" example:"1"`
+	Name              string             `json:"name" example:"Pineapple" binding:"required"`
+	Quantity          int                `json:"quantity" example:"100" binding:"required"`
+	AvailableQuantity *int               `json:"available_quantity,omitempty" example:"98"`
+	ReorderPoint      int                `json:"reorder_point" example:"10"`
+	CodeValue         string             `json:"code_value" example:"COD123" binding:"required"`
+	IsPublished       bool               `json:"is_published" example:"true"`
+	Status            ProductStatus      `json:"status" example:"published"`
+	Expiration        Date               `json:"expiration" example:"2030-08-25" swaggertype:"string" format:"date"`
+	Price             Money              `json:"price" example:"299.99" swaggertype:"number"`
+	EffectivePrice    *Money             `json:"effective_price,omitempty" example:"239.99" swaggertype:"number"`
+	Promotions        []AppliedPromotion `json:"promotions,omitempty"`
+	Markdown          *Markdown          `json:"markdown,omitempty"`
+	ScheduledPrices   []ScheduledPrice   `json:"scheduled_prices,omitempty"`
+	PriceTiers        []PriceTier        `json:"price_tiers,omitempty"`
+	TaxCategory       string             `json:"tax_category,omitempty" example:"reduced_food"`
+	Tax               *TaxAmounts        `json:"tax,omitempty"`
+	PublishAt         *time.Time         `json:"publish_at,omitempty" example:"2030-08-01T09:00:00Z"`
+	UnpublishAt       *time.Time         `json:"unpublish_at,omitempty" example:"2030-08-15T23:59:59Z"`
+	Expired           bool               `json:"expired" example:"false"`
+	AllowBackorder    bool               `json:"allow_backorder" example:"false"`
+	SupplierId        int                `json:"supplier_id,omitempty" example:"1"`
+	CategoryId        int                `json:"category_id,omitempty" example:"2"`
+	Tags              []string           `json:"tags,omitempty" example:"organic,imported"`
+	Options           []ProductOption    `json:"options,omitempty"`
+	ParentId          int                `json:"parent_id,omitempty" example:"1"`
+	OptionValues      map[string]string  `json:"option_values,omitempty"`
+	Variants          *VariantSummary    `json:"variants,omitempty"`
+	Components        []BundleComponent  `json:"components,omitempty"`
+	Attributes        Attributes         `json:"attributes,omitempty" swaggertype:"object"`
+	Lots              []Lot              `json:"lots,omitempty"`
+	Stock             []LocationStock    `json:"stock,omitempty"`
 }
 
 type ProductRequest struct {
-	Name        string  `json:"name,omitempty" example:"Pineapple"`
-	Quantity    int     `json:"quantity,omitempty" example:"100"`
-	CodeValue   string  `json:"code_value,omitempty" example:"COD123"`
-	IsPublished bool    `json:"is_published,omitempty" example:"true"`
-	Expiration  string  `json:"expiration,omitempty" example:"25/08/2030"`
-	Price       float64 `json:"price,omitempty" example:"299" format:"float64"`
+	Name           string            `json:"name,omitempty" example:"Pineapple"`
+	Quantity       int               `json:"quantity,omitempty" example:"100"`
+	ReorderPoint   *int              `json:"reorder_point,omitempty" example:"10"`
+	CodeValue      string            `json:"code_value,omitempty" example:"COD123"`
+	IsPublished    *bool             `json:"is_published,omitempty" example:"true"`
+	Expiration     Date              `json:"expiration,omitempty" example:"2030-08-25" swaggertype:"string" format:"date"`
+	Price          *Decimal          `json:"price,omitempty" example:"299.99" swaggertype:"number"`
+	Currency       string            `json:"currency,omitempty" example:"USD"`
+	PublishAt      *time.Time        `json:"publish_at,omitempty" example:"2030-08-01T09:00:00Z"`
+	UnpublishAt    *time.Time        `json:"unpublish_at,omitempty" example:"2030-08-15T23:59:59Z"`
+	AllowBackorder *bool             `json:"allow_backorder,omitempty" example:"false"`
+	PriceTiers     []PriceTier       `json:"price_tiers,omitempty"`
+	TaxCategory    string            `json:"tax_category,omitempty" example:"reduced_food"`
+	Tags           []string          `json:"tags,omitempty" example:"organic,imported"`
+	Options        []ProductOption   `json:"options,omitempty"`
+	Components     []BundleComponent `json:"components,omitempty"`
+	Attributes     Attributes        `json:"attributes,omitempty" swaggertype:"object"`
+}
+
+// productAlias has the same fields as Product but none of its methods, to avoid recursive JSON encoding.
+type productAlias Product
+
+// productJSON is the JSON representation of a Product. The price currency is a sibling field.
+type productJSON struct {
+	productAlias
+	Currency string `json:"currency"`
+}
+
+// The MarshalJSON method encodes the product, reporting the price currency in the "currency" field.
+func (p Product) MarshalJSON() ([]byte, error) {
+	return json.Marshal(productJSON{
+		productAlias: productAlias(p),
+		Currency:     p.Price.Currency,
+	})
+}
+
+/*
+The UnmarshalJSON method decodes a product. Products stored before prices carried a currency
+get DefaultCurrency, and products stored before the lifecycle status existed get it from
+is_published. The is_published flag always mirrors the status.
+*/
+func (p *Product) UnmarshalJSON(data []byte) error {
+	var decoded productJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*p = Product(decoded.productAlias)
+	p.Price = NewMoney(p.Price.Amount, decoded.Currency)
+	if p.Markdown != nil {
+		p.Markdown.BasePrice = NewMoney(p.Markdown.BasePrice.Amount, decoded.Currency)
+	}
+	for i := range p.PriceTiers {
+		p.PriceTiers[i].Price = NewMoney(p.PriceTiers[i].Price.Amount, decoded.Currency)
+	}
+	if p.Variants != nil {
+		p.Variants.MinPrice = NewMoney(p.Variants.MinPrice.Amount, decoded.Currency)
+		p.Variants.MaxPrice = NewMoney(p.Variants.MaxPrice.Amount, decoded.Currency)
+	}
+	if p.Status == "" {
+		p.SetPublished(p.IsPublished)
+	}
+	p.IsPublished = p.Status == StatusPublished
+	return nil
+}
+
+/*
+The SetPublished method applies the legacy is_published flag to the lifecycle status. Publishing
+moves the product to published, and unpublishing a published product moves it back to draft.
+*/
+func (p *Product) SetPublished(published bool) {
+	switch {
+	case published:
+		p.Status = StatusPublished
+	case p.Status == StatusPublished || p.Status == "":
+		p.Status = StatusDraft
+	}
+	p.IsPublished = p.Status == StatusPublished
+}
+
+/*
+The IsVisibleAt method reports whether the product is inside its publication window at the given
+instant. Products without a window are always inside it.
+*/
+func (p Product) IsVisibleAt(now time.Time) bool {
+	if p.PublishAt != nil && now.Before(*p.PublishAt) {
+		return false
+	}
+	if p.UnpublishAt != nil && !now.Before(*p.UnpublishAt) {
+		return false
+	}
+	return true
+}
+
+// The IsExpiredOn method reports whether the product expiration date has passed on the given day.
+func (p Product) IsExpiredOn(today Date) bool {
+	return p.Expiration.Before(today)
+}
+
+// The IsLowOnStock method reports whether the product quantity is below its reorder point, if it has one.
+func (p Product) IsLowOnStock() bool {
+	return p.ReorderPoint > 0 && p.Quantity < p.ReorderPoint
+}
+
+// The FindByCode function returns the product of a list with the given code value, or nil if there is none.
+func FindByCode(products []Product, codeValue string) *Product {
+	for i := range products {
+		if products[i].CodeValue == codeValue {
+			return &products[i]
+		}
+	}
+	return nil
 }
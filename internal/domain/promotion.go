@@ -0,0 +1,100 @@
+package domain
+
+import "time"
+
+// PromotionType is the kind of discount a promotion gives.
+type PromotionType string
+
+const (
+	// PromotionPercentage takes a percentage (Value) off the price.
+	PromotionPercentage PromotionType = "percentage"
+	// PromotionFixed takes a fixed amount (Value, in Currency) off the price of every unit.
+	PromotionFixed PromotionType = "fixed"
+	// PromotionQuantity gives "buy BuyQuantity pay PayQuantity" for every group of BuyQuantity units.
+	PromotionQuantity PromotionType = "quantity"
+	// PromotionExpiration takes a percentage (Value) off products expiring within WithinDays days.
+	PromotionExpiration PromotionType = "expiration"
+)
+
+/*
+The Promotion struct represents a discount rule. It applies to the given products (by ID or code
+value) and to the products of the given categories or their subcategories, or to every product if
+none is given, while inside its validity window. Promotions apply from the highest priority down; a
+promotion that is not stackable is never combined with others.
+*/
+type Promotion struct {
+	Id          int           `json:"id" example:"1"`
+	Name        string        `json:"name" example:"20% off dairy" binding:"required"`
+	Type        PromotionType `json:"type" example:"percentage" binding:"required"`
+	Value       Decimal       `json:"value" example:"20" swaggertype:"number"`
+	Currency    string        `json:"currency,omitempty" example:"USD"`
+	BuyQuantity int           `json:"buy_quantity,omitempty" example:"3"`
+	PayQuantity int           `json:"pay_quantity,omitempty" example:"2"`
+	WithinDays  int           `json:"within_days,omitempty" example:"7"`
+	ProductIds  []int         `json:"product_ids,omitempty" example:"1"`
+	CodeValues  []string      `json:"code_values,omitempty" example:"COD123"`
+	CategoryIds []int         `json:"category_ids,omitempty" example:"2"`
+	StartsAt    *time.Time    `json:"starts_at,omitempty" example:"2030-08-01T00:00:00Z"`
+	EndsAt      *time.Time    `json:"ends_at,omitempty" example:"2030-08-31T23:59:59Z"`
+	Priority    int           `json:"priority" example:"10"`
+	Stackable   bool          `json:"stackable" example:"false"`
+}
+
+// The AppliedPromotion struct represents a promotion applied to a price, and the discount it gave.
+type AppliedPromotion struct {
+	Id       int           `json:"id" example:"1"`
+	Name     string        `json:"name" example:"20% off dairy"`
+	Type     PromotionType `json:"type" example:"percentage"`
+	Discount Money         `json:"discount" example:"59.99" swaggertype:"number"`
+}
+
+// The PriceQuote struct represents the price of some units of a product after promotions.
+type PriceQuote struct {
+	UnitPrice  Money              `json:"unit_price" example:"239.99" swaggertype:"number"`
+	Total      Money              `json:"total" example:"479.98" swaggertype:"number"`
+	Promotions []AppliedPromotion `json:"promotions,omitempty"`
+}
+
+// The IsActiveAt method reports whether the promotion is inside its validity window at the given instant.
+func (p Promotion) IsActiveAt(now time.Time) bool {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// The FixedDiscount method returns the amount a fixed promotion takes off the price of every unit.
+func (p Promotion) FixedDiscount() Money {
+	return NewMoney(p.Value, p.Currency)
+}
+
+/*
+The Targets method reports whether the promotion applies to the given product, whose category is
+given followed by its ancestors.
+*/
+func (p Promotion) Targets(product Product, categories []int) bool {
+	if len(p.ProductIds) == 0 && len(p.CodeValues) == 0 && len(p.CategoryIds) == 0 {
+		return true
+	}
+	for _, id := range p.ProductIds {
+		if id == product.Id {
+			return true
+		}
+	}
+	for _, code := range p.CodeValues {
+		if code == product.CodeValue {
+			return true
+		}
+	}
+	for _, id := range p.CategoryIds {
+		for _, category := range categories {
+			if id == category {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package domain
+
+import "time"
+
+// PurchaseOrderStatus is the state of a purchase order.
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderProposed  PurchaseOrderStatus = "proposed"
+	PurchaseOrderApproved  PurchaseOrderStatus = "approved"
+	PurchaseOrderReceived  PurchaseOrderStatus = "received"
+	PurchaseOrderCancelled PurchaseOrderStatus = "cancelled"
+)
+
+/*
+The PurchaseOrder struct represents an order of products to a supplier.
+
+	ExpectedAt (string): Expected arrival, the approval time plus the supplier lead time.
+*/
+type PurchaseOrder struct {
+	Id         int                 `json:"id" example:"1"`
+	SupplierId int                 `json:"supplier_id" example:"1"`
+	Status     PurchaseOrderStatus `json:"status" example:"proposed"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+	CreatedAt  time.Time           `json:"created_at"`
+	ApprovedAt *time.Time          `json:"approved_at,omitempty"`
+	ExpectedAt *time.Time          `json:"expected_at,omitempty"`
+	ClosedAt   *time.Time          `json:"closed_at,omitempty"`
+}
+
+// The PurchaseOrderLine struct represents the units of a product ordered in a purchase order.
+type PurchaseOrderLine struct {
+	ProductId int    `json:"product_id" example:"1"`
+	CodeValue string `json:"code_value" example:"COD123"`
+	Quantity  int    `json:"quantity" example:"20"`
+}
+
+// The IsOpen method reports whether the purchase order is still waiting to be received.
+func (o PurchaseOrder) IsOpen() bool {
+	return o.Status == PurchaseOrderProposed || o.Status == PurchaseOrderApproved
+}
+
+/*
+The StockReceipt struct represents units of a product received into stock, optionally as a new or
+existing lot and at a location.
+*/
+type StockReceipt struct {
+	ProductId  int    `json:"product_id" example:"1"`
+	Quantity   int    `json:"quantity" example:"20"`
+	Lot        string `json:"lot,omitempty" example:"L2030-08"`
+	Expiration Date   `json:"expiration,omitempty" example:"2030-08-25" swaggertype:"string" format:"date"`
+	Location   string `json:"location,omitempty" example:"main"`
+}
+
+/*
+ReceiveRequest is the body of a request that receives a purchase order. Lot-tracked products need
+the lot (and its expiration, if it is a new lot) the units are received in.
+*/
+type ReceiveRequest struct {
+	Location string              `json:"location,omitempty" example:"main"`
+	Lots     []ReceiveLotRequest `json:"lots,omitempty"`
+}
+
+// ReceiveLotRequest tells the lot in which the units of a product are received.
+type ReceiveLotRequest struct {
+	ProductId  int    `json:"product_id" example:"1"`
+	Code       string `json:"code" example:"L2030-08"`
+	Expiration Date   `json:"expiration,omitempty" example:"2030-08-25" swaggertype:"string" format:"date"`
+}
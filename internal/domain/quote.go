@@ -0,0 +1,79 @@
+package domain
+
+import "errors"
+
+var ErrInvalidPriceTiers = errors.New("price tiers must have increasing minimum quantities (from 2 units) and decreasing prices below the list price, in its currency")
+
+// The PriceTier struct represents a lower unit price for buying at least a given quantity of a product.
+type PriceTier struct {
+	MinQuantity int   `json:"min_quantity" example:"10"`
+	Price       Money `json:"price" example:"279.99" swaggertype:"number"`
+}
+
+// The QuoteRequest struct represents a request for the price of some units of some products.
+type QuoteRequest struct {
+	Lines []QuoteItem `json:"lines" binding:"required,min=1,dive"`
+}
+
+// The QuoteItem struct represents the units of a product to quote.
+type QuoteItem struct {
+	Id       int `json:"id" example:"1" binding:"required"`
+	Quantity int `json:"quantity" example:"10" binding:"required"`
+}
+
+// The Quote struct represents the price of some units of some products, after tiers and promotions.
+type Quote struct {
+	Lines    []QuoteLine `json:"lines"`
+	Total    Money       `json:"total" example:"2799.90" swaggertype:"number"`
+	Currency string      `json:"currency" example:"USD"`
+	Tax      *TaxAmounts `json:"tax,omitempty"`
+}
+
+// The QuoteLine struct represents the price of some units of a product, after tiers and promotions.
+type QuoteLine struct {
+	ProductId  int                `json:"product_id" example:"1"`
+	CodeValue  string             `json:"code_value" example:"COD123"`
+	Name       string             `json:"name" example:"Pineapple"`
+	Quantity   int                `json:"quantity" example:"10"`
+	UnitPrice  Money              `json:"unit_price" example:"279.99" swaggertype:"number"`
+	Total      Money              `json:"total" example:"2799.90" swaggertype:"number"`
+	Promotions []AppliedPromotion `json:"promotions,omitempty"`
+	Tax        *TaxAmounts        `json:"tax,omitempty"`
+}
+
+/*
+The ValidatePriceTiers method checks that the minimum quantities of the price tiers start at 2 and
+increase, and that their prices are positive, in the currency of the product, below its list price
+and decrease from one tier to the next.
+*/
+func (p Product) ValidatePriceTiers() error {
+	previous := PriceTier{MinQuantity: 1, Price: p.ListPrice()}
+	for _, tier := range p.PriceTiers {
+		if tier.MinQuantity <= previous.MinQuantity || tier.Price.Currency != previous.Price.Currency {
+			return ErrInvalidPriceTiers
+		}
+		if !tier.Price.Amount.IsPositive() || !tier.Price.Amount.LessThan(previous.Price.Amount) {
+			return ErrInvalidPriceTiers
+		}
+		previous = tier
+	}
+	return nil
+}
+
+/*
+The UnitPriceFor method returns the unit price of the product when buying the given quantity: the
+price of the highest tier reached, unless the current price (which may be marked down) is lower.
+Tiers in another currency than the current price are never used.
+*/
+func (p Product) UnitPriceFor(quantity int) Money {
+	price := p.Price
+	for _, tier := range p.PriceTiers {
+		if tier.Price.Currency != price.Currency {
+			continue
+		}
+		if quantity >= tier.MinQuantity && tier.Price.Amount.LessThan(price.Amount) {
+			price = tier.Price
+		}
+	}
+	return price
+}
@@ -0,0 +1,100 @@
+package domain
+
+import "time"
+
+// RecallStatus is the state of a product recall.
+type RecallStatus string
+
+const (
+	RecallActive RecallStatus = "active"
+	RecallClosed RecallStatus = "closed"
+)
+
+/*
+The Recall struct represents a recall of a product, identified by its code value. It can be narrowed
+to some lots and to a range of expiration dates; without them it covers all the product stock.
+*/
+type Recall struct {
+	Id             int          `json:"id" example:"1"`
+	CodeValue      string       `json:"code_value" example:"COD123"`
+	Lots           []string     `json:"lots,omitempty" example:"L2030-08"`
+	ExpirationFrom Date         `json:"expiration_from,omitempty" example:"2030-08-01" swaggertype:"string" format:"date"`
+	ExpirationTo   Date         `json:"expiration_to,omitempty" example:"2030-08-31" swaggertype:"string" format:"date"`
+	Reason         string       `json:"reason,omitempty" example:"Supplier notice 42"`
+	Status         RecallStatus `json:"status" example:"active"`
+	CreatedAt      time.Time    `json:"created_at"`
+	ClosedAt       *time.Time   `json:"closed_at,omitempty"`
+}
+
+// RecallRequest is the body of a recall request.
+type RecallRequest struct {
+	CodeValue      string   `json:"code_value" example:"COD123" binding:"required"`
+	Lots           []string `json:"lots,omitempty" example:"L2030-08"`
+	ExpirationFrom Date     `json:"expiration_from,omitempty" example:"2030-08-01" swaggertype:"string" format:"date"`
+	ExpirationTo   Date     `json:"expiration_to,omitempty" example:"2030-08-31" swaggertype:"string" format:"date"`
+	Reason         string   `json:"reason,omitempty" example:"Supplier notice 42"`
+}
+
+// The RecallItem struct represents stock affected by a recall: a lot, or a product that is not lot-tracked.
+type RecallItem struct {
+	RecallId   int    `json:"recall_id" example:"1"`
+	ProductId  int    `json:"product_id" example:"1"`
+	CodeValue  string `json:"code_value" example:"COD123"`
+	Name       string `json:"name" example:"Pineapple"`
+	Lot        string `json:"lot,omitempty" example:"L2030-08"`
+	Expiration Date   `json:"expiration" example:"2030-08-25" swaggertype:"string" format:"date"`
+	Quantity   int    `json:"quantity" example:"50"`
+}
+
+/*
+The Covers method reports whether the recall covers the given lot of a product. An empty lot code
+stands for the stock of a product that is not lot-tracked, which only narrows by expiration date.
+*/
+func (r Recall) Covers(product Product, lot string, expiration Date) bool {
+	if product.CodeValue != r.CodeValue {
+		return false
+	}
+	if len(r.Lots) > 0 {
+		found := false
+		for _, code := range r.Lots {
+			found = found || code == lot
+		}
+		if !found {
+			return false
+		}
+	}
+	if !r.ExpirationFrom.IsZero() && expiration.Before(r.ExpirationFrom) {
+		return false
+	}
+	if !r.ExpirationTo.IsZero() && expiration.After(r.ExpirationTo) {
+		return false
+	}
+	return true
+}
+
+// The Items method returns the stock of the product covered by the recall, lot by lot.
+func (r Recall) Items(product Product) []RecallItem {
+	var items []RecallItem
+	add := func(lot string, expiration Date, quantity int) {
+		if r.Covers(product, lot, expiration) {
+			items = append(items, RecallItem{
+				RecallId:   r.Id,
+				ProductId:  product.Id,
+				CodeValue:  product.CodeValue,
+				Name:       product.Name,
+				Lot:        lot,
+				Expiration: expiration,
+				Quantity:   quantity,
+			})
+		}
+	}
+
+	if !product.IsLotTracked() {
+		add("", product.Expiration, product.Quantity)
+		return items
+	}
+	for _, lot := range product.Lots {
+		add(lot.Code, lot.Expiration, lot.Quantity)
+	}
+	return items
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// ReservationStatus is the state of a stock reservation.
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// The Reservation struct represents units of a product held for a customer until it expires.
+type Reservation struct {
+	Id        int               `json:"id" example:"1"`
+	ProductId int               `json:"product_id" example:"1"`
+	Quantity  int               `json:"quantity" example:"2"`
+	Location  string            `json:"location,omitempty" example:"north"`
+	Status    ReservationStatus `json:"status" example:"active"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// The IsActiveAt method reports whether the reservation still holds its units at the given instant.
+func (r Reservation) IsActiveAt(now time.Time) bool {
+	return r.Status == ReservationActive && now.Before(r.ExpiresAt)
+}
+
+// ReservationRequest is the body of a reservation request.
+type ReservationRequest struct {
+	Quantity int    `json:"quantity" example:"2" binding:"required"`
+	Location string `json:"location,omitempty" example:"north"`
+	TTL      string `json:"ttl,omitempty" example:"15m"`
+}
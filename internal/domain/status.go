@@ -0,0 +1,27 @@
+package domain
+
+// ProductStatus is the lifecycle state of a product.
+type ProductStatus string
+
+const (
+	StatusDraft        ProductStatus = "draft"
+	StatusInReview     ProductStatus = "in_review"
+	StatusPublished    ProductStatus = "published"
+	StatusDiscontinued ProductStatus = "discontinued"
+	StatusArchived     ProductStatus = "archived"
+)
+
+// The IsValid method reports whether the status is one of the known lifecycle states.
+func (s ProductStatus) IsValid() bool {
+	switch s {
+	case StatusDraft, StatusInReview, StatusPublished, StatusDiscontinued, StatusArchived:
+		return true
+	default:
+		return false
+	}
+}
+
+// TransitionRequest is the body of a lifecycle transition request.
+type TransitionRequest struct {
+	Status ProductStatus `json:"status" example:"published" binding:"required"`
+}
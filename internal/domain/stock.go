@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// MovementReason is the cause of a stock movement.
+type MovementReason string
+
+const (
+	ReasonSale      MovementReason = "sale"
+	ReasonRestock   MovementReason = "restock"
+	ReasonShrinkage MovementReason = "shrinkage"
+	ReasonReceipt   MovementReason = "receipt"
+	ReasonTransfer  MovementReason = "transfer"
+	ReasonCount     MovementReason = "count"
+	ReasonReturn    MovementReason = "return"
+)
+
+/*
+The StockMovement struct represents an entry of the stock movement ledger.
+
+	Delta (int): Signed change applied to the product quantity. Example: -3.
+	QuantityAfter (int): Product quantity right after the movement.
+	Reason (string): Cause of the movement. Example: "sale".
+	Lots (array): For lot-tracked products, how the delta was split among the lots.
+	Locations (array): For location-tracked products, how the delta was split among the locations.
+*/
+type StockMovement struct {
+	Id            int                `json:"id" example:"1"`
+	ProductId     int                `json:"product_id" example:"1"`
+	Delta         int                `json:"delta" example:"-3"`
+	QuantityAfter int                `json:"quantity_after" example:"97"`
+	Reason        MovementReason     `json:"reason" example:"sale"`
+	Reference     string             `json:"reference,omitempty" example:"ticket-1234"`
+	Lots          []LotMovement      `json:"lots,omitempty"`
+	Locations     []LocationMovement `json:"locations,omitempty"`
+	Time          time.Time          `json:"time"`
+}
+
+// StockAdjustmentRequest is the body of a stock adjustment request.
+type StockAdjustmentRequest struct {
+	Delta     int            `json:"delta" example:"-3" binding:"required"`
+	Reason    MovementReason `json:"reason" example:"sale" binding:"required"`
+	Reference string         `json:"reference,omitempty" example:"ticket-1234"`
+	Lot       string         `json:"lot,omitempty" example:"L2030-08"`
+	Location  string         `json:"location,omitempty" example:"main"`
+}
+
+// The StockItem struct represents units of a product taken out of stock together with others.
+type StockItem struct {
+	ProductId int `json:"product_id" example:"1" binding:"required"`
+	Quantity  int `json:"quantity" example:"2" binding:"required"`
+	// Location is where the units are taken from, or "" to take them from the locations in order
+	Location string `json:"-"`
+	// Reserved is how many of the units were held for this very sale, such as by the reservation it confirms
+	Reserved int `json:"-"`
+}
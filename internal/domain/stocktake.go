@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// StocktakeStatus is the state of a stocktake session.
+type StocktakeStatus string
+
+const (
+	StocktakeOpen      StocktakeStatus = "open"
+	StocktakeCommitted StocktakeStatus = "committed"
+	StocktakeCancelled StocktakeStatus = "cancelled"
+)
+
+/*
+The StocktakeSession struct represents a physical inventory count. Counted quantities are submitted
+while the session is open, and committing it adjusts the stock to match them.
+
+	Location (string): Location being counted. Empty to count the whole stock of every product.
+	Counts (array): Last counted quantity submitted for each code value.
+	Movements (array): IDs of the ledger movements applied when the session was committed.
+*/
+type StocktakeSession struct {
+	Id        int             `json:"id" example:"1"`
+	Location  string          `json:"location,omitempty" example:"main"`
+	Status    StocktakeStatus `json:"status" example:"open"`
+	Counts    []StockCount    `json:"counts"`
+	Movements []int           `json:"movements,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	ClosedAt  *time.Time      `json:"closed_at,omitempty"`
+}
+
+// The StockCount struct represents the counted quantity of a product, identified by its code value.
+type StockCount struct {
+	CodeValue string `json:"code_value" example:"COD123" binding:"required"`
+	Counted   int    `json:"counted" example:"97"`
+}
+
+// The StocktakeLine struct represents the variance between the counted and the system quantity of a product.
+type StocktakeLine struct {
+	ProductId      int    `json:"product_id" example:"1"`
+	CodeValue      string `json:"code_value" example:"COD123"`
+	Name           string `json:"name" example:"Pineapple"`
+	SystemQuantity int    `json:"system_quantity" example:"100"`
+	Counted        int    `json:"counted" example:"97"`
+	Variance       int    `json:"variance" example:"-3"`
+}
+
+// The StocktakeReview struct represents a stocktake session along with its variances.
+type StocktakeReview struct {
+	StocktakeSession
+	Lines []StocktakeLine `json:"lines"`
+}
+
+// StocktakeRequest is the body of a request that opens a stocktake session.
+type StocktakeRequest struct {
+	Location string `json:"location,omitempty" example:"main"`
+}
@@ -0,0 +1,14 @@
+package domain
+
+// The Supplier struct represents a company that supplies products.
+type Supplier struct {
+	Id           int    `json:"id" example:"1"`
+	Name         string `json:"name" example:"Fresh Farms" binding:"required"`
+	Contact      string `json:"contact,omitempty" example:"Jane Doe <jane@freshfarms.example>"`
+	LeadTimeDays int    `json:"lead_time_days" example:"7"`
+}
+
+// SupplierLinkRequest is the body of a request that links a product to a supplier.
+type SupplierLinkRequest struct {
+	ProductId int `json:"product_id" example:"1" binding:"required"`
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidTag = errors.New("invalid tag, expected a non-empty name without commas")
+
+const (
+	// TagsAll makes a tag filter match the products that have every given tag.
+	TagsAll = "all"
+	// TagsAny makes a tag filter match the products that have at least one of the given tags.
+	TagsAny = "any"
+)
+
+/*
+The Tag struct represents a free-form label of products, such as "organic" or "imported". Any tag
+can be set on a product; creating one beforehand only gives it a description. The product count is
+computed from the products when tags are read.
+*/
+type Tag struct {
+	Name         string `json:"name" example:"organic" binding:"required"`
+	Description  string `json:"description,omitempty" example:"Certified organic products"`
+	ProductCount int    `json:"product_count" example:"12"`
+}
+
+// TagRenameRequest is the body of a request that renames a tag on every product that has it.
+type TagRenameRequest struct {
+	Name string `json:"name" example:"bio" binding:"required"`
+}
+
+// TagMergeRequest is the body of a request that merges some tags into another one.
+type TagMergeRequest struct {
+	Sources []string `json:"sources" example:"bio,eco" binding:"required,min=1"`
+}
+
+/*
+The NormalizeTag function returns the canonical form of a tag name: trimmed and in lower case, so
+"Organic " and "organic" are the same tag. Empty names and names with commas, which separate tags
+in filters, are invalid.
+*/
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || strings.Contains(name, ",") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, name)
+	}
+	return name, nil
+}
+
+// The NormalizeTags function normalizes a list of tag names, sorting them and removing duplicates.
+func NormalizeTags(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+	unique := tags[:0]
+	for i, tag := range tags {
+		if i == 0 || tag != tags[i-1] {
+			unique = append(unique, tag)
+		}
+	}
+	return unique, nil
+}
+
+// The HasTag method reports whether the product has the given (normalized) tag.
+func (p Product) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+The MatchesTags method reports whether the product matches a tag filter: it must have every given
+tag with the TagsAll mode, or at least one of them with the TagsAny mode.
+*/
+func (p Product) MatchesTags(tags []string, mode string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if mode == TagsAny && p.HasTag(tag) {
+			return true
+		}
+		if mode != TagsAny && !p.HasTag(tag) {
+			return false
+		}
+	}
+	return mode != TagsAny
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownRegion      = errors.New("unknown tax region")
+	ErrUnknownTaxCategory = errors.New("unknown tax category")
+	ErrInvalidTaxTable    = errors.New("invalid tax table")
+)
+
+// StandardTaxCategory is the tax category of products without one, and the fallback of unknown categories.
+const StandardTaxCategory = "standard"
+
+/*
+The TaxTable struct represents the tax rates of every region, by region code (such as "CL" or
+"AR"). Catalog prices include taxes or not depending on PricesIncludeTax.
+*/
+type TaxTable struct {
+	PricesIncludeTax bool                 `json:"prices_include_tax" example:"false"`
+	Regions          map[string]TaxRegion `json:"regions"`
+}
+
+// The TaxRegion struct represents the tax rates (percentages) of a region, by tax category.
+type TaxRegion struct {
+	Name  string             `json:"name" example:"Argentina"`
+	Rates map[string]Decimal `json:"rates" swaggertype:"object"`
+}
+
+/*
+The TaxAmounts struct represents the net, tax and gross amounts of a price in a region. The
+category and rate are only reported for the price of a single product.
+*/
+type TaxAmounts struct {
+	Region   string   `json:"region" example:"AR"`
+	Category string   `json:"category,omitempty" example:"reduced_food"`
+	Rate     *Decimal `json:"rate,omitempty" example:"10.5" swaggertype:"number"`
+	Net      Money    `json:"net" example:"100" swaggertype:"number"`
+	Tax      Money    `json:"tax" example:"10.5" swaggertype:"number"`
+	Gross    Money    `json:"gross" example:"110.5" swaggertype:"number"`
+}
+
+/*
+The Validate method checks that every region of the table has a standard rate, which the
+categories without a rate of their own in the region fall back to, and that no rate is negative.
+*/
+func (t TaxTable) Validate() error {
+	for code, region := range t.Regions {
+		if _, ok := region.Rates[StandardTaxCategory]; !ok {
+			return fmt.Errorf("%w: region %s has no %s rate", ErrInvalidTaxTable, code, StandardTaxCategory)
+		}
+		for category, rate := range region.Rates {
+			if rate.IsNegative() {
+				return fmt.Errorf("%w: the %s rate of region %s is negative", ErrInvalidTaxTable, category, code)
+			}
+		}
+	}
+	return nil
+}
+
+// The HasCategory method tells if some region of the table has a rate for the tax category. Empty categories are standard.
+func (t TaxTable) HasCategory(category string) bool {
+	if category == "" || category == StandardTaxCategory {
+		return true
+	}
+	for _, region := range t.Regions {
+		if _, ok := region.Rates[category]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+The Rate method returns the tax rate (percentage) of a tax category in a region. Empty categories,
+and those the region has no rate for, get the standard rate of the region. Categories no region
+knows are rejected, and region codes are not case sensitive.
+*/
+func (t TaxTable) Rate(region string, category string) (Decimal, error) {
+	taxRegion, ok := t.Regions[strings.ToUpper(region)]
+	if !ok {
+		return Decimal{}, ErrUnknownRegion
+	}
+	if !t.HasCategory(category) {
+		return Decimal{}, fmt.Errorf("%w: %s", ErrUnknownTaxCategory, category)
+	}
+	if rate, ok := taxRegion.Rates[category]; ok {
+		return rate, nil
+	}
+	rate, ok := taxRegion.Rates[StandardTaxCategory]
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: region %s has no %s rate", ErrInvalidTaxTable, strings.ToUpper(region), StandardTaxCategory)
+	}
+	return rate, nil
+}
+
+// The Apply method breaks a price of the given tax category down into its net, tax and gross amounts in a region.
+func (t TaxTable) Apply(amount Money, region string, category string) (TaxAmounts, error) {
+	if category == "" {
+		category = StandardTaxCategory
+	}
+	rate, err := t.Rate(region, category)
+	if err != nil {
+		return TaxAmounts{}, err
+	}
+
+	hundred := NewDecimal(100)
+	net, gross := amount, amount
+	if t.PricesIncludeTax {
+		netAmount, _ := amount.Amount.Mul(hundred).Div(hundred.Add(rate))
+		net.Amount = netAmount.Round(2)
+	} else {
+		tax, _ := amount.Amount.Mul(rate).Div(hundred)
+		gross.Amount = amount.Amount.Add(tax.Round(2))
+	}
+
+	return TaxAmounts{
+		Region:   strings.ToUpper(region),
+		Category: category,
+		Rate:     &rate,
+		Net:      net,
+		Tax:      NewMoney(gross.Amount.Sub(net.Amount), amount.Currency),
+		Gross:    gross,
+	}, nil
+}
+
+/*
+The SumTaxes function adds up the tax amounts of some prices in the same region, leaving out their
+categories and rates. All of them must share the same currency.
+*/
+func SumTaxes(region string, amounts ...TaxAmounts) (TaxAmounts, error) {
+	nets, taxes, grosses := make([]Money, len(amounts)), make([]Money, len(amounts)), make([]Money, len(amounts))
+	for i, amount := range amounts {
+		nets[i], taxes[i], grosses[i] = amount.Net, amount.Tax, amount.Gross
+	}
+
+	total := TaxAmounts{Region: strings.ToUpper(region)}
+	var err error
+	if total.Net, err = Sum(nets...); err != nil {
+		return TaxAmounts{}, err
+	}
+	if total.Tax, err = Sum(taxes...); err != nil {
+		return TaxAmounts{}, err
+	}
+	if total.Gross, err = Sum(grosses...); err != nil {
+		return TaxAmounts{}, err
+	}
+	return total, nil
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidOptions = errors.New("invalid product options")
+	ErrInvalidVariant = errors.New("invalid product variant")
+)
+
+/*
+The ProductOption struct represents an axis along which the variants of a parent product differ,
+such as the size of a wine bottle, with the values that its variants can take.
+*/
+type ProductOption struct {
+	Name   string   `json:"name" example:"size"`
+	Values []string `json:"values" example:"375ml,750ml"`
+}
+
+/*
+The VariantSummary struct represents the variants of a parent product in a listing collapsed to
+parents: how many of them are listed, their total available quantity and their price range.
+*/
+type VariantSummary struct {
+	Count             int   `json:"count" example:"2"`
+	AvailableQuantity int   `json:"available_quantity" example:"120"`
+	MinPrice          Money `json:"min_price" example:"12.5" swaggertype:"number"`
+	MaxPrice          Money `json:"max_price" example:"21.9" swaggertype:"number"`
+}
+
+/*
+VariantRequest is the body of a request that creates a variant of a parent product. The variant
+takes the name, expiration, price and classification of its parent, unless the request gives its
+own expiration or price.
+*/
+type VariantRequest struct {
+	CodeValue    string            `json:"code_value" example:"WINE-750" binding:"required"`
+	OptionValues map[string]string `json:"option_values" binding:"required"`
+	Quantity     int               `json:"quantity" example:"60"`
+	ReorderPoint int               `json:"reorder_point,omitempty" example:"10"`
+	Expiration   Date              `json:"expiration,omitempty" example:"2030-08-25" swaggertype:"string" format:"date"`
+	Price        *Decimal          `json:"price,omitempty" example:"21.9" swaggertype:"number"`
+	Currency     string            `json:"currency,omitempty" example:"USD"`
+}
+
+// The IsVariant method reports whether the product is a variant of a parent product.
+func (p Product) IsVariant() bool {
+	return p.ParentId != 0
+}
+
+/*
+The ValidateOptions function checks that every option has a distinct non-empty name and at least
+one value, and that the values of each option are distinct and non-empty.
+*/
+func ValidateOptions(options []ProductOption) error {
+	names := map[string]bool{}
+	for _, option := range options {
+		if strings.TrimSpace(option.Name) == "" || names[option.Name] || len(option.Values) == 0 {
+			return fmt.Errorf("%w: every option needs a distinct name and some values", ErrInvalidOptions)
+		}
+		names[option.Name] = true
+
+		values := map[string]bool{}
+		for _, value := range option.Values {
+			if strings.TrimSpace(value) == "" || values[value] {
+				return fmt.Errorf("%w: the values of %q must be distinct and non-empty", ErrInvalidOptions, option.Name)
+			}
+			values[value] = true
+		}
+	}
+	return nil
+}
+
+/*
+The ValidateOptionValues method checks that the given option values of a variant pick exactly one
+of the values of every option of the product, its parent.
+*/
+func (p Product) ValidateOptionValues(values map[string]string) error {
+	if len(p.Options) == 0 {
+		return fmt.Errorf("%w: product %d has no options", ErrInvalidVariant, p.Id)
+	}
+	if len(values) != len(p.Options) {
+		return fmt.Errorf("%w: expected a value for every option of product %d", ErrInvalidVariant, p.Id)
+	}
+	for _, option := range p.Options {
+		value, ok := values[option.Name]
+		if !ok || !containsValue(option.Values, value) {
+			return fmt.Errorf("%w: %q is not a value of option %q", ErrInvalidVariant, value, option.Name)
+		}
+	}
+	return nil
+}
+
+/*
+The VariantName method returns the name of a variant with the given option values: the name of the
+product, its parent, followed by the values in the order of the options.
+*/
+func (p Product) VariantName(values map[string]string) string {
+	parts := []string{p.Name}
+	for _, option := range p.Options {
+		parts = append(parts, values[option.Name])
+	}
+	return strings.Join(parts, " - ")
+}
+
+// The SameOptionValues function reports whether two variants have the same option values.
+func SameOptionValues(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if b[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Auxiliary function that checks if a list of option values contains the given one.
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package expiration
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/audit"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+)
+
+var ErrInvalidPolicy = errors.New("invalid expiration policy")
+
+// errNothingToDo tells that a product has already been handled, so it is not written.
+var errNothingToDo = errors.New("nothing to do")
+
+// auditActor is the actor reported in the audit log for the monitor actions.
+const auditActor = "expiration-monitor"
+
+// Policy is what the monitor does with expired products.
+type Policy string
+
+const (
+	// PolicyFlag marks expired products but leaves them published.
+	PolicyFlag Policy = "flag"
+	// PolicyUnpublish marks expired products and unpublishes them.
+	PolicyUnpublish Policy = "unpublish"
+)
+
+// The ParsePolicy function returns the policy with the given name. An empty name defaults to PolicyUnpublish.
+func ParsePolicy(name string) (Policy, error) {
+	switch Policy(name) {
+	case "":
+		return PolicyUnpublish, nil
+	case PolicyFlag, PolicyUnpublish:
+		return Policy(name), nil
+	default:
+		return "", ErrInvalidPolicy
+	}
+}
+
+// Monitor is the interface definition for the expiration monitor
+type Monitor interface {
+	Run(now time.Time) ([]domain.Product, error)
+}
+
+// MonitorImpl is the implementation of the monitor interface
+type MonitorImpl struct {
+	repository product.Repository
+	location   *time.Location
+	policy     Policy
+	auditLog   audit.Log
+}
+
+/*
+The NewMonitor function returns a new expiration monitor. The location is the catalog time zone,
+used to decide which day is "today", and every action is reported in the audit log.
+*/
+func NewMonitor(repository product.Repository, location *time.Location, policy Policy, auditLog audit.Log) Monitor {
+	return &MonitorImpl{
+		repository: repository,
+		location:   location,
+		policy:     policy,
+		auditLog:   auditLog,
+	}
+}
+
+/*
+The Run method applies the policy to every expired product that has not been handled yet and
+returns the changed products. Products already flagged (and unpublished, if the policy says so)
+are left alone, so running the monitor repeatedly is safe.
+*/
+func (m *MonitorImpl) Run(now time.Time) ([]domain.Product, error) {
+	today := domain.DateOf(now.In(m.location))
+
+	var changed []domain.Product
+	for _, candidate := range m.repository.GetAll() {
+		if m.handle(&candidate, today) == "" {
+			continue
+		}
+
+		// The policy is applied again on the stored product, which may have changed since it was read,
+		// and the change is audited along with it, so a failed audit leaves the product unhandled
+		var action string
+		var handled domain.Product
+		updatedProduct, err := m.repository.Modify(candidate.Id, func(p *domain.Product) error {
+			if action = m.handle(p, today); action == "" {
+				return errNothingToDo
+			}
+			handled = *p
+			return nil
+		}, func() error {
+			details := fmt.Sprintf("product %s expired on %s", handled.CodeValue, handled.Expiration)
+			return m.auditLog.Record(auditActor, action, handled.Id, details)
+		})
+		if errors.Is(err, errNothingToDo) || errors.Is(err, product.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+		changed = append(changed, updatedProduct)
+	}
+	return changed, nil
+}
+
+/*
+Auxiliary function that applies the policy to a product if it has expired and has not been handled
+yet, and returns the action taken for the audit log, or "" if there was nothing to do.
+*/
+func (m *MonitorImpl) handle(p *domain.Product, today domain.Date) string {
+	if !p.IsExpiredOn(today) {
+		return ""
+	}
+
+	action := ""
+	switch {
+	case m.policy == PolicyUnpublish && p.IsPublished:
+		p.SetPublished(false)
+		action = "unpublish_expired"
+	case !p.Expired:
+		action = "flag_expired"
+	default:
+		return ""
+	}
+	p.Expired = true
+	return action
+}
@@ -0,0 +1,104 @@
+package expiration
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/audit"
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var errAuditFailed = errors.New("audit log unavailable")
+
+// failingLog is an audit log whose writes always fail.
+type failingLog struct{}
+
+func (failingLog) Record(actor string, action string, productId int, details string) error {
+	return errAuditFailed
+}
+
+func (failingLog) GetAll() ([]domain.AuditEntry, error) {
+	return nil, nil
+}
+
+func createMonitorForTest(t *testing.T, policy Policy) (Monitor, product.Repository, audit.Log) {
+	dir := t.TempDir()
+
+	// One expired published product and one product that is still valid
+	products := []domain.Product{
+		{Id: 1, CodeValue: "OLD1", IsPublished: true, Status: domain.StatusPublished, Expiration: domain.NewDate(2021, time.December, 15)},
+		{Id: 2, CodeValue: "NEW1", IsPublished: true, Status: domain.StatusPublished, Expiration: domain.NewDate(2030, time.October, 25)},
+	}
+	repository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	auditLog := audit.NewLog(store.NewJsonLinesStore[domain.AuditEntry](filepath.Join(dir, "audit.log")))
+
+	return NewMonitor(repository, time.UTC, policy, auditLog), repository, auditLog
+}
+
+func TestMonitor_Run(t *testing.T) {
+	t.Run("Unpublish policy", func(t *testing.T) {
+		monitor, repository, auditLog := createMonitorForTest(t, PolicyUnpublish)
+
+		// Run the monitor twice, the second run has nothing to do
+		changed, err := monitor.Run(time.Now())
+		assert.Nil(t, err)
+		assert.Len(t, changed, 1)
+		changed, err = monitor.Run(time.Now())
+		assert.Nil(t, err)
+		assert.Len(t, changed, 0)
+
+		// Assertions
+		expired, _ := repository.GetById(1)
+		assert.True(t, expired.Expired)
+		assert.False(t, expired.IsPublished)
+		valid, _ := repository.GetById(2)
+		assert.False(t, valid.Expired)
+		assert.True(t, valid.IsPublished)
+
+		entries, err := auditLog.GetAll()
+		assert.Nil(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "unpublish_expired", entries[0].Action)
+	})
+	t.Run("Flag policy", func(t *testing.T) {
+		monitor, repository, auditLog := createMonitorForTest(t, PolicyFlag)
+
+		_, err := monitor.Run(time.Now())
+		assert.Nil(t, err)
+
+		// Assertions
+		expired, _ := repository.GetById(1)
+		assert.True(t, expired.Expired)
+		assert.True(t, expired.IsPublished)
+
+		entries, _ := auditLog.GetAll()
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "flag_expired", entries[0].Action)
+	})
+	t.Run("Failed audit", func(t *testing.T) {
+		_, repository, auditLog := createMonitorForTest(t, PolicyUnpublish)
+		failing := NewMonitor(repository, time.UTC, PolicyUnpublish, failingLog{})
+
+		// The product is left as it was, so the next run still handles and audits it
+		_, err := failing.Run(time.Now())
+		assert.ErrorIs(t, err, errAuditFailed)
+		expired, _ := repository.GetById(1)
+		assert.False(t, expired.Expired)
+		assert.True(t, expired.IsPublished)
+
+		changed, err := NewMonitor(repository, time.UTC, PolicyUnpublish, auditLog).Run(time.Now())
+		assert.Nil(t, err)
+		assert.Len(t, changed, 1)
+		entries, _ := auditLog.GetAll()
+		assert.Len(t, entries, 1)
+	})
+	t.Run("Invalid policy", func(t *testing.T) {
+		_, err := ParsePolicy("delete")
+		assert.ErrorIs(t, err, ErrInvalidPolicy)
+	})
+}
@@ -0,0 +1,78 @@
+package location
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var (
+	ErrNotFound  = errors.New("location not found")
+	ErrDuplicate = errors.New("location code already exists")
+)
+
+// Repository is the interface definition for the location storage
+type Repository interface {
+	GetAll() []domain.Location
+	GetByCode(code string) (domain.Location, error)
+	Create(location domain.Location) (domain.Location, error)
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu        sync.RWMutex
+	locations []domain.Location
+	store     store.DocumentStore[[]domain.Location]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+locations and saves every change in the provided store.
+*/
+func NewRepository(locations []domain.Location, locationStore store.DocumentStore[[]domain.Location]) Repository {
+	return &RepositoryImpl{
+		locations: locations,
+		store:     locationStore,
+	}
+}
+
+// The GetAll method returns all the locations
+func (r *RepositoryImpl) GetAll() []domain.Location {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Location{}, r.locations...)
+}
+
+// The GetByCode method returns a location by its code
+func (r *RepositoryImpl) GetByCode(code string) (domain.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, location := range r.locations {
+		if location.Code == code {
+			return location, nil
+		}
+	}
+	return domain.Location{}, ErrNotFound
+}
+
+// The Create method stores a new location. Location codes are unique.
+func (r *RepositoryImpl) Create(location domain.Location) (domain.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.locations {
+		if existing.Code == location.Code {
+			return domain.Location{}, ErrDuplicate
+		}
+	}
+
+	locations := append(append([]domain.Location{}, r.locations...), location)
+	if err := r.store.Save(locations); err != nil {
+		return domain.Location{}, err
+	}
+	r.locations = locations
+	return location, nil
+}
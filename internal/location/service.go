@@ -0,0 +1,49 @@
+package location
+
+import "github.com/soppibb/practica-go-web/internal/domain"
+
+// defaultLocation is reported while DefaultLocation has not been stored with another name.
+var defaultLocation = domain.Location{Code: domain.DefaultLocation, Name: "Main warehouse"}
+
+type Service interface {
+	GetAll() []domain.Location
+	GetByCode(code string) (domain.Location, error)
+	Create(location domain.Location) (domain.Location, error)
+}
+
+type ServiceImpl struct {
+	repository Repository
+}
+
+// The NewService function returns a new instance of the service.
+func NewService(repository Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+	}
+}
+
+// The GetAll method returns all the locations, DefaultLocation first if it has not been stored.
+func (s *ServiceImpl) GetAll() []domain.Location {
+	locations := s.repository.GetAll()
+	if _, err := s.repository.GetByCode(domain.DefaultLocation); err == ErrNotFound {
+		locations = append([]domain.Location{defaultLocation}, locations...)
+	}
+	return locations
+}
+
+/*
+The GetByCode method returns a location by its code. DefaultLocation always exists, since it holds
+the stock of the products that are not spread over locations.
+*/
+func (s *ServiceImpl) GetByCode(code string) (domain.Location, error) {
+	location, err := s.repository.GetByCode(code)
+	if err == ErrNotFound && code == domain.DefaultLocation {
+		return defaultLocation, nil
+	}
+	return location, err
+}
+
+// The Create method stores a new location.
+func (s *ServiceImpl) Create(location domain.Location) (domain.Location, error) {
+	return s.repository.Create(location)
+}
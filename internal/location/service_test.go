@@ -0,0 +1,47 @@
+package location
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createServiceForTest(t *testing.T, locations []domain.Location) Service {
+	repository := NewRepository(locations, store.NewJsonDocumentStore[[]domain.Location](filepath.Join(t.TempDir(), "locations.json")))
+	return NewService(repository)
+}
+
+func TestService_GetByCode(t *testing.T) {
+	// The default location exists before it is stored
+	service := createServiceForTest(t, nil)
+	main, err := service.GetByCode(domain.DefaultLocation)
+	assert.Nil(t, err)
+	assert.Equal(t, "Main warehouse", main.Name)
+	assert.Equal(t, []domain.Location{main}, service.GetAll())
+	_, err = service.GetByCode("back")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Once stored, it keeps its own name
+	service = createServiceForTest(t, []domain.Location{{Code: domain.DefaultLocation, Name: "Store"}})
+	main, err = service.GetByCode(domain.DefaultLocation)
+	assert.Nil(t, err)
+	assert.Equal(t, "Store", main.Name)
+	assert.Len(t, service.GetAll(), 1)
+}
+
+func TestService_Create(t *testing.T) {
+	service := createServiceForTest(t, nil)
+
+	created, err := service.Create(domain.Location{Code: "back", Name: "Back room"})
+	assert.Nil(t, err)
+	assert.Equal(t, "back", created.Code)
+	_, err = service.Create(domain.Location{Code: "back", Name: "Another back room"})
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	// The default location is listed first
+	locations := service.GetAll()
+	assert.Equal(t, []string{domain.DefaultLocation, "back"}, []string{locations[0].Code, locations[1].Code})
+}
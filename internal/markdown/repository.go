@@ -0,0 +1,51 @@
+package markdown
+
+import (
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+// Repository is the interface definition for the markdown policy storage
+type Repository interface {
+	Get() domain.MarkdownPolicy
+	Update(policy domain.MarkdownPolicy) error
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu     sync.RWMutex
+	policy domain.MarkdownPolicy
+	store  store.DocumentStore[domain.MarkdownPolicy]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+policy and saves every update in the provided store.
+*/
+func NewRepository(policy domain.MarkdownPolicy, policyStore store.DocumentStore[domain.MarkdownPolicy]) Repository {
+	return &RepositoryImpl{
+		policy: policy,
+		store:  policyStore,
+	}
+}
+
+// The Get method returns the current markdown policy
+func (r *RepositoryImpl) Get() domain.MarkdownPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.policy
+}
+
+// The Update method replaces the markdown policy, saving it in the store first.
+func (r *RepositoryImpl) Update(policy domain.MarkdownPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.store.Save(policy); err != nil {
+		return err
+	}
+	r.policy = policy
+	return nil
+}
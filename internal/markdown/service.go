@@ -0,0 +1,159 @@
+package markdown
+
+import (
+	"errors"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+)
+
+var ErrUnknownCategory = errors.New("markdown policy refers to an unknown category")
+
+// jobActor is the actor reported in the price history for the markdown job changes.
+const jobActor = "markdown-job"
+
+// CategoryTree returns a category followed by its ancestors, to find the markdown schedule of a product.
+type CategoryTree interface {
+	Ancestors(id int) []int
+}
+
+type Service interface {
+	GetPolicy() domain.MarkdownPolicy
+	UpdatePolicy(policy domain.MarkdownPolicy) (domain.MarkdownPolicy, error)
+	Run(now time.Time) ([]domain.Product, error)
+}
+
+type ServiceImpl struct {
+	repository Repository
+	products   product.Repository
+	history    product.PriceRecorder
+	categories CategoryTree
+	location   *time.Location
+}
+
+/*
+The NewService function returns a new instance of the service. The location is the catalog time
+zone, used to count the days until every product expires, and every price change made by the
+markdown job is recorded in the price history. The category tree (optional) is used to find the
+schedule of every product.
+*/
+func NewService(repository Repository, products product.Repository, history product.PriceRecorder, categories CategoryTree, location *time.Location) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+		history:    history,
+		categories: categories,
+		location:   location,
+	}
+}
+
+// The GetPolicy method returns the current markdown policy
+func (s *ServiceImpl) GetPolicy() domain.MarkdownPolicy {
+	return s.repository.Get()
+}
+
+/*
+The UpdatePolicy method validates and replaces the markdown policy, whose categories must exist. It
+applies on the next run of the job.
+*/
+func (s *ServiceImpl) UpdatePolicy(policy domain.MarkdownPolicy) (domain.MarkdownPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		return domain.MarkdownPolicy{}, err
+	}
+	for _, category := range policy.Categories {
+		if len(s.ancestors(category.CategoryId)) == 0 {
+			return domain.MarkdownPolicy{}, ErrUnknownCategory
+		}
+	}
+	if err := s.repository.Update(policy); err != nil {
+		return domain.MarkdownPolicy{}, err
+	}
+	return policy, nil
+}
+
+/*
+The Run method marks down every product that has not expired yet to the percentage the policy
+gives for the days left until its expiration, and returns the changed products. Markdowns computed
+for an expiration date that has since changed are recomputed, and products the policy no longer
+covers get their list price back. Expired products are left as they are, and running the job
+repeatedly on the same day is safe.
+*/
+func (s *ServiceImpl) Run(now time.Time) ([]domain.Product, error) {
+	today := domain.DateOf(now.In(s.location))
+	policy := s.repository.Get()
+
+	var changed []domain.Product
+	for _, p := range s.products.GetAll() {
+		if p.Expiration.IsZero() || p.IsExpiredOn(today) {
+			continue
+		}
+
+		percentage := policy.PercentageFor(today.DaysUntil(p.Expiration), s.ancestors(p.CategoryId))
+		if !needsMarkdown(p, percentage) {
+			continue
+		}
+
+		// The product may have changed since it was read, so the markdown is computed again
+		var marked bool
+		var change *domain.PriceChange
+		updatedProduct, err := s.products.Modify(p.Id, func(product *domain.Product) error {
+			marked, change = false, nil
+			if product.Expiration.IsZero() || product.IsExpiredOn(today) {
+				return nil
+			}
+			percentage := policy.PercentageFor(today.DaysUntil(product.Expiration), s.ancestors(product.CategoryId))
+			if !needsMarkdown(*product, percentage) {
+				return nil
+			}
+
+			marked = true
+			previous := product.Price
+			product.ApplyMarkdown(percentage, now)
+			if product.Price == previous {
+				return nil
+			}
+			reason := domain.PriceMarkdown
+			if percentage.IsZero() {
+				reason = domain.PriceMarkdownReverted
+			}
+			change = &domain.PriceChange{
+				ProductId: product.Id,
+				Previous:  previous,
+				Price:     product.Price,
+				Reason:    reason,
+				Actor:     jobActor,
+				ChangedAt: now,
+			}
+			return nil
+		}, func() error {
+			if change == nil {
+				return nil
+			}
+			return s.history.Record(*change)
+		})
+		if err != nil {
+			return changed, err
+		}
+		if marked {
+			changed = append(changed, updatedProduct)
+		}
+	}
+	return changed, nil
+}
+
+// Auxiliary function that returns a category followed by its ancestors, if there is a category tree.
+func (s *ServiceImpl) ancestors(categoryId int) []int {
+	if s.categories == nil || categoryId == 0 {
+		return nil
+	}
+	return s.categories.Ancestors(categoryId)
+}
+
+// Auxiliary function that checks if a product is not marked down by the given percentage for its current expiration date.
+func needsMarkdown(p domain.Product, percentage domain.Decimal) bool {
+	if p.Markdown == nil {
+		return !percentage.IsZero()
+	}
+	return p.Markdown.Percentage != percentage || p.Markdown.Expiration != p.Expiration
+}
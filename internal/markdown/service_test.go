@@ -0,0 +1,202 @@
+package markdown
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/price"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// now is the instant the markdown job runs at in the tests.
+var now = time.Date(2030, time.January, 1, 6, 0, 0, 0, time.UTC)
+
+func createServiceForTest(t *testing.T) (Service, product.Repository, price.History) {
+	dir := t.TempDir()
+
+	// Products expiring in 2, 10 and 30 days, and an expired one
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Price: domain.NewMoney(domain.NewDecimal(100), ""), Expiration: domain.NewDate(2030, time.January, 3)},
+		{Id: 2, CodeValue: "CHEESE1", Price: domain.NewMoney(domain.MustParseDecimal("49.99"), ""), Expiration: domain.NewDate(2030, time.January, 11)},
+		{Id: 3, CodeValue: "RICE1", Price: domain.NewMoney(domain.NewDecimal(20), ""), Expiration: domain.NewDate(2030, time.January, 31)},
+		{Id: 4, CodeValue: "OLD1", Price: domain.NewMoney(domain.NewDecimal(10), ""), Expiration: domain.NewDate(2029, time.December, 15)},
+	}
+	repository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	history := price.NewHistory(nil, store.NewJsonLinesStore[domain.PriceChange](filepath.Join(dir, "price_history.jsonl")))
+
+	// 10% off at 14 days and 30% off at 3 days
+	service := NewService(NewRepository(domain.MarkdownPolicy{}, store.NewJsonDocumentStore[domain.MarkdownPolicy](filepath.Join(dir, "markdown.json"))), repository, history, nil, time.UTC)
+	_, err := service.UpdatePolicy(domain.MarkdownPolicy{Steps: []domain.MarkdownStep{
+		{WithinDays: 14, Percentage: domain.NewDecimal(10)},
+		{WithinDays: 3, Percentage: domain.NewDecimal(30)},
+	}})
+	assert.Nil(t, err)
+
+	return service, repository, history
+}
+
+func TestService_UpdatePolicy(t *testing.T) {
+	service, _, _ := createServiceForTest(t)
+
+	// Steps are sorted by days
+	assert.Equal(t, 3, service.GetPolicy().Steps[0].WithinDays)
+
+	_, err := service.UpdatePolicy(domain.MarkdownPolicy{Steps: []domain.MarkdownStep{{WithinDays: 3, Percentage: domain.NewDecimal(100)}}})
+	assert.ErrorIs(t, err, domain.ErrInvalidMarkdownPolicy)
+	_, err = service.UpdatePolicy(domain.MarkdownPolicy{Steps: []domain.MarkdownStep{
+		{WithinDays: 3, Percentage: domain.NewDecimal(10)},
+		{WithinDays: 3, Percentage: domain.NewDecimal(20)},
+	}})
+	assert.ErrorIs(t, err, domain.ErrInvalidMarkdownPolicy)
+}
+
+func TestService_Run(t *testing.T) {
+	service, repository, history := createServiceForTest(t)
+
+	// Run the job twice, the second run has nothing to do
+	changed, err := service.Run(now)
+	assert.Nil(t, err)
+	assert.Len(t, changed, 2)
+	changed, err = service.Run(now)
+	assert.Nil(t, err)
+	assert.Len(t, changed, 0)
+
+	// Assertions
+	milk, _ := repository.GetById(1)
+	assert.Equal(t, "70", milk.Price.Amount.String())
+	assert.Equal(t, "100", milk.Markdown.BasePrice.Amount.String())
+	cheese, _ := repository.GetById(2)
+	assert.Equal(t, "44.99", cheese.Price.Amount.String())
+	rice, _ := repository.GetById(3)
+	assert.Nil(t, rice.Markdown)
+	old, _ := repository.GetById(4)
+	assert.Equal(t, "10", old.Price.Amount.String())
+
+	changes := history.GetByProduct(1)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, domain.PriceMarkdown, changes[0].Reason)
+	assert.Equal(t, "100", changes[0].Previous.Amount.String())
+	assert.Equal(t, "70", changes[0].Price.Amount.String())
+
+	// A product gets deeper markdowns as its expiration gets closer
+	changed, err = service.Run(now.AddDate(0, 0, 8))
+	assert.Nil(t, err)
+	assert.Len(t, changed, 1)
+	cheese, _ = repository.GetById(2)
+	assert.Equal(t, "34.99", cheese.Price.Amount.String())
+}
+
+func TestService_ExpirationEdited(t *testing.T) {
+	t.Run("Through the product service", func(t *testing.T) {
+		service, repository, history := createServiceForTest(t)
+		products := product.NewService(repository, time.UTC, nil, nil, nil, history, domain.TaxTable{}, nil)
+		_, err := service.Run(now)
+		assert.Nil(t, err)
+
+		// Editing the expiration date restores the list price right away
+		updated, err := products.Update(1, domain.ProductRequest{Expiration: domain.NewDate(2030, time.February, 1)}, "")
+		assert.Nil(t, err)
+		assert.Equal(t, "100", updated.Price.Amount.String())
+		assert.Nil(t, updated.Markdown)
+
+		changes := history.GetByProduct(1)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, domain.PriceMarkdownReverted, changes[1].Reason)
+
+		// The next run finds nothing to mark down for the new date
+		changed, err := service.Run(now)
+		assert.Nil(t, err)
+		assert.Len(t, changed, 0)
+	})
+	t.Run("Through any other path", func(t *testing.T) {
+		service, repository, history := createServiceForTest(t)
+		_, err := service.Run(now)
+		assert.Nil(t, err)
+
+		// The job recomputes markdowns whose expiration date changed
+		_, err = repository.Modify(1, func(p *domain.Product) error {
+			p.Expiration = domain.NewDate(2030, time.January, 10)
+			return nil
+		})
+		assert.Nil(t, err)
+		changed, err := service.Run(now)
+		assert.Nil(t, err)
+		assert.Len(t, changed, 1)
+		assert.Equal(t, "90", changed[0].Price.Amount.String())
+		assert.Equal(t, "100", changed[0].Markdown.BasePrice.Amount.String())
+		assert.Len(t, history.GetByProduct(1), 2)
+	})
+}
+
+// treeForTest is a category tree where every category is the child of the one with the previous id.
+type treeForTest struct{}
+
+func (treeForTest) Ancestors(id int) []int {
+	var ancestors []int
+	for ; id > 0 && id <= 3; id-- {
+		ancestors = append(ancestors, id)
+	}
+	return ancestors
+}
+
+func TestService_Run_CategorySchedule(t *testing.T) {
+	dir := t.TempDir()
+
+	// Rice belongs to category 3, whose grandparent marks down 50% at 30 days
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Price: domain.NewMoney(domain.NewDecimal(100), ""), Expiration: domain.NewDate(2030, time.January, 3)},
+		{Id: 3, CodeValue: "RICE1", Price: domain.NewMoney(domain.NewDecimal(20), ""), Expiration: domain.NewDate(2030, time.January, 31), CategoryId: 3},
+	}
+	repository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	history := price.NewHistory(nil, store.NewJsonLinesStore[domain.PriceChange](filepath.Join(dir, "price_history.jsonl")))
+	service := NewService(NewRepository(domain.MarkdownPolicy{}, store.NewJsonDocumentStore[domain.MarkdownPolicy](filepath.Join(dir, "markdown.json"))), repository, history, treeForTest{}, time.UTC)
+
+	// Unknown and repeated categories are rejected
+	steps := []domain.MarkdownStep{{WithinDays: 30, Percentage: domain.NewDecimal(50)}}
+	_, err := service.UpdatePolicy(domain.MarkdownPolicy{Categories: []domain.CategoryMarkdown{{CategoryId: 7, Steps: steps}}})
+	assert.ErrorIs(t, err, ErrUnknownCategory)
+	_, err = service.UpdatePolicy(domain.MarkdownPolicy{Categories: []domain.CategoryMarkdown{{CategoryId: 1, Steps: steps}, {CategoryId: 1, Steps: steps}}})
+	assert.ErrorIs(t, err, domain.ErrInvalidMarkdownPolicy)
+
+	_, err = service.UpdatePolicy(domain.MarkdownPolicy{
+		Steps:      []domain.MarkdownStep{{WithinDays: 3, Percentage: domain.NewDecimal(30)}},
+		Categories: []domain.CategoryMarkdown{{CategoryId: 1, Steps: steps}},
+	})
+	assert.Nil(t, err)
+
+	changed, err := service.Run(now)
+	assert.Nil(t, err)
+	assert.Len(t, changed, 2)
+	milk, _ := repository.GetById(1)
+	assert.Equal(t, "70", milk.Price.Amount.String())
+	rice, _ := repository.GetById(3)
+	assert.Equal(t, "10", rice.Price.Amount.String())
+}
+
+// failingHistory is a price history that cannot record anything.
+type failingHistory struct{}
+
+func (failingHistory) Record(domain.PriceChange) error {
+	return errHistory
+}
+
+var errHistory = errors.New("price history unavailable")
+
+func TestService_Run_HistoryFails(t *testing.T) {
+	_, repository, _ := createServiceForTest(t)
+	dir := t.TempDir()
+	service := NewService(NewRepository(domain.MarkdownPolicy{Steps: []domain.MarkdownStep{{WithinDays: 3, Percentage: domain.NewDecimal(30)}}},
+		store.NewJsonDocumentStore[domain.MarkdownPolicy](filepath.Join(dir, "markdown.json"))), repository, failingHistory{}, nil, time.UTC)
+
+	// A markdown whose price change cannot be recorded is not kept
+	_, err := service.Run(now)
+	assert.ErrorIs(t, err, errHistory)
+	milk, _ := repository.GetById(1)
+	assert.Equal(t, "100", milk.Price.Amount.String())
+	assert.Nil(t, milk.Markdown)
+}
@@ -0,0 +1,109 @@
+package order
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("order not found")
+
+// Repository is the interface definition for the order storage
+type Repository interface {
+	GetById(id int) (domain.Order, error)
+	GetAll() []domain.Order
+	NextId() int
+	Create(order domain.Order) (domain.Order, error)
+	Update(order domain.Order) (domain.Order, error)
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu     sync.RWMutex
+	orders []domain.Order
+	store  store.DocumentStore[[]domain.Order]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+orders and saves every change in the provided store.
+*/
+func NewRepository(orders []domain.Order, orderStore store.DocumentStore[[]domain.Order]) Repository {
+	return &RepositoryImpl{
+		orders: orders,
+		store:  orderStore,
+	}
+}
+
+// The GetById method returns an order by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, order := range r.orders {
+		if order.Id == id {
+			return order, nil
+		}
+	}
+	return domain.Order{}, ErrNotFound
+}
+
+// The GetAll method returns all the orders
+func (r *RepositoryImpl) GetAll() []domain.Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Order{}, r.orders...)
+}
+
+// The NextId method returns the ID the next created order will get.
+func (r *RepositoryImpl) NextId() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.orders) + 1
+}
+
+// The Create method stores a new order with the next available ID and returns it.
+func (r *RepositoryImpl) Create(order domain.Order) (domain.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order.Id = len(r.orders) + 1
+	if err := r.save(append(r.copyList(), order)); err != nil {
+		return domain.Order{}, err
+	}
+	return order, nil
+}
+
+// The Update method replaces a stored order with the same ID.
+func (r *RepositoryImpl) Update(order domain.Order) (domain.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.orders {
+		if r.orders[i].Id == order.Id {
+			orders := r.copyList()
+			orders[i] = order
+			if err := r.save(orders); err != nil {
+				return domain.Order{}, err
+			}
+			return order, nil
+		}
+	}
+	return domain.Order{}, ErrNotFound
+}
+
+// Auxiliary function that returns a copy of the order list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.Order {
+	return append([]domain.Order{}, r.orders...)
+}
+
+// Auxiliary function that saves the order list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(orders []domain.Order) error {
+	if err := r.store.Save(orders); err != nil {
+		return err
+	}
+	r.orders = orders
+	return nil
+}
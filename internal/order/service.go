@@ -0,0 +1,185 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+)
+
+var (
+	ErrInvalidQuantity = errors.New("order quantities must be greater than zero")
+	ErrUnavailable     = errors.New("product is not available for sale")
+	ErrNotPlaced       = errors.New("order is not placed")
+)
+
+type Service interface {
+	GetAll() []domain.Order
+	GetById(id int) (domain.Order, error)
+	Create(request domain.OrderRequest) (domain.Order, error)
+	Cancel(id int) (domain.Order, error)
+}
+
+type ServiceImpl struct {
+	mu         sync.Mutex
+	repository Repository
+	products   product.Service
+	stock      stock.Service
+	location   *time.Location
+}
+
+/*
+The NewService function returns a new instance of the service. Products are used to price the
+orders, orders take their units out of stock through the stock service, and the location is the
+catalog time zone, used to decide whether a product has expired.
+*/
+func NewService(repository Repository, products product.Service, stockService stock.Service, location *time.Location) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+		stock:      stockService,
+		location:   location,
+	}
+}
+
+// The GetAll method returns all the orders
+func (s *ServiceImpl) GetAll() []domain.Order {
+	return s.repository.GetAll()
+}
+
+// The GetById method returns an order by its ID
+func (s *ServiceImpl) GetById(id int) (domain.Order, error) {
+	return s.repository.GetById(id)
+}
+
+/*
+The Create method places an order. Every product must be published, inside its publication window
+and not expired, and have enough available units. The prices (after tiers and promotions) are
+snapshotted and the units taken out of stock in a single atomic operation, which stores the order
+once the products are saved.
+*/
+func (s *ServiceImpl) Create(request domain.OrderRequest) (domain.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := mergeItems(request.Lines)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	// Orders are created one at a time, so the next ID is the one the order gets
+	id := s.repository.NextId()
+	var newOrder, placed domain.Order
+	_, err = s.stock.Sell(items, func(products []domain.Product) (string, error) {
+		newOrder, err = s.newOrder(products, items, request.Reference)
+		return reference(id), err
+	}, func() (err error) {
+		placed, err = s.repository.Create(newOrder)
+		return err
+	})
+	if err != nil && placed.Id != 0 {
+		// The sale was undone after the order was stored, so the order is closed
+		now := time.Now().UTC()
+		placed.Status = domain.OrderCancelled
+		placed.CancelledAt = &now
+		_, cancelErr := s.repository.Update(placed)
+		err = errors.Join(err, cancelErr)
+	}
+	if err != nil {
+		return domain.Order{}, err
+	}
+	return placed, nil
+}
+
+/*
+The Cancel method cancels a placed order, putting its units back in stock. The units of an order are
+put back only once, so if storing the cancelled order fails, cancelling it again only stores it.
+*/
+func (s *ServiceImpl) Cancel(id int) (domain.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	placed, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if placed.Status != domain.OrderPlaced {
+		return domain.Order{}, ErrNotPlaced
+	}
+
+	if _, err := s.stock.Revert(reference(placed.Id), domain.ReasonReturn); err != nil {
+		return domain.Order{}, err
+	}
+
+	now := time.Now().UTC()
+	placed.Status = domain.OrderCancelled
+	placed.CancelledAt = &now
+	return s.repository.Update(placed)
+}
+
+// Auxiliary function that builds a new order for the given products, checking they can be sold.
+func (s *ServiceImpl) newOrder(products []domain.Product, items []domain.StockItem, orderReference string) (domain.Order, error) {
+	now := time.Now()
+	today := domain.Today(s.location)
+
+	lines := make([]domain.OrderLine, len(items))
+	totals := make([]domain.Money, len(items))
+	for i, p := range products {
+		if p.Status != domain.StatusPublished || !p.IsVisibleAt(now) || p.Expired || p.IsExpiredOn(today) {
+			return domain.Order{}, fmt.Errorf("%w: %s", ErrUnavailable, p.CodeValue)
+		}
+		quote := s.products.PriceOf(p, items[i].Quantity)
+		totals[i] = quote.Total
+		lines[i] = domain.OrderLine{
+			ProductId: p.Id,
+			CodeValue: p.CodeValue,
+			Name:      p.Name,
+			Quantity:  items[i].Quantity,
+			UnitPrice: quote.UnitPrice,
+			Total:     totals[i],
+		}
+	}
+
+	total, err := domain.Sum(totals...)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	return domain.Order{
+		Status:    domain.OrderPlaced,
+		Lines:     lines,
+		Total:     total,
+		Currency:  total.Currency,
+		Reference: orderReference,
+		CreatedAt: now.UTC(),
+	}, nil
+}
+
+// Auxiliary function that merges the lines of the same product and checks their quantities.
+func mergeItems(lines []domain.StockItem) ([]domain.StockItem, error) {
+	var items []domain.StockItem
+	positions := map[int]int{}
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if i, ok := positions[line.ProductId]; ok {
+			items[i].Quantity += line.Quantity
+			continue
+		}
+		positions[line.ProductId] = len(items)
+		items = append(items, line)
+	}
+	if len(items) == 0 {
+		return nil, ErrInvalidQuantity
+	}
+	return items, nil
+}
+
+// Auxiliary function that returns the reference of the stock movements of an order.
+func reference(id int) string {
+	return fmt.Sprintf("order-%d", id)
+}
@@ -0,0 +1,113 @@
+package order
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var errStore = errors.New("order store unavailable")
+
+// failingStore is an order store that cannot save anything.
+type failingStore struct{}
+
+func (failingStore) Load() ([]domain.Order, error) {
+	return nil, nil
+}
+
+func (failingStore) Save([]domain.Order) error {
+	return errStore
+}
+
+func createServiceForTest(t *testing.T, orderStore store.DocumentStore[[]domain.Order]) (Service, product.Repository) {
+	dir := t.TempDir()
+
+	// Milk for sale, cheese still a draft and yogurt already expired
+	price := domain.NewMoney(domain.MustParseDecimal("12.5"), "USD")
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 10, Price: price, Status: domain.StatusPublished, IsPublished: true, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 2, CodeValue: "CHEESE1", Quantity: 10, Price: price, Status: domain.StatusDraft, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 3, CodeValue: "YOGURT1", Quantity: 10, Price: price, Status: domain.StatusPublished, IsPublished: true, Expiration: domain.NewDate(2021, time.January, 1)},
+	}
+	if orderStore == nil {
+		orderStore = store.NewJsonDocumentStore[[]domain.Order](filepath.Join(dir, "orders.json"))
+	}
+
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	productService := product.NewService(productRepository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil)
+	locationService := location.NewService(location.NewRepository(nil, store.NewJsonDocumentStore[[]domain.Location](filepath.Join(dir, "locations.json"))))
+	ledger := stock.NewLedger(nil, store.NewJsonLinesStore[domain.StockMovement](filepath.Join(dir, "stock_movements.jsonl")))
+	stockService := stock.NewService(productService, ledger, locationService, time.UTC)
+
+	return NewService(NewRepository(nil, orderStore), productService, stockService, time.UTC), productRepository
+}
+
+func TestService_Create(t *testing.T) {
+	service, productRepository := createServiceForTest(t, nil)
+
+	tests := []struct {
+		name  string
+		lines []domain.StockItem
+		err   error
+	}{
+		{"No lines", nil, ErrInvalidQuantity},
+		{"Zero quantity", []domain.StockItem{{ProductId: 1, Quantity: 0}}, ErrInvalidQuantity},
+		{"Unknown product", []domain.StockItem{{ProductId: 9, Quantity: 1}}, product.ErrNotFound},
+		{"Draft product", []domain.StockItem{{ProductId: 1, Quantity: 1}, {ProductId: 2, Quantity: 1}}, ErrUnavailable},
+		{"Expired product", []domain.StockItem{{ProductId: 3, Quantity: 1}}, ErrUnavailable},
+		{"More than the stock", []domain.StockItem{{ProductId: 1, Quantity: 6}, {ProductId: 1, Quantity: 5}}, stock.ErrInsufficientStock},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.Create(domain.OrderRequest{Lines: test.lines})
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, 10, milk.Quantity)
+	assert.Empty(t, service.GetAll())
+
+	// Lines of the same product are merged, and the units taken out of stock
+	placed, err := service.Create(domain.OrderRequest{Lines: []domain.StockItem{{ProductId: 1, Quantity: 3}, {ProductId: 1, Quantity: 1}}, Reference: "web-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, domain.OrderPlaced, placed.Status)
+	assert.Len(t, placed.Lines, 1)
+	assert.Equal(t, "50", placed.Total.Amount.String())
+	assert.Equal(t, "USD", placed.Currency)
+	milk, _ = productRepository.GetById(1)
+	assert.Equal(t, 6, milk.Quantity)
+}
+
+func TestService_Create_FailedStore(t *testing.T) {
+	service, productRepository := createServiceForTest(t, failingStore{})
+
+	// An order that cannot be stored takes no units
+	_, err := service.Create(domain.OrderRequest{Lines: []domain.StockItem{{ProductId: 1, Quantity: 3}}})
+	assert.ErrorIs(t, err, errStore)
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, 10, milk.Quantity)
+}
+
+func TestService_Cancel(t *testing.T) {
+	service, productRepository := createServiceForTest(t, nil)
+	placed, err := service.Create(domain.OrderRequest{Lines: []domain.StockItem{{ProductId: 1, Quantity: 4}}})
+	assert.Nil(t, err)
+
+	// The units are put back only once
+	cancelled, err := service.Cancel(placed.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.OrderCancelled, cancelled.Status)
+	assert.NotNil(t, cancelled.CancelledAt)
+	_, err = service.Cancel(placed.Id)
+	assert.ErrorIs(t, err, ErrNotPlaced)
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, 10, milk.Quantity)
+}
@@ -0,0 +1,59 @@
+package price
+
+import (
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+// History is the interface definition for the append-only price history
+type History interface {
+	Record(change domain.PriceChange) error
+	GetByProduct(productId int) []domain.PriceChange
+}
+
+// HistoryImpl is the implementation of the history interface
+type HistoryImpl struct {
+	mu      sync.RWMutex
+	changes []domain.PriceChange
+	store   store.AppendStore[domain.PriceChange]
+}
+
+/*
+The NewHistory function returns a new instance of the price history. It starts with the given
+changes and appends every new change to the provided store.
+*/
+func NewHistory(changes []domain.PriceChange, changeStore store.AppendStore[domain.PriceChange]) History {
+	return &HistoryImpl{
+		changes: changes,
+		store:   changeStore,
+	}
+}
+
+// The Record method appends a price change to the history. Changes are never modified or removed afterwards.
+func (h *HistoryImpl) Record(change domain.PriceChange) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	change.Currency = change.Price.Currency
+	if err := h.store.Append(change); err != nil {
+		return err
+	}
+	h.changes = append(h.changes, change)
+	return nil
+}
+
+// The GetByProduct method returns the price changes of a product, oldest first.
+func (h *HistoryImpl) GetByProduct(productId int) []domain.PriceChange {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	changes := []domain.PriceChange{}
+	for _, change := range h.changes {
+		if change.ProductId == productId {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
@@ -2,8 +2,10 @@ package product
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
 )
 
 var (
@@ -15,31 +17,43 @@ var (
 type Repository interface {
 	GetAll() []domain.Product
 	GetById(id int) (domain.Product, error)
-	GetByPriceGt(price float64) []domain.Product
 	Create(product domain.Product) (domain.Product, error)
 	Update(id int, newProductData domain.Product) (domain.Product, error)
-	Delete(id int) error
+	Delete(id int, checks ...func(products []domain.Product) error) error
+	Modify(id int, change func(product *domain.Product) error, records ...func() error) (domain.Product, error)
+	ModifyAll(change func(products []domain.Product) error, records ...func() error) ([]domain.Product, error)
 }
 
 // RepositoryImpl is the implementation of the repository interface
 type RepositoryImpl struct {
+	mu          sync.RWMutex
 	productList []domain.Product
+	store       store.Store
 }
 
-// The NewRepository function returns a new instance of the repository.
-func NewRepository(productList []domain.Product) Repository {
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+products and saves every change in the provided store, so changes survive a restart.
+*/
+func NewRepository(productList []domain.Product, productStore store.Store) Repository {
 	return &RepositoryImpl{
 		productList: productList,
+		store:       productStore,
 	}
 }
 
 // The GetAll method returns all available products
 func (r *RepositoryImpl) GetAll() []domain.Product {
-	return r.productList
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Product{}, r.productList...)
 }
 
 // The GetById method returns a product by its ID
 func (r *RepositoryImpl) GetById(id int) (domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, product := range r.productList {
 		if product.Id == id {
 			return product, nil
@@ -49,29 +63,28 @@ func (r *RepositoryImpl) GetById(id int) (domain.Product, error) {
 	return domain.Product{}, ErrNotFound
 }
 
-// The GetByPriceGt method returns a list of products with a price greater than the given price.
-func (r *RepositoryImpl) GetByPriceGt(price float64) []domain.Product {
-	var filteredProducts []domain.Product
-
-	for _, product := range r.productList {
-		if product.Price > price {
-			filteredProducts = append(filteredProducts, product)
-		}
-	}
-	return filteredProducts
-}
-
 /*
 The Create method creates a new product. If the product code already exists, it will return an error.
-Otherwise, it creates a new product.
+Otherwise, it creates a new product with the ID after the highest one, so the IDs of deleted products
+are never given again.
 */
 func (r *RepositoryImpl) Create(product domain.Product) (domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if !r.validateCodeValue(product.CodeValue) {
 		return domain.Product{}, ErrInvalidCode
 	}
 
-	product.Id = len(r.productList) + 1
-	r.productList = append(r.productList, product)
+	product.Id = 1
+	for _, p := range r.productList {
+		if p.Id >= product.Id {
+			product.Id = p.Id + 1
+		}
+	}
+	if err := r.save(append(r.copyList(), product)); err != nil {
+		return domain.Product{}, err
+	}
 
 	return product, nil
 }
@@ -82,6 +95,9 @@ data as parameters and returns the updated product if the process was successful
 returns an error.
 */
 func (r *RepositoryImpl) Update(id int, updatedProduct domain.Product) (domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	// Search for the product with the given ID
 	for i, product := range r.productList {
 		if product.Id == id {
@@ -91,22 +107,98 @@ func (r *RepositoryImpl) Update(id int, updatedProduct domain.Product) (domain.P
 			}
 			// Store the updated product and return it
 			updatedProduct.Id = id
-			r.productList[i] = updatedProduct
+			productList := r.copyList()
+			productList[i] = updatedProduct
+			if err := r.save(productList); err != nil {
+				return domain.Product{}, err
+			}
 			return updatedProduct, nil
 		}
 	}
 	return domain.Product{}, ErrNotFound
 }
 
+/*
+The Modify method atomically reads, changes and stores a product. The change function runs while no
+other write can happen; if it returns an error, nothing is stored and that error is returned. The
+records functions write whatever goes along with the change somewhere else, such as a stock movement
+in the ledger or an entry in the audit log. They run once the product is stored, still inside the
+same operation, and if one of them fails the product is stored back as it was. This way nothing is
+recorded for a change that could not be stored.
+*/
+func (r *RepositoryImpl) Modify(id int, change func(product *domain.Product) error, records ...func() error) (domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, product := range r.productList {
+		if product.Id == id {
+			// The product is copied so a failed change leaves the stored product untouched
+			product = deepCopy(product)
+			if err := change(&product); err != nil {
+				return domain.Product{}, err
+			}
+			product.Id = id
+			if product.CodeValue != r.productList[i].CodeValue && !r.validateCodeValue(product.CodeValue) {
+				return domain.Product{}, ErrInvalidCode
+			}
+			productList := r.copyList()
+			productList[i] = product
+			if err := r.commit(productList, records); err != nil {
+				return domain.Product{}, err
+			}
+			return product, nil
+		}
+	}
+	return domain.Product{}, ErrNotFound
+}
+
+/*
+The ModifyAll method works like Modify, but on the whole product list, so several products can be
+changed in a single atomic operation. The change function must not add, remove or reorder products.
+*/
+func (r *RepositoryImpl) ModifyAll(change func(products []domain.Product) error, records ...func() error) ([]domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	productList := make([]domain.Product, len(r.productList))
+	for i, product := range r.productList {
+		productList[i] = deepCopy(product)
+	}
+	if err := change(productList); err != nil {
+		return nil, err
+	}
+	codeValues := make(map[string]bool, len(productList))
+	for _, product := range productList {
+		if codeValues[product.CodeValue] {
+			return nil, ErrInvalidCode
+		}
+		codeValues[product.CodeValue] = true
+	}
+	if err := r.commit(productList, records); err != nil {
+		return nil, err
+	}
+	return append([]domain.Product{}, productList...), nil
+}
+
 /*
 The Delete method deletes a product. It receives the ID of the product and returns an error if the
-product does not exist.
+product does not exist. The checks (such as whether other products refer to it) run on the current
+product list while it is locked, so they hold when the product is deleted; if one fails, its error is
+returned and nothing is deleted.
 */
-func (r *RepositoryImpl) Delete(id int) error {
+func (r *RepositoryImpl) Delete(id int, checks ...func(products []domain.Product) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	for i, product := range r.productList {
 		if product.Id == id {
-			r.productList = append(r.productList[:i], r.productList[i+1:]...)
-			return nil
+			productList := r.copyList()
+			for _, check := range checks {
+				if err := check(productList); err != nil {
+					return err
+				}
+			}
+			return r.save(append(productList[:i], productList[i+1:]...))
 		}
 	}
 	return ErrNotFound
@@ -124,3 +216,64 @@ func (r *RepositoryImpl) validateCodeValue(codeValue string) bool {
 	}
 	return true
 }
+
+// Auxiliary function that returns a copy of the product list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.Product {
+	return append([]domain.Product{}, r.productList...)
+}
+
+// Auxiliary function that copies a product along with the slices and pointers it holds, which are shared otherwise.
+func deepCopy(product domain.Product) domain.Product {
+	product.Lots = append([]domain.Lot(nil), product.Lots...)
+	product.Stock = append([]domain.LocationStock(nil), product.Stock...)
+	product.ScheduledPrices = append([]domain.ScheduledPrice(nil), product.ScheduledPrices...)
+	product.PriceTiers = append([]domain.PriceTier(nil), product.PriceTiers...)
+	product.Tags = append([]string(nil), product.Tags...)
+	product.Options = append([]domain.ProductOption(nil), product.Options...)
+	product.Components = append([]domain.BundleComponent(nil), product.Components...)
+	if product.Attributes != nil {
+		attributes := make(domain.Attributes, len(product.Attributes))
+		for name, value := range product.Attributes {
+			attributes[name] = value
+		}
+		product.Attributes = attributes
+	}
+	if product.OptionValues != nil {
+		optionValues := make(map[string]string, len(product.OptionValues))
+		for name, value := range product.OptionValues {
+			optionValues[name] = value
+		}
+		product.OptionValues = optionValues
+	}
+	if product.Markdown != nil {
+		markdown := *product.Markdown
+		product.Markdown = &markdown
+	}
+	return product
+}
+
+// Auxiliary function that saves the product list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(productList []domain.Product) error {
+	if err := r.store.Save(productList); err != nil {
+		return err
+	}
+	r.productList = productList
+	return nil
+}
+
+/*
+Auxiliary function that saves the product list and then runs the records functions. If one of them
+fails, the previous product list is saved back and the error is returned.
+*/
+func (r *RepositoryImpl) commit(productList []domain.Product, records []func() error) error {
+	previous := r.productList
+	if err := r.save(productList); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := record(); err != nil {
+			return errors.Join(err, r.save(previous))
+		}
+	}
+	return nil
+}
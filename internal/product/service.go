@@ -2,33 +2,156 @@ package product
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/soppibb/practica-go-web/internal/domain"
 )
 
+var (
+	ErrMissingExpiration   = errors.New("expiration date is required")
+	ErrExpiredDate         = errors.New("expiration date must be after current date")
+	ErrInvalidPrice        = errors.New("product price must be greater than zero")
+	ErrInvalidStatus       = errors.New("invalid product status")
+	ErrIllegalTransition   = errors.New("illegal product status transition")
+	ErrInvalidSchedule     = errors.New("unpublish date must be after publish date")
+	ErrInvalidQuantity     = errors.New("quantity must be greater than zero")
+	ErrHasVariants         = errors.New("product has variants, delete them first")
+	ErrDuplicateVariant    = errors.New("a variant with the same option values already exists")
+	ErrInBundle            = errors.New("product is a component of a bundle, remove it from the bundle first")
+	ErrInvalidReorderPoint = errors.New("reorder point must not be negative")
+	ErrStockManaged        = errors.New("quantity changes through stock adjustments, so they are recorded in the ledger")
+	ErrUnavailable         = errors.New("product is not available for sale")
+)
+
+// errNothingDue tells that a product has no scheduled change left to apply, so it is not written.
+var errNothingDue = errors.New("nothing due")
+
+// apiActor is the actor reported in the price history for the changes made through the API.
+const apiActor = "api"
+
+// transitions lists the lifecycle states each state can move to.
+var transitions = map[domain.ProductStatus][]domain.ProductStatus{
+	domain.StatusDraft:        {domain.StatusInReview, domain.StatusArchived},
+	domain.StatusInReview:     {domain.StatusDraft, domain.StatusPublished},
+	domain.StatusPublished:    {domain.StatusDraft, domain.StatusDiscontinued},
+	domain.StatusDiscontinued: {domain.StatusPublished, domain.StatusArchived},
+	domain.StatusArchived:     {},
+}
+
+// PriceConverter converts prices between currencies.
+type PriceConverter interface {
+	Convert(amount domain.Money, currency string) (domain.Money, error)
+}
+
+/*
+HoldCounter reports the units of a product that are held and cannot be sold, such as active
+reservations. It is asked while products are being changed, so it must not read them back.
+*/
+type HoldCounter interface {
+	HeldQuantity(product domain.Product) int
+}
+
+// LocationHoldCounter is a HoldCounter that also knows the location of some of the units it holds.
+type LocationHoldCounter interface {
+	HoldCounter
+	HeldQuantityAt(product domain.Product, location string) int
+}
+
+// StockAlerter is told about every product written by the service, to warn about low stock.
+type StockAlerter interface {
+	Check(product domain.Product)
+}
+
+// Pricer quotes the price of some units of a product after the promotions that apply to it.
+type Pricer interface {
+	Quote(product domain.Product, quantity int, now time.Time) domain.PriceQuote
+}
+
+// PriceRecorder records the price changes of the products in the price history.
+type PriceRecorder interface {
+	Record(change domain.PriceChange) error
+}
+
+// PriceHistory records the price changes of the products and returns them.
+type PriceHistory interface {
+	PriceRecorder
+	GetByProduct(productId int) []domain.PriceChange
+}
+
+// AttributeValidator checks the custom attributes of a product against the schemas of its category.
+type AttributeValidator interface {
+	ValidateAttributes(categoryId int, attributes domain.Attributes) error
+}
+
 type Service interface {
 	GetAll() []domain.Product
 	GetById(id int) (domain.Product, error)
-	GetByPriceGt(price float64) ([]domain.Product, error)
+	GetByPriceGt(price domain.Money) ([]domain.Product, error)
+	ConvertPrices(products []domain.Product, currency string) ([]domain.Product, error)
+	AtLocation(products []domain.Product, location string) []domain.Product
 	Create(product domain.Product) (domain.Product, error)
-	Update(id int, updatedProduct domain.Product) (domain.Product, error)
+	Update(id int, request domain.ProductRequest, actor string) (domain.Product, error)
+	SetPrice(id int, request domain.PriceRequest, actor string) (domain.Product, error)
+	PriceOf(product domain.Product, quantity int) domain.PriceQuote
+	Quote(items []domain.QuoteItem, region string) (domain.Quote, error)
+	WithTax(products []domain.Product, region string) ([]domain.Product, error)
+	GetPrices(id int) (domain.PriceTimeline, error)
 	Delete(id int) error
+	Transition(id int, status domain.ProductStatus) (domain.Product, error)
+	ApplySchedule(now time.Time) ([]domain.Product, error)
+	GetExpiring(days int) []domain.Product
+	CreateVariant(parentId int, request domain.VariantRequest) (domain.Product, error)
+	GetVariants(parentId int) ([]domain.Product, error)
+	CollapseVariants(products []domain.Product) []domain.Product
+	Modify(id int, change func(product *domain.Product) error, records ...func() error) (domain.Product, error)
+	ModifyAll(change func(products []domain.Product) error, records ...func() error) ([]domain.Product, error)
+	Available(product domain.Product, products []domain.Product) int
+	AvailableAt(product domain.Product, location string, products []domain.Product) int
 }
 
 type ServiceImpl struct {
 	repository Repository
+	location   *time.Location
+	converter  PriceConverter
+	alerter    StockAlerter
+	pricer     Pricer
+	history    PriceHistory
+	taxes      domain.TaxTable
+	attributes AttributeValidator
+	holds      []HoldCounter
+	// mu makes the checks of the links between products (variants and bundles) atomic with the changes they allow
+	mu sync.Mutex
 }
 
-// The NewService function returns a new instance of the service.
-func NewService(repository Repository) Service {
+/*
+The NewService function returns a new instance of the service. The location is the catalog time
+zone, used to decide which day is "today" when validating expiration dates. The converter is used
+to compare and present prices in other currencies, the alerter (optional) to warn about low stock
+after every write, the pricer (optional) to present the effective price of every product, the
+history (optional) to record every price change, the tax table to break prices down by region, the
+attribute validator (optional) to check the custom attributes of the products in a category, and
+the hold counters to compute the available quantity of every product.
+*/
+func NewService(repository Repository, location *time.Location, converter PriceConverter, alerter StockAlerter, pricer Pricer, history PriceHistory, taxes domain.TaxTable, attributes AttributeValidator, holds ...HoldCounter) Service {
 	return &ServiceImpl{
 		repository: repository,
+		location:   location,
+		converter:  converter,
+		alerter:    alerter,
+		pricer:     pricer,
+		history:    history,
+		taxes:      taxes,
+		attributes: attributes,
+		holds:      holds,
 	}
 }
 
-// The GetAll method returns all available products
+// The GetAll method returns all available products, leaving out those outside their publication window
 func (s *ServiceImpl) GetAll() []domain.Product {
-	return s.repository.GetAll()
+	return s.present(visibleProducts(s.repository.GetAll(), time.Now()))
 }
 
 // The GetById method returns a product by its ID
@@ -37,20 +160,114 @@ func (s *ServiceImpl) GetById(id int) (domain.Product, error) {
 	if err != nil {
 		return domain.Product{}, err
 	}
-	return product, nil
+	return s.present([]domain.Product{product})[0], nil
 }
 
 /*
 The GetByPriceGt method returns all product that has a price greater than the given price.
+Product prices are converted to the currency of the given price before being compared.
 If no product has a price greater than the given price, it returns an error.
-Otherwise, it returns all product that has a price greater than the given price.
 */
-func (s *ServiceImpl) GetByPriceGt(price float64) ([]domain.Product, error) {
-	products := s.repository.GetByPriceGt(price)
+func (s *ServiceImpl) GetByPriceGt(price domain.Money) ([]domain.Product, error) {
+	var products []domain.Product
+	for _, product := range visibleProducts(s.repository.GetAll(), time.Now()) {
+		converted, err := s.converter.Convert(product.Price, price.Currency)
+		if err != nil {
+			return []domain.Product{}, err
+		}
+		if converted.Amount.GreaterThan(price.Amount) {
+			products = append(products, product)
+		}
+	}
+
 	if len(products) == 0 {
 		return []domain.Product{}, errors.New("no products found")
 	}
-	return products, nil
+	return s.present(products), nil
+}
+
+/*
+The ConvertPrices method returns a copy of the given products with their prices converted to the
+given currency. An empty currency leaves the prices in their base currency.
+*/
+func (s *ServiceImpl) ConvertPrices(products []domain.Product, currency string) ([]domain.Product, error) {
+	if currency == "" {
+		return products, nil
+	}
+
+	converted := make([]domain.Product, len(products))
+	for i, product := range products {
+		price, err := s.converter.Convert(product.Price, currency)
+		if err != nil {
+			return nil, err
+		}
+		product.Price = price
+		if product.Markdown != nil {
+			markdown := *product.Markdown
+			if markdown.BasePrice, err = s.converter.Convert(markdown.BasePrice, currency); err != nil {
+				return nil, err
+			}
+			product.Markdown = &markdown
+		}
+		tiers := make([]domain.PriceTier, len(product.PriceTiers))
+		for j, tier := range product.PriceTiers {
+			if tier.Price, err = s.converter.Convert(tier.Price, currency); err != nil {
+				return nil, err
+			}
+			tiers[j] = tier
+		}
+		if len(tiers) > 0 {
+			product.PriceTiers = tiers
+		}
+		if product.EffectivePrice != nil {
+			effectivePrice, err := s.converter.Convert(*product.EffectivePrice, currency)
+			if err != nil {
+				return nil, err
+			}
+			product.EffectivePrice = &effectivePrice
+		}
+		if product.Variants != nil {
+			variants := *product.Variants
+			if variants.MinPrice, err = s.converter.Convert(variants.MinPrice, currency); err != nil {
+				return nil, err
+			}
+			if variants.MaxPrice, err = s.converter.Convert(variants.MaxPrice, currency); err != nil {
+				return nil, err
+			}
+			product.Variants = &variants
+		}
+		// The promotions are converted in a copy, since the given products share them
+		promotions := make([]domain.AppliedPromotion, len(product.Promotions))
+		for j, promotion := range product.Promotions {
+			if promotion.Discount, err = s.converter.Convert(promotion.Discount, currency); err != nil {
+				return nil, err
+			}
+			promotions[j] = promotion
+		}
+		if len(promotions) > 0 {
+			product.Promotions = promotions
+		}
+		converted[i] = product
+	}
+	return converted, nil
+}
+
+/*
+The AtLocation method returns the given products that have stock at the given location. An empty
+location leaves the list as it is.
+*/
+func (s *ServiceImpl) AtLocation(products []domain.Product, location string) []domain.Product {
+	if location == "" {
+		return products
+	}
+
+	stocked := []domain.Product{}
+	for _, product := range products {
+		if product.QuantityAt(location) > 0 {
+			stocked = append(stocked, product)
+		}
+	}
+	return stocked
 }
 
 /*
@@ -58,57 +275,1086 @@ The Create method try to create a new product. If the product already exists, it
 Otherwise, it creates a new product and returns it.
 */
 func (s *ServiceImpl) Create(product domain.Product) (domain.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Lots and locations are filled through the stock service, and suppliers and categories are linked through theirs
+	product.Lots = nil
+	product.Stock = nil
+	product.SupplierId = 0
+	product.CategoryId = 0
+	// Effective prices are computed from the promotions when products are read, and markdowns by their job
+	product.EffectivePrice = nil
+	product.Promotions = nil
+	product.Markdown = nil
+	product.Tax = nil
+	product.AvailableQuantity = nil
+	// Future prices are scheduled through their own endpoint, and variants are created through theirs
+	product.ScheduledPrices = nil
+	product.ParentId = 0
+	product.OptionValues = nil
+	product.Variants = nil
+	// New products start as drafts or published, later states are reached through transitions
+	if product.Status != "" && product.Status != domain.StatusDraft && product.Status != domain.StatusPublished {
+		return domain.Product{}, ErrIllegalTransition
+	}
+	return s.create(product)
+}
+
+// Auxiliary function that validates and stores a new product, either a top-level one or a variant.
+func (s *ServiceImpl) create(product domain.Product) (domain.Product, error) {
+	if product.Expiration.IsZero() {
+		return domain.Product{}, ErrMissingExpiration
+	}
+	if err := s.validateExpiration(product.Expiration); err != nil {
+		return domain.Product{}, err
+	}
+	if !product.Price.Amount.IsPositive() {
+		return domain.Product{}, ErrInvalidPrice
+	}
+	if err := s.checkCurrency(product.Price); err != nil {
+		return domain.Product{}, err
+	}
+	if !s.taxes.HasCategory(product.TaxCategory) {
+		return domain.Product{}, fmt.Errorf("%w: %s", domain.ErrUnknownTaxCategory, product.TaxCategory)
+	}
+	if product.Status == "" {
+		product.SetPublished(product.IsPublished)
+	}
+	if !product.Status.IsValid() {
+		return domain.Product{}, ErrInvalidStatus
+	}
+	product.IsPublished = product.Status == domain.StatusPublished
+	if err := validateSchedule(product); err != nil {
+		return domain.Product{}, err
+	}
+	if err := domain.ValidateOptions(product.Options); err != nil {
+		return domain.Product{}, err
+	}
+	if err := validateComponents(product, s.repository.GetAll()); err != nil {
+		return domain.Product{}, err
+	}
+	if product.IsBundle() {
+		product.Quantity = 0
+	}
+	if err := validatePriceTiers(&product); err != nil {
+		return domain.Product{}, err
+	}
+	tags, err := domain.NormalizeTags(product.Tags)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	product.Tags = tags
+
 	newProduct, err := s.repository.Create(product)
 	if err != nil {
 		return domain.Product{}, err
 	}
-	return newProduct, nil
+	s.checkStock(newProduct)
+	return s.present([]domain.Product{newProduct})[0], nil
 }
 
 /*
-The Update method try to update a product. If the product does not exist or any updated fields
-data is invalid then returns an error. Otherwise, it updates the product and returns it.
+The Update method try to update a product with the fields given in the request. If the product does
+not exist or any updated fields data is invalid then returns an error. Otherwise, it updates the
+product and returns it. Quantities only change through stock movements, so they are recorded in the
+ledger: a quantity other than the current one returns ErrStockManaged. A price change is recorded in
+the price history on behalf of the given actor.
 */
-func (s *ServiceImpl) Update(id int, newProductData domain.Product) (domain.Product, error) {
-	// Search the old product data
-	product, err := s.repository.GetById(id)
+func (s *ServiceImpl) Update(id int, request domain.ProductRequest, actor string) (domain.Product, error) {
+	if request.Options != nil || request.Components != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	// The whole list is changed at once, because options and components are checked against other products
+	var updatedProduct domain.Product
+	var previousPrice domain.Money
+	var priceReason domain.PriceChangeReason
+	_, err := s.repository.ModifyAll(func(products []domain.Product) error {
+		i := indexOf(products, id)
+		if i < 0 {
+			return ErrNotFound
+		}
+		previousPrice = products[i].Price
+		reason, err := s.update(&products[i], products, request)
+		if err != nil {
+			return err
+		}
+		updatedProduct, priceReason = products[i], reason
+		return nil
+	}, func() error {
+		if updatedProduct.Price == previousPrice {
+			return nil
+		}
+		return s.recordPrice(updatedProduct, previousPrice, priceReason, actor, time.Now())
+	})
 	if err != nil {
 		return domain.Product{}, err
 	}
+	s.checkStock(updatedProduct)
+	return s.present([]domain.Product{updatedProduct})[0], nil
+}
+
+/*
+Auxiliary function that applies the fields given in an update request to a product, checking them
+against the rest of the products. It returns the reason of the price change, if the price changes.
+*/
+func (s *ServiceImpl) update(product *domain.Product, products []domain.Product, request domain.ProductRequest) (domain.PriceChangeReason, error) {
+	// The quantity of a bundle comes from its components, and is not changed here either
+	if request.Quantity > 0 && !product.IsBundle() && request.Quantity != product.Quantity {
+		switch {
+		case product.IsLocationTracked():
+			return "", domain.ErrLocationTrackedProduct
+		case product.IsLotTracked():
+			return "", domain.ErrLotTrackedProduct
+		}
+		return "", ErrStockManaged
+	}
+
+	// The expiration of a lot-tracked product comes from its lots
+	if product.IsLotTracked() && !request.Expiration.IsZero() && request.Expiration != product.Expiration {
+		return "", domain.ErrLotTrackedProduct
+	}
 
 	// Update the product data
-	if newProductData.Name != "" {
-		product.Name = newProductData.Name
+	if request.Name != "" {
+		product.Name = request.Name
 	}
-	if newProductData.Quantity > 0 {
-		product.Quantity = newProductData.Quantity
+	if request.TaxCategory != "" {
+		if !s.taxes.HasCategory(request.TaxCategory) {
+			return "", fmt.Errorf("%w: %s", domain.ErrUnknownTaxCategory, request.TaxCategory)
+		}
+		product.TaxCategory = request.TaxCategory
 	}
-	if newProductData.CodeValue != "" {
-		product.CodeValue = newProductData.CodeValue
+	// A reorder point of zero turns the low-stock alerts off
+	if request.ReorderPoint != nil {
+		if *request.ReorderPoint < 0 {
+			return "", ErrInvalidReorderPoint
+		}
+		product.ReorderPoint = *request.ReorderPoint
 	}
-	if newProductData.Expiration != "" {
-		product.Expiration = newProductData.Expiration
+	if request.CodeValue != "" {
+		product.CodeValue = request.CodeValue
 	}
-	if newProductData.Price > 0 {
-		product.Price = newProductData.Price
+	priceReason := domain.PriceChangeReason("")
+	if !request.Expiration.IsZero() {
+		if err := s.validateExpiration(request.Expiration); err != nil {
+			return "", err
+		}
+		// A markdown is computed for an expiration date, so editing the date restores the list price
+		if product.Markdown != nil && request.Expiration != product.Expiration {
+			product.ApplyMarkdown(domain.Decimal{}, time.Now())
+			priceReason = domain.PriceMarkdownReverted
+		}
+		product.Expiration = request.Expiration
+		product.Expired = product.IsExpiredOn(domain.Today(s.location))
 	}
-	product.IsPublished = newProductData.IsPublished
+	if request.Price != nil {
+		// A price without a currency keeps the currency of the product
+		currency := request.Currency
+		if currency == "" {
+			currency = product.Price.Currency
+		}
+		price := domain.NewMoney(*request.Price, currency)
+		if !price.Amount.IsPositive() {
+			return "", ErrInvalidPrice
+		}
+		if err := s.checkCurrency(price); err != nil {
+			return "", err
+		}
+		// Variants share the currency of their parent, so their prices can be compared in listings
+		if i := indexOf(products, product.ParentId); product.IsVariant() && i >= 0 && currency != products[i].Price.Currency {
+			return "", fmt.Errorf("%w: the price must be in %s like the parent", domain.ErrInvalidVariant, products[i].Price.Currency)
+		}
+		// A new price replaces the list price, the markdown job marks it down again if due
+		if price != product.Price {
+			product.Price = price
+			product.Markdown = nil
+			priceReason = domain.PriceManual
+		}
+	}
+	if request.AllowBackorder != nil {
+		product.AllowBackorder = *request.AllowBackorder
+	}
+	if request.PublishAt != nil {
+		product.PublishAt = request.PublishAt
+	}
+	if request.UnpublishAt != nil {
+		product.UnpublishAt = request.UnpublishAt
+	}
+	if err := validateSchedule(*product); err != nil {
+		return "", err
+	}
+	// Tiers are checked again when the price changes, so they always stay below it and in its currency
+	if request.PriceTiers != nil {
+		product.PriceTiers = request.PriceTiers
+	}
+	if request.PriceTiers != nil || priceReason != "" {
+		if err := validatePriceTiers(product); err != nil {
+			return "", err
+		}
+	}
+	if request.Tags != nil {
+		tags, err := domain.NormalizeTags(request.Tags)
+		if err != nil {
+			return "", err
+		}
+		product.Tags = tags
+	}
+	if request.Options != nil {
+		product.Options = request.Options
+		if err := validateOptions(*product, products); err != nil {
+			return "", err
+		}
+	}
+	if request.Attributes != nil {
+		product.Attributes = request.Attributes
+		if len(product.Attributes) == 0 {
+			product.Attributes = nil
+		}
+		if s.attributes != nil && product.CategoryId != 0 {
+			if err := s.attributes.ValidateAttributes(product.CategoryId, product.Attributes); err != nil {
+				return "", err
+			}
+		}
+	}
+	if request.Components != nil {
+		// A product turns into a bundle only once its own stock is gone, which leaves it through the ledger
+		if len(request.Components) > 0 && !product.IsBundle() && product.Quantity != 0 {
+			return "", fmt.Errorf("%w: the product still has %d units of its own", domain.ErrInvalidBundle, product.Quantity)
+		}
+		product.Components = request.Components
+		if len(product.Components) == 0 {
+			product.Components = nil
+		}
+		if err := validateComponents(*product, products); err != nil {
+			return "", err
+		}
+	}
+	// Bundles hold no stock of their own
+	if product.IsBundle() {
+		product.Quantity = 0
+	}
+
+	// The legacy is_published flag moves the product through the transitions table too
+	if request.IsPublished != nil {
+		if err := publish(product, *request.IsPublished); err != nil {
+			return "", err
+		}
+	}
+	return priceReason, nil
+}
+
+/*
+The Delete method try to delete a product. If the product does not exist, or it is the parent of
+some variants, it returns an error.
+*/
+func (s *ServiceImpl) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The links are checked by the repository, so no product can start referring to this one before it is deleted
+	err := s.repository.Delete(id, func(products []domain.Product) error {
+		if len(variantsOf(products, id)) > 0 {
+			return ErrHasVariants
+		}
+		for _, p := range products {
+			if p.HasComponent(id) {
+				return fmt.Errorf("%w: %s", ErrInBundle, p.CodeValue)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+/*
+Auxiliary function that checks that the currency of a price is in the exchange rates table, so the
+product can be compared and presented in other currencies.
+*/
+func (s *ServiceImpl) checkCurrency(price domain.Money) error {
+	if s.converter == nil {
+		return nil
+	}
+	_, err := s.converter.Convert(price, price.Currency)
+	return err
+}
+
+/*
+Auxiliary function that records a price change of a product in the price history, if there is one.
+Changes without an actor are reported as made through the API.
+*/
+func (s *ServiceImpl) recordPrice(product domain.Product, previous domain.Money, reason domain.PriceChangeReason, actor string, at time.Time) error {
+	if s.history == nil {
+		return nil
+	}
+	if actor == "" {
+		actor = apiActor
+	}
+	return s.history.Record(domain.PriceChange{
+		ProductId: product.Id,
+		Previous:  previous,
+		Price:     product.Price,
+		Reason:    reason,
+		Actor:     actor,
+		ChangedAt: at.UTC(),
+	})
+}
+
+// Auxiliary function that hands a written product to the stock alerter, if there is one.
+func (s *ServiceImpl) checkStock(product domain.Product) {
+	if s.alerter != nil && !product.IsBundle() {
+		s.alerter.Check(product)
+	}
+}
 
-	// Store the updated product data
-	updatedProduct, err := s.repository.Update(id, product)
+// Auxiliary function that checks if the expiration date occurs after the current date in the catalog time zone.
+func (s *ServiceImpl) validateExpiration(expiration domain.Date) error {
+	if !expiration.After(domain.Today(s.location)) {
+		return ErrExpiredDate
+	}
+	return nil
+}
+
+/*
+The Transition method moves a product to a new lifecycle state. If the product does not exist, the
+status is unknown or the transition is not allowed from the current state, it returns an error.
+*/
+func (s *ServiceImpl) Transition(id int, status domain.ProductStatus) (domain.Product, error) {
+	if !status.IsValid() {
+		return domain.Product{}, ErrInvalidStatus
+	}
+
+	updatedProduct, err := s.repository.Modify(id, func(product *domain.Product) error {
+		if !canTransition(product.Status, status) {
+			return ErrIllegalTransition
+		}
+		product.Status = status
+		product.IsPublished = status == domain.StatusPublished
+		return nil
+	})
 	if err != nil {
 		return domain.Product{}, err
 	}
-	return updatedProduct, nil
+	return s.present([]domain.Product{updatedProduct})[0], nil
 }
 
 /*
-The Delete method try to delete a product. If the product does not exist, it returns an error.
+Auxiliary function that applies the legacy is_published flag to a product as a lifecycle transition.
+Publishing moves it to published and unpublishing a published product moves it back to draft, as
+long as the transitions table allows it.
 */
-func (s *ServiceImpl) Delete(id int) error {
-	err := s.repository.Delete(id)
+func publish(product *domain.Product, published bool) error {
+	status := product.Status
+	switch {
+	case published:
+		status = domain.StatusPublished
+	case product.Status == domain.StatusPublished:
+		status = domain.StatusDraft
+	}
+	if status == product.Status {
+		return nil
+	}
+	if !canTransition(product.Status, status) {
+		return ErrIllegalTransition
+	}
+	product.Status = status
+	product.IsPublished = status == domain.StatusPublished
+	return nil
+}
+
+// Auxiliary function that checks if a product can move from one lifecycle state to another.
+func canTransition(from domain.ProductStatus, to domain.ProductStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+The GetExpiring method returns the products that have not expired yet but will do so within the
+given number of days, sorted by expiration date (soonest first).
+*/
+func (s *ServiceImpl) GetExpiring(days int) []domain.Product {
+	today := domain.Today(s.location)
+	limit := today.AddDays(days)
+
+	products := []domain.Product{}
+	for _, product := range s.repository.GetAll() {
+		if !product.IsExpiredOn(today) && !product.Expiration.After(limit) {
+			products = append(products, product)
+		}
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		return products[i].Expiration.Before(products[j].Expiration)
+	})
+	return s.present(products)
+}
+
+/*
+The ApplySchedule method publishes every product whose publish date has been reached, unpublishes
+every product whose unpublish date has been reached and applies every scheduled price whose
+effective instant has been reached. Applied dates and prices are cleared, so each scheduled change
+happens once even if the server was down at that instant. It returns the changed products.
+*/
+func (s *ServiceImpl) ApplySchedule(now time.Time) ([]domain.Product, error) {
+	var changed []domain.Product
+	for _, candidate := range s.repository.GetAll() {
+		if _, due := applyDue(&candidate, now); !due {
+			continue
+		}
+
+		// The schedule is applied again on the stored product, which may have changed since it was read
+		var changedProduct domain.Product
+		var previousPrice domain.Money
+		var scheduled domain.ScheduledPrice
+		updatedProduct, err := s.repository.Modify(candidate.Id, func(product *domain.Product) error {
+			previousPrice = product.Price
+			applied, due := applyDue(product, now)
+			if !due {
+				return errNothingDue
+			}
+			changedProduct, scheduled = *product, applied
+			return nil
+		}, func() error {
+			if changedProduct.Price == previousPrice {
+				return nil
+			}
+			return s.recordPrice(changedProduct, previousPrice, domain.PriceScheduled, scheduled.Actor, now)
+		})
+		if errors.Is(err, errNothingDue) || errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+		changed = append(changed, updatedProduct)
+	}
+	return changed, nil
+}
+
+/*
+Auxiliary function that applies to a product the publication dates and the scheduled prices that are
+due at the given instant, and reports whether there was any. Publication goes through the transitions
+table: it waits until the product can be published (a draft must be reviewed first), except for
+archived products, which are never published again. It returns the applied scheduled price, if any.
+*/
+func applyDue(product *domain.Product, now time.Time) (domain.ScheduledPrice, bool) {
+	due := false
+	if product.PublishAt != nil && !now.Before(*product.PublishAt) {
+		if publish(product, true) == nil || product.Status == domain.StatusArchived {
+			product.PublishAt = nil
+			due = true
+		}
+	}
+	if product.UnpublishAt != nil && !now.Before(*product.UnpublishAt) {
+		// Only published products move back to draft, which the transitions table always allows
+		_ = publish(product, false)
+		product.UnpublishAt = nil
+		due = true
+	}
+	scheduled, priced := product.TakeDuePrice(now)
+	if priced {
+		// A scheduled price replaces the list price, the markdown job marks it down again if due
+		product.Price = scheduled.Price
+		product.Markdown = nil
+		due = true
+
+		// Tiers set after the price was scheduled may not suit it, so they are dropped rather than misused
+		if product.ValidatePriceTiers() != nil {
+			product.PriceTiers = nil
+		}
+	}
+	return scheduled, due
+}
+
+/*
+The SetPrice method changes the price of a product on behalf of the given actor. A price without a
+currency keeps the product currency. Prices effective from a future instant are scheduled and
+applied by ApplySchedule; otherwise the price changes right away and the change is recorded in the
+price history.
+*/
+func (s *ServiceImpl) SetPrice(id int, request domain.PriceRequest, actor string) (domain.Product, error) {
+	if !request.Price.IsPositive() {
+		return domain.Product{}, ErrInvalidPrice
+	}
+	if actor == "" {
+		actor = apiActor
+	}
+	if request.Currency != "" {
+		if err := s.checkCurrency(domain.NewMoney(request.Price, request.Currency)); err != nil {
+			return domain.Product{}, err
+		}
+	}
+
+	now := time.Now()
+	var changedProduct domain.Product
+	var previousPrice domain.Money
+	updatedProduct, err := s.repository.Modify(id, func(product *domain.Product) error {
+		previousPrice = product.Price
+		currency := request.Currency
+		if currency == "" {
+			currency = product.Price.Currency
+		}
+		price := domain.NewMoney(request.Price, currency)
+
+		// The tiers must suit the new price, even if it only applies later
+		priced := *product
+		priced.Price, priced.Markdown = price, nil
+		if err := priced.ValidatePriceTiers(); err != nil {
+			return err
+		}
+
+		if request.EffectiveFrom != nil && request.EffectiveFrom.After(now) {
+			product.SchedulePrice(domain.ScheduledPrice{
+				Price:         price,
+				Currency:      price.Currency,
+				EffectiveFrom: request.EffectiveFrom.UTC(),
+				Actor:         actor,
+				ScheduledAt:   now.UTC(),
+			})
+		} else if product.Price != price {
+			product.Price = price
+			product.Markdown = nil
+		}
+		changedProduct = *product
+		return nil
+	}, func() error {
+		if changedProduct.Price == previousPrice {
+			return nil
+		}
+		return s.recordPrice(changedProduct, previousPrice, domain.PriceManual, actor, now)
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+	return s.present([]domain.Product{updatedProduct})[0], nil
+}
+
+/*
+The GetPrices method returns the price timeline of a product: its recorded price changes, its
+current price and its scheduled prices.
+*/
+func (s *ServiceImpl) GetPrices(id int) (domain.PriceTimeline, error) {
+	product, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.PriceTimeline{}, err
+	}
+
+	timeline := domain.PriceTimeline{
+		ProductId: product.Id,
+		History:   []domain.PriceChange{},
+		Price:     product.Price,
+		Currency:  product.Price.Currency,
+		Scheduled: append([]domain.ScheduledPrice{}, product.ScheduledPrices...),
+	}
+	if s.history != nil {
+		timeline.History = s.history.GetByProduct(id)
+	}
+	return timeline, nil
+}
+
+/*
+The PriceOf method prices some units of a product: the unit price of the tier their quantity
+reaches, after the promotions running now.
+*/
+func (s *ServiceImpl) PriceOf(product domain.Product, quantity int) domain.PriceQuote {
+	product.Price = product.UnitPriceFor(quantity)
+	if s.pricer == nil {
+		return domain.PriceQuote{UnitPrice: product.Price, Total: product.Price.MulInt(int64(quantity))}
+	}
+	return s.pricer.Quote(product, quantity, time.Now())
+}
+
+/*
+The Quote method prices some units of some products, as PriceOf does, and adds up the total. Every
+product must exist and be for sale (published and inside its publication window, or ErrUnavailable),
+every quantity be positive, and all the prices must share the same currency. If a region is given,
+every line and the total are broken down into their net, tax and gross amounts.
+*/
+func (s *ServiceImpl) Quote(items []domain.QuoteItem, region string) (domain.Quote, error) {
+	taxes := make([]domain.TaxAmounts, len(items))
+	lines := make([]domain.QuoteLine, len(items))
+	totals := make([]domain.Money, len(items))
+	for i, item := range items {
+		if item.Quantity <= 0 {
+			return domain.Quote{}, ErrInvalidQuantity
+		}
+		product, err := s.repository.GetById(item.Id)
+		if err != nil {
+			return domain.Quote{}, fmt.Errorf("%w: %d", err, item.Id)
+		}
+		if product.Status != domain.StatusPublished || !product.IsVisibleAt(time.Now()) {
+			return domain.Quote{}, fmt.Errorf("%w: %s", ErrUnavailable, product.CodeValue)
+		}
+
+		quote := s.PriceOf(product, item.Quantity)
+		totals[i] = quote.Total
+		lines[i] = domain.QuoteLine{
+			ProductId:  product.Id,
+			CodeValue:  product.CodeValue,
+			Name:       product.Name,
+			Quantity:   item.Quantity,
+			UnitPrice:  quote.UnitPrice,
+			Total:      quote.Total,
+			Promotions: quote.Promotions,
+		}
+		if region != "" {
+			if taxes[i], err = s.taxes.Apply(quote.Total, region, product.TaxCategory); err != nil {
+				return domain.Quote{}, err
+			}
+			lines[i].Tax = &taxes[i]
+		}
+	}
+
+	total, err := domain.Sum(totals...)
+	if err != nil {
+		return domain.Quote{}, err
+	}
+	result := domain.Quote{Lines: lines, Total: total, Currency: total.Currency}
+	if region != "" {
+		totalTax, err := domain.SumTaxes(region, taxes...)
+		if err != nil {
+			return domain.Quote{}, err
+		}
+		result.Tax = &totalTax
+	}
+	return result, nil
+}
+
+/*
+The WithTax method returns a copy of the given products with their price (the effective one, if
+they have it) broken down into its net, tax and gross amounts in the given region. An empty region
+leaves the products as they are.
+*/
+func (s *ServiceImpl) WithTax(products []domain.Product, region string) ([]domain.Product, error) {
+	if region == "" {
+		return products, nil
+	}
+
+	taxed := make([]domain.Product, len(products))
+	for i, product := range products {
+		price := product.Price
+		if product.EffectivePrice != nil {
+			price = *product.EffectivePrice
+		}
+		amounts, err := s.taxes.Apply(price, region, product.TaxCategory)
+		if err != nil {
+			return nil, err
+		}
+		product.Tax = &amounts
+		taxed[i] = product
+	}
+	return taxed, nil
+}
+
+/*
+The CreateVariant method creates a variant of a parent product for the given option values, which
+must pick one value of every option of the parent and differ from those of its other variants. The
+variant has its own code value, stock and price (the list price of the parent by default), and it
+takes the rest of its data from the parent.
+*/
+func (s *ServiceImpl) CreateVariant(parentId int, request domain.VariantRequest) (domain.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parent, err := s.repository.GetById(parentId)
 	if err != nil {
+		return domain.Product{}, err
+	}
+	if parent.IsVariant() {
+		return domain.Product{}, fmt.Errorf("%w: variants cannot have variants", domain.ErrInvalidVariant)
+	}
+	if err := parent.ValidateOptionValues(request.OptionValues); err != nil {
+		return domain.Product{}, err
+	}
+	for _, sibling := range variantsOf(s.repository.GetAll(), parentId) {
+		if domain.SameOptionValues(sibling.OptionValues, request.OptionValues) {
+			return domain.Product{}, ErrDuplicateVariant
+		}
+	}
+
+	variant := domain.Product{
+		Name:           parent.VariantName(request.OptionValues),
+		Quantity:       request.Quantity,
+		ReorderPoint:   request.ReorderPoint,
+		CodeValue:      request.CodeValue,
+		Status:         parent.Status,
+		Expiration:     parent.Expiration,
+		Price:          parent.ListPrice(),
+		TaxCategory:    parent.TaxCategory,
+		PublishAt:      parent.PublishAt,
+		UnpublishAt:    parent.UnpublishAt,
+		AllowBackorder: parent.AllowBackorder,
+		CategoryId:     parent.CategoryId,
+		Tags:           parent.Tags,
+		Attributes:     parent.Attributes,
+		ParentId:       parentId,
+		OptionValues:   request.OptionValues,
+	}
+	if !request.Expiration.IsZero() {
+		variant.Expiration = request.Expiration
+	}
+	if request.Price != nil {
+		currency := request.Currency
+		if currency == "" {
+			currency = parent.Price.Currency
+		}
+		// Variants share the currency of their parent, so their prices can be compared in listings
+		if currency != parent.Price.Currency {
+			return domain.Product{}, fmt.Errorf("%w: the price must be in %s like the parent", domain.ErrInvalidVariant, parent.Price.Currency)
+		}
+		variant.Price = domain.NewMoney(*request.Price, currency)
+	}
+	return s.create(variant)
+}
+
+// The GetVariants method returns the variants of a parent product.
+func (s *ServiceImpl) GetVariants(parentId int) ([]domain.Product, error) {
+	if _, err := s.repository.GetById(parentId); err != nil {
+		return nil, err
+	}
+	return s.present(variantsOf(s.repository.GetAll(), parentId)), nil
+}
+
+/*
+The CollapseVariants method replaces the variants in a listing with their parents, each one listed
+once where its first variant was. Parents get a summary of their variants in the listing: how many
+there are, their total available quantity and their price range, in the currency of the parent.
+Variants of parents that are not published and visible now are listed as they are.
+*/
+func (s *ServiceImpl) CollapseVariants(products []domain.Product) []domain.Product {
+	now := time.Now()
+	collapsed := []domain.Product{}
+	positions := map[int]int{}
+	for _, product := range products {
+		id := product.Id
+		var parent domain.Product
+		if product.IsVariant() {
+			var err error
+			parent, err = s.repository.GetById(product.ParentId)
+			if err != nil || parent.Status != domain.StatusPublished || !parent.IsVisibleAt(now) {
+				collapsed = append(collapsed, product)
+				continue
+			}
+			id = product.ParentId
+		}
+
+		i, listed := positions[id]
+		if !listed {
+			entry := product
+			if product.IsVariant() {
+				entry = s.present([]domain.Product{parent})[0]
+			}
+			i = len(collapsed)
+			positions[id] = i
+			collapsed = append(collapsed, entry)
+		}
+		if product.IsVariant() {
+			collapsed[i].Variants = s.summarizeVariant(collapsed[i].Variants, product, collapsed[i].Price.Currency)
+		}
+	}
+	return collapsed
+}
+
+/*
+Auxiliary function that checks the options of a product being updated against the given products.
+Variants cannot have options, and the options of a parent must keep the values its variants have.
+*/
+func validateOptions(product domain.Product, products []domain.Product) error {
+	if product.IsVariant() && len(product.Options) > 0 {
+		return fmt.Errorf("%w: variants cannot have options", domain.ErrInvalidOptions)
+	}
+	if err := domain.ValidateOptions(product.Options); err != nil {
+		return err
+	}
+	for _, variant := range variantsOf(products, product.Id) {
+		if err := product.ValidateOptionValues(variant.OptionValues); err != nil {
+			return fmt.Errorf("%w: variant %d would lose its option values", domain.ErrInvalidOptions, variant.Id)
+		}
+	}
+	return nil
+}
+
+/*
+Auxiliary function that checks the components of a product against the given products. Components
+must exist and cannot be bundles themselves, and a bundle cannot be a component of another one.
+Bundles cannot be lot-tracked or location-tracked, since they hold no stock of their own.
+*/
+func validateComponents(product domain.Product, products []domain.Product) error {
+	if !product.IsBundle() {
+		return nil
+	}
+	if err := product.ValidateComponents(); err != nil {
 		return err
 	}
+	if product.IsLotTracked() || product.IsLocationTracked() {
+		return fmt.Errorf("%w: bundles hold no stock of their own", domain.ErrInvalidBundle)
+	}
+
+	for _, component := range product.Components {
+		i := indexOf(products, component.ProductId)
+		if i < 0 {
+			return fmt.Errorf("%w: %d", ErrNotFound, component.ProductId)
+		}
+		if products[i].IsBundle() {
+			return fmt.Errorf("%w: %s is a bundle", domain.ErrInvalidBundle, products[i].CodeValue)
+		}
+	}
+	for _, p := range products {
+		if p.HasComponent(product.Id) && product.Id != 0 {
+			return fmt.Errorf("%w: the product is a component of %s", domain.ErrInvalidBundle, p.CodeValue)
+		}
+	}
 	return nil
 }
+
+// Auxiliary function that returns the position of the product with the given ID, or -1 if there is none.
+func indexOf(products []domain.Product, id int) int {
+	for i, product := range products {
+		if product.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Auxiliary function that returns the variants of a parent product.
+func variantsOf(products []domain.Product, parentId int) []domain.Product {
+	variants := []domain.Product{}
+	for _, product := range products {
+		if product.ParentId == parentId {
+			variants = append(variants, product)
+		}
+	}
+	return variants
+}
+
+// Auxiliary function that adds a variant to the summary of the variants of its parent, with prices in the given currency.
+func (s *ServiceImpl) summarizeVariant(summary *domain.VariantSummary, variant domain.Product, currency string) *domain.VariantSummary {
+	updated := domain.VariantSummary{}
+	if summary != nil {
+		updated = *summary
+	}
+	updated.Count++
+	updated.AvailableQuantity += *variant.AvailableQuantity
+
+	// Prices in another currency are converted, or left out of the range if they cannot be
+	price := variant.Price
+	if price.Currency != currency {
+		if s.converter == nil {
+			return &updated
+		}
+		converted, err := s.converter.Convert(price, currency)
+		if err != nil {
+			return &updated
+		}
+		price = converted
+	}
+	if updated.MinPrice.Currency == "" || price.Amount.LessThan(updated.MinPrice.Amount) {
+		updated.MinPrice = price
+	}
+	if updated.MaxPrice.Currency == "" || price.Amount.GreaterThan(updated.MaxPrice.Amount) {
+		updated.MaxPrice = price
+	}
+	return &updated
+}
+
+/*
+Auxiliary function that checks the price tiers of a product. Tiers given without a currency take
+the one of the product.
+*/
+func validatePriceTiers(product *domain.Product) error {
+	for i := range product.PriceTiers {
+		if product.PriceTiers[i].Price.Currency == "" {
+			product.PriceTiers[i].Price.Currency = product.ListPrice().Currency
+		}
+	}
+	return product.ValidatePriceTiers()
+}
+
+// Auxiliary function that checks if a product publication window ends after it starts.
+func validateSchedule(product domain.Product) error {
+	if product.PublishAt != nil && product.UnpublishAt != nil && !product.UnpublishAt.After(*product.PublishAt) {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// Auxiliary function that filters out the products outside their publication window.
+func visibleProducts(products []domain.Product, now time.Time) []domain.Product {
+	visible := []domain.Product{}
+	for _, product := range products {
+		if product.IsVisibleAt(now) {
+			visible = append(visible, product)
+		}
+	}
+	return visible
+}
+
+/*
+Auxiliary function that sets the available quantity (quantity minus held units, never negative) of
+the given products and, if there is a pricer, the unit price after the promotions running now. The
+quantities of a bundle are the number of whole bundles its components make up.
+*/
+func (s *ServiceImpl) present(products []domain.Product) []domain.Product {
+	now := time.Now()
+	all := s.repository.GetAll()
+	for i := range products {
+		if products[i].IsBundle() {
+			quantity, available := s.bundleQuantities(products[i], all)
+			products[i].Quantity, products[i].AvailableQuantity = quantity, &available
+		} else {
+			available := s.Available(products[i], all)
+			products[i].AvailableQuantity = &available
+		}
+
+		products[i].EffectivePrice, products[i].Promotions = nil, nil
+		if s.pricer != nil {
+			quote := s.pricer.Quote(products[i], 1, now)
+			if len(quote.Promotions) > 0 {
+				products[i].EffectivePrice = &quote.UnitPrice
+				products[i].Promotions = quote.Promotions
+			}
+		}
+	}
+	return products
+}
+
+/*
+The Modify method atomically reads, changes and stores a product (see Repository.Modify). It is the
+way for other subsystems, such as stock adjustments, to change a product through the service.
+*/
+func (s *ServiceImpl) Modify(id int, change func(product *domain.Product) error, records ...func() error) (domain.Product, error) {
+	updatedProduct, err := s.repository.Modify(id, change, records...)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	s.checkStock(updatedProduct)
+	return s.present([]domain.Product{updatedProduct})[0], nil
+}
+
+/*
+The ModifyAll method atomically changes several products at once (see Repository.ModifyAll). Only
+the products whose stock level the change touched are handed to the stock alerter.
+*/
+func (s *ServiceImpl) ModifyAll(change func(products []domain.Product) error, records ...func() error) ([]domain.Product, error) {
+	levels := map[int]stockLevel{}
+	updatedProducts, err := s.repository.ModifyAll(func(products []domain.Product) error {
+		for _, product := range products {
+			levels[product.Id] = levelOf(product)
+		}
+		return change(products)
+	}, records...)
+	if err != nil {
+		return nil, err
+	}
+	for _, updatedProduct := range updatedProducts {
+		if level, ok := levels[updatedProduct.Id]; !ok || level != levelOf(updatedProduct) {
+			s.checkStock(updatedProduct)
+		}
+	}
+	return s.present(updatedProducts), nil
+}
+
+// The stockLevel struct holds what the stock alerter looks at in a product.
+type stockLevel struct {
+	quantity     int
+	reorderPoint int
+}
+
+// Auxiliary function that returns the stock level of a product.
+func levelOf(product domain.Product) stockLevel {
+	return stockLevel{quantity: product.Quantity, reorderPoint: product.ReorderPoint}
+}
+
+/*
+The Available method returns the units of a product that can be sold: its quantity minus the units
+held, such as by reservations, never negative. Units held for the bundles among the given products
+count against their components, times the units of the component in the bundle. Bundles hold no
+stock of their own, so their available units come from their components when they are presented.
+Without products the catalog is read, so callers inside a change of the repository must give them.
+*/
+func (s *ServiceImpl) Available(product domain.Product, products []domain.Product) int {
+	if products == nil {
+		products = s.repository.GetAll()
+	}
+	return clampAvailable(product.Quantity - s.held(product, products))
+}
+
+/*
+The AvailableAt method returns the units of a product kept at a location that can be sold: the units
+at the location minus the units held there, directly or through the bundles among the given products,
+never negative. Units held without a location are left to Available. Products are given like in
+Available.
+*/
+func (s *ServiceImpl) AvailableAt(product domain.Product, location string, products []domain.Product) int {
+	if products == nil {
+		products = s.repository.GetAll()
+	}
+	available := product.QuantityAt(location)
+	for _, hold := range s.holds {
+		if hold, ok := hold.(LocationHoldCounter); ok {
+			available -= hold.HeldQuantityAt(product, location)
+			for _, bundle := range products {
+				if units := bundle.ComponentQuantity(product.Id); units > 0 {
+					available -= hold.HeldQuantityAt(bundle, location) * units
+				}
+			}
+		}
+	}
+	return clampAvailable(available)
+}
+
+// Auxiliary function that returns the units of a product held by all the hold counters, directly or through the given bundles.
+func (s *ServiceImpl) held(product domain.Product, products []domain.Product) int {
+	held := 0
+	for _, hold := range s.holds {
+		held += hold.HeldQuantity(product)
+		for _, bundle := range products {
+			if units := bundle.ComponentQuantity(product.Id); units > 0 {
+				held += hold.HeldQuantity(bundle) * units
+			}
+		}
+	}
+	return held
+}
+
+// Auxiliary function that turns a negative available quantity, such as that of a backordered product, into zero.
+func clampAvailable(quantity int) int {
+	if quantity < 0 {
+		return 0
+	}
+	return quantity
+}
+
+/*
+Auxiliary function that returns the quantity and the available quantity of a bundle: how many whole
+bundles the quantities and the available quantities of its components make up. Units held for the
+bundle itself are held from its components, so they are not available either.
+*/
+func (s *ServiceImpl) bundleQuantities(bundle domain.Product, products []domain.Product) (int, int) {
+	quantity, available := -1, -1
+	for _, component := range bundle.Components {
+		// Backordered components have a negative quantity, which makes up no bundles
+		componentQuantity, componentAvailable := 0, 0
+		if i := indexOf(products, component.ProductId); i >= 0 && products[i].Quantity > 0 {
+			componentQuantity = products[i].Quantity
+			componentAvailable = s.Available(products[i], products)
+		}
+		if sets := componentQuantity / component.Quantity; quantity < 0 || sets < quantity {
+			quantity = sets
+		}
+		if sets := componentAvailable / component.Quantity; available < 0 || sets < available {
+			available = sets
+		}
+	}
+	return quantity, clampAvailable(available)
+}
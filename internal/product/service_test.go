@@ -0,0 +1,244 @@
+package product
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/price"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_ApplySchedule_Publication(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	publishAt := now.Add(-time.Minute)
+	products := []domain.Product{
+		{Id: 1, CodeValue: "DRAFT1", Status: domain.StatusDraft, PublishAt: &publishAt, Price: domain.NewMoney(domain.NewDecimal(10), "")},
+		{Id: 2, CodeValue: "ARCHIVED1", Status: domain.StatusArchived, PublishAt: &publishAt, Price: domain.NewMoney(domain.NewDecimal(10), "")},
+	}
+	repository := NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	service := NewService(repository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil)
+
+	// A draft cannot be published before being reviewed, so its publication waits, and archived products are never published
+	changed, err := service.ApplySchedule(now)
+	assert.Nil(t, err)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, domain.StatusArchived, changed[0].Status)
+	assert.Nil(t, changed[0].PublishAt)
+	draft, _ := service.GetById(1)
+	assert.Equal(t, domain.StatusDraft, draft.Status)
+	assert.NotNil(t, draft.PublishAt)
+
+	// Once reviewed, the next run publishes it
+	_, err = service.Transition(1, domain.StatusInReview)
+	assert.Nil(t, err)
+	changed, err = service.ApplySchedule(now)
+	assert.Nil(t, err)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, domain.StatusPublished, changed[0].Status)
+	assert.True(t, changed[0].IsPublished)
+}
+
+func TestService_Update(t *testing.T) {
+	dir := t.TempDir()
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 10, AllowBackorder: true, Status: domain.StatusPublished, IsPublished: true, Price: domain.NewMoney(domain.NewDecimal(10), "EUR"), Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	repository := NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	service := NewService(repository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil)
+
+	// Fields missing from the request are kept, and a price without a currency keeps the product one
+	price, published := domain.NewDecimal(12), true
+	updated, err := service.Update(1, domain.ProductRequest{Name: "Milk", Quantity: 10, IsPublished: &published, Price: &price}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "Milk", updated.Name)
+	assert.True(t, updated.AllowBackorder)
+	assert.Equal(t, "EUR", updated.Price.Currency)
+	assert.Equal(t, 10, *updated.AvailableQuantity)
+
+	// The available quantity is computed when products are read, so it is not stored
+	stored, err := os.ReadFile(filepath.Join(dir, "products.json"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(stored), "available_quantity")
+
+	// The quantity only changes through stock movements
+	_, err = service.Update(1, domain.ProductRequest{Quantity: 20, IsPublished: &published}, "")
+	assert.ErrorIs(t, err, ErrStockManaged)
+
+	// A change whose records cannot be written is not kept
+	errLedger := errors.New("ledger unavailable")
+	_, err = repository.Modify(1, func(p *domain.Product) error {
+		p.Quantity = 0
+		return nil
+	}, func() error { return errLedger })
+	assert.ErrorIs(t, err, errLedger)
+	current, _ := service.GetById(1)
+	assert.Equal(t, 10, current.Quantity)
+}
+
+// The alerterStub struct records the products handed to the stock alerter.
+type alerterStub struct {
+	checked []int
+}
+
+func (a *alerterStub) Check(product domain.Product) {
+	a.checked = append(a.checked, product.Id)
+}
+
+func TestService_ModifyAll_Alerts(t *testing.T) {
+	dir := t.TempDir()
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 10, ReorderPoint: 5, Price: domain.NewMoney(domain.NewDecimal(10), "EUR")},
+		{Id: 2, CodeValue: "MILK2", Quantity: 10, ReorderPoint: 5, Price: domain.NewMoney(domain.NewDecimal(10), "EUR")},
+	}
+	repository := NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	alerter := &alerterStub{}
+	service := NewService(repository, time.UTC, nil, alerter, nil, nil, domain.TaxTable{}, nil)
+
+	// Only the products whose stock level changed are checked
+	_, err := service.ModifyAll(func(products []domain.Product) error {
+		products[0].Name = "Milk"
+		products[1].Quantity = 2
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, alerter.checked)
+}
+
+// relabelConverter is a price converter that keeps the amounts and only changes their currency.
+type relabelConverter struct{}
+
+func (relabelConverter) Convert(amount domain.Money, currency string) (domain.Money, error) {
+	return domain.NewMoney(amount.Amount, currency), nil
+}
+
+func TestService_ConvertPrices(t *testing.T) {
+	dir := t.TempDir()
+	repository := NewRepository(nil, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	service := NewService(repository, time.UTC, relabelConverter{}, nil, nil, nil, domain.TaxTable{}, nil)
+
+	products := []domain.Product{{
+		Id:         1,
+		Price:      domain.NewMoney(domain.NewDecimal(10), "EUR"),
+		Promotions: []domain.AppliedPromotion{{Id: 1, Name: "1 EUR off", Type: domain.PromotionFixed, Discount: domain.NewMoney(domain.NewDecimal(1), "EUR")}},
+	}}
+
+	// The converted products do not share their promotions with the given ones
+	converted, err := service.ConvertPrices(products, "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", converted[0].Promotions[0].Discount.Currency)
+	assert.Equal(t, "EUR", products[0].Promotions[0].Discount.Currency)
+}
+
+func TestService_ApplySchedule_Prices(t *testing.T) {
+	dir := t.TempDir()
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Price: domain.NewMoney(domain.NewDecimal(10), ""), Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	repository := NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	history := price.NewHistory(nil, store.NewJsonLinesStore[domain.PriceChange](filepath.Join(dir, "price_history.jsonl")))
+	service := NewService(repository, time.UTC, nil, nil, nil, history, domain.TaxTable{}, nil)
+
+	// Two prices scheduled for the next hours, in any order
+	now := time.Now()
+	for _, scheduled := range []struct {
+		price string
+		hours int
+	}{{"12", 2}, {"11", 1}} {
+		effectiveFrom := now.Add(time.Duration(scheduled.hours) * time.Hour)
+		_, err := service.SetPrice(1, domain.PriceRequest{Price: domain.MustParseDecimal(scheduled.price), EffectiveFrom: &effectiveFrom}, "bob")
+		assert.Nil(t, err)
+	}
+
+	// Nothing is due yet
+	changed, err := service.ApplySchedule(now)
+	assert.Nil(t, err)
+	assert.Len(t, changed, 0)
+
+	// The first price is applied once it is due, and recorded on behalf of who scheduled it
+	changed, err = service.ApplySchedule(now.Add(90 * time.Minute))
+	assert.Nil(t, err)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, "11", changed[0].Price.Amount.String())
+	assert.Len(t, changed[0].ScheduledPrices, 1)
+
+	// A late run applies only the latest due price
+	effectiveFrom := now.Add(3 * time.Hour)
+	_, err = service.SetPrice(1, domain.PriceRequest{Price: domain.NewDecimal(13), EffectiveFrom: &effectiveFrom}, "bob")
+	assert.Nil(t, err)
+	changed, err = service.ApplySchedule(now.Add(4 * time.Hour))
+	assert.Nil(t, err)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, "13", changed[0].Price.Amount.String())
+	assert.Empty(t, changed[0].ScheduledPrices)
+
+	changes := history.GetByProduct(1)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, domain.PriceScheduled, changes[0].Reason)
+	assert.Equal(t, "bob", changes[0].Actor)
+	assert.Equal(t, "11", changes[1].Previous.Amount.String())
+}
+
+// failingHistory is a price history that cannot record anything.
+type failingHistory struct{}
+
+func (failingHistory) Record(domain.PriceChange) error {
+	return errHistory
+}
+
+func (failingHistory) GetByProduct(int) []domain.PriceChange {
+	return nil
+}
+
+var errHistory = errors.New("price history unavailable")
+
+func TestService_SetPrice(t *testing.T) {
+	dir := t.TempDir()
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Price: domain.NewMoney(domain.NewDecimal(10), "EUR"), Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	repository := NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	history := price.NewHistory(nil, store.NewJsonLinesStore[domain.PriceChange](filepath.Join(dir, "price_history.jsonl")))
+	service := NewService(repository, time.UTC, nil, nil, nil, history, domain.TaxTable{}, nil)
+
+	// A price without a currency keeps the product one
+	updated, err := service.SetPrice(1, domain.PriceRequest{Price: domain.NewDecimal(12)}, "alice")
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", updated.Price.Currency)
+	assert.Equal(t, "EUR", history.GetByProduct(1)[0].Price.Currency)
+
+	// A price change that cannot be recorded is not kept
+	service = NewService(repository, time.UTC, nil, nil, nil, failingHistory{}, domain.TaxTable{}, nil)
+	_, err = service.SetPrice(1, domain.PriceRequest{Price: domain.NewDecimal(15)}, "alice")
+	assert.ErrorIs(t, err, errHistory)
+	current, _ := service.GetById(1)
+	assert.Equal(t, "12", current.Price.Amount.String())
+}
+
+func TestService_Delete(t *testing.T) {
+	dir := t.TempDir()
+	expiration := domain.NewDate(2030, time.January, 1)
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 10, Expiration: expiration},
+		{Id: 2, CodeValue: "MILK2", Expiration: expiration, Components: []domain.BundleComponent{{ProductId: 1, Quantity: 2}}},
+		{Id: 3, CodeValue: "CHEESE1", Quantity: 5, Expiration: expiration},
+	}
+	repository := NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	service := NewService(repository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil)
+
+	// Components of a bundle cannot be deleted
+	assert.ErrorIs(t, service.Delete(1), ErrInBundle)
+	assert.ErrorIs(t, service.Delete(4), ErrNotFound)
+	assert.Nil(t, service.Delete(2))
+	assert.Nil(t, service.Delete(1))
+
+	// New products never take the ID of a deleted one
+	created, err := repository.Create(domain.Product{CodeValue: "BREAD1", Expiration: expiration})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, created.Id)
+}
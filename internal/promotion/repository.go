@@ -0,0 +1,122 @@
+package promotion
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("promotion not found")
+
+// Repository is the interface definition for the promotion storage
+type Repository interface {
+	GetById(id int) (domain.Promotion, error)
+	GetAll() []domain.Promotion
+	Create(promotion domain.Promotion) (domain.Promotion, error)
+	Update(promotion domain.Promotion) (domain.Promotion, error)
+	Delete(id int) error
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu         sync.RWMutex
+	promotions []domain.Promotion
+	store      store.DocumentStore[[]domain.Promotion]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+promotions and saves every change in the provided store.
+*/
+func NewRepository(promotions []domain.Promotion, promotionStore store.DocumentStore[[]domain.Promotion]) Repository {
+	return &RepositoryImpl{
+		promotions: promotions,
+		store:      promotionStore,
+	}
+}
+
+// The GetById method returns a promotion by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, promotion := range r.promotions {
+		if promotion.Id == id {
+			return promotion, nil
+		}
+	}
+	return domain.Promotion{}, ErrNotFound
+}
+
+// The GetAll method returns all the promotions
+func (r *RepositoryImpl) GetAll() []domain.Promotion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Promotion{}, r.promotions...)
+}
+
+// The Create method stores a new promotion with the next available ID and returns it.
+func (r *RepositoryImpl) Create(promotion domain.Promotion) (domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// IDs are not reused after a deletion
+	promotion.Id = 1
+	for _, existing := range r.promotions {
+		if existing.Id >= promotion.Id {
+			promotion.Id = existing.Id + 1
+		}
+	}
+	if err := r.save(append(r.copyList(), promotion)); err != nil {
+		return domain.Promotion{}, err
+	}
+	return promotion, nil
+}
+
+// The Update method replaces a stored promotion with the same ID.
+func (r *RepositoryImpl) Update(promotion domain.Promotion) (domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.promotions {
+		if r.promotions[i].Id == promotion.Id {
+			promotions := r.copyList()
+			promotions[i] = promotion
+			if err := r.save(promotions); err != nil {
+				return domain.Promotion{}, err
+			}
+			return promotion, nil
+		}
+	}
+	return domain.Promotion{}, ErrNotFound
+}
+
+// The Delete method removes a stored promotion.
+func (r *RepositoryImpl) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.promotions {
+		if r.promotions[i].Id == id {
+			promotions := append(r.copyList()[:i], r.promotions[i+1:]...)
+			return r.save(promotions)
+		}
+	}
+	return ErrNotFound
+}
+
+// Auxiliary function that returns a copy of the promotion list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.Promotion {
+	return append([]domain.Promotion{}, r.promotions...)
+}
+
+// Auxiliary function that saves the promotion list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(promotions []domain.Promotion) error {
+	if err := r.store.Save(promotions); err != nil {
+		return err
+	}
+	r.promotions = promotions
+	return nil
+}
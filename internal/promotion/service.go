@@ -0,0 +1,228 @@
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+)
+
+var ErrInvalidPromotion = errors.New("invalid promotion")
+
+// pricePlaces is the number of decimal places discounts and effective prices are rounded to.
+const pricePlaces = 2
+
+// PriceConverter converts the fixed discounts to the currency of the products they apply to.
+type PriceConverter interface {
+	Convert(amount domain.Money, currency string) (domain.Money, error)
+}
+
+// CategoryTree returns a category followed by its ancestors, to find the promotions of a category.
+type CategoryTree interface {
+	Ancestors(id int) []int
+}
+
+type Service interface {
+	GetAll() []domain.Promotion
+	GetById(id int) (domain.Promotion, error)
+	Create(promotion domain.Promotion) (domain.Promotion, error)
+	Delete(id int) error
+	Quote(product domain.Product, quantity int, now time.Time) domain.PriceQuote
+}
+
+type ServiceImpl struct {
+	repository Repository
+	location   *time.Location
+	converter  PriceConverter
+	categories CategoryTree
+}
+
+/*
+The NewService function returns a new instance of the service. The location is the catalog time
+zone, used to count the days until a product expires for expiration-based promotions. The converter
+(optional) converts fixed discounts to the currency of every product, and the category tree
+(optional) finds the promotions of the categories of every product.
+*/
+func NewService(repository Repository, location *time.Location, converter PriceConverter, categories CategoryTree) Service {
+	return &ServiceImpl{
+		repository: repository,
+		location:   location,
+		converter:  converter,
+		categories: categories,
+	}
+}
+
+// The GetAll method returns all the promotions
+func (s *ServiceImpl) GetAll() []domain.Promotion {
+	return s.repository.GetAll()
+}
+
+// The GetById method returns a promotion by its ID
+func (s *ServiceImpl) GetById(id int) (domain.Promotion, error) {
+	return s.repository.GetById(id)
+}
+
+/*
+The Create method validates and stores a new promotion. Fixed discounts without a currency are in
+DefaultCurrency, and the currency and the categories of a promotion must be known.
+*/
+func (s *ServiceImpl) Create(promotion domain.Promotion) (domain.Promotion, error) {
+	if err := validate(promotion); err != nil {
+		return domain.Promotion{}, err
+	}
+	if promotion.Type == domain.PromotionFixed {
+		promotion.Currency = promotion.FixedDiscount().Currency
+		if s.converter != nil {
+			if _, err := s.converter.Convert(promotion.FixedDiscount(), promotion.Currency); err != nil {
+				return domain.Promotion{}, fmt.Errorf("%w: %s", ErrInvalidPromotion, err)
+			}
+		}
+	}
+	if s.categories != nil {
+		for _, id := range promotion.CategoryIds {
+			if len(s.categories.Ancestors(id)) == 0 {
+				return domain.Promotion{}, fmt.Errorf("%w: unknown category %d", ErrInvalidPromotion, id)
+			}
+		}
+	}
+	return s.repository.Create(promotion)
+}
+
+// The Delete method removes a promotion.
+func (s *ServiceImpl) Delete(id int) error {
+	return s.repository.Delete(id)
+}
+
+/*
+The Quote method prices some units of a product after the promotions that apply to it at the given
+instant. Promotions apply from the highest priority down (the oldest first on a tie), each on the
+price left by the previous ones. If the first one is not stackable, it is the only one applied;
+otherwise only the stackable ones are.
+*/
+func (s *ServiceImpl) Quote(product domain.Product, quantity int, now time.Time) domain.PriceQuote {
+	total := product.Price.MulInt(int64(quantity))
+	quote := domain.PriceQuote{UnitPrice: product.Price, Total: total}
+	if quantity <= 0 {
+		return quote
+	}
+
+	promotions := s.applicable(product, now)
+	for i, promotion := range promotions {
+		if i > 0 && (!promotions[0].Stackable || !promotion.Stackable) {
+			continue
+		}
+
+		discount, ok := s.discountOf(promotion, total, quantity)
+		if !ok {
+			continue
+		}
+		if discount.GreaterThan(total.Amount) {
+			discount = total.Amount
+		}
+		if !discount.IsPositive() {
+			continue
+		}
+
+		total.Amount = total.Amount.Sub(discount)
+		quote.Promotions = append(quote.Promotions, domain.AppliedPromotion{
+			Id:       promotion.Id,
+			Name:     promotion.Name,
+			Type:     promotion.Type,
+			Discount: domain.NewMoney(discount, total.Currency),
+		})
+	}
+
+	unitPrice, _ := total.Amount.Div(domain.NewDecimal(int64(quantity)))
+	quote.UnitPrice = domain.NewMoney(unitPrice.Round(pricePlaces), total.Currency)
+	quote.Total = total
+	return quote
+}
+
+// Auxiliary function that returns the promotions that apply to a product, in the order they apply.
+func (s *ServiceImpl) applicable(product domain.Product, now time.Time) []domain.Promotion {
+	today := domain.Today(s.location)
+	var categories []int
+	if s.categories != nil && product.CategoryId != 0 {
+		categories = s.categories.Ancestors(product.CategoryId)
+	}
+
+	var promotions []domain.Promotion
+	for _, promotion := range s.repository.GetAll() {
+		if !promotion.IsActiveAt(now) || !promotion.Targets(product, categories) {
+			continue
+		}
+		if promotion.Type == domain.PromotionExpiration {
+			days := today.DaysUntil(product.Expiration)
+			if product.Expiration.IsZero() || days < 0 || days > promotion.WithinDays {
+				continue
+			}
+		}
+		promotions = append(promotions, promotion)
+	}
+
+	sort.SliceStable(promotions, func(i, j int) bool {
+		return promotions[i].Priority > promotions[j].Priority
+	})
+	return promotions
+}
+
+/*
+Auxiliary function that returns the discount a promotion gives on the total price of some units. A
+fixed discount is converted to the currency of the price, and gives none (false) if it cannot be.
+*/
+func (s *ServiceImpl) discountOf(promotion domain.Promotion, total domain.Money, quantity int) (domain.Decimal, bool) {
+	var discount domain.Decimal
+	switch promotion.Type {
+	case domain.PromotionPercentage, domain.PromotionExpiration:
+		discount, _ = total.Amount.Mul(promotion.Value).Div(domain.NewDecimal(100))
+	case domain.PromotionFixed:
+		unitDiscount := promotion.FixedDiscount()
+		if unitDiscount.Currency != total.Currency {
+			if s.converter == nil {
+				return domain.Decimal{}, false
+			}
+			converted, err := s.converter.Convert(unitDiscount, total.Currency)
+			if err != nil {
+				return domain.Decimal{}, false
+			}
+			unitDiscount = converted
+		}
+		discount = unitDiscount.Amount.MulInt(int64(quantity))
+	case domain.PromotionQuantity:
+		free := quantity / promotion.BuyQuantity * (promotion.BuyQuantity - promotion.PayQuantity)
+		discount, _ = total.Amount.MulInt(int64(free)).Div(domain.NewDecimal(int64(quantity)))
+	}
+	return discount.Round(pricePlaces), true
+}
+
+// Auxiliary function that checks the rule of a promotion and its validity window.
+func validate(promotion domain.Promotion) error {
+	hundred := domain.NewDecimal(100)
+	switch promotion.Type {
+	case domain.PromotionPercentage:
+		if !promotion.Value.IsPositive() || promotion.Value.GreaterThan(hundred) {
+			return fmt.Errorf("%w: percentage must be between 0 and 100", ErrInvalidPromotion)
+		}
+	case domain.PromotionFixed:
+		if !promotion.Value.IsPositive() {
+			return fmt.Errorf("%w: fixed discount must be greater than zero", ErrInvalidPromotion)
+		}
+	case domain.PromotionQuantity:
+		if promotion.PayQuantity < 1 || promotion.BuyQuantity <= promotion.PayQuantity {
+			return fmt.Errorf("%w: buy quantity must be greater than pay quantity, which must be at least 1", ErrInvalidPromotion)
+		}
+	case domain.PromotionExpiration:
+		if !promotion.Value.IsPositive() || promotion.Value.GreaterThan(hundred) || promotion.WithinDays < 0 {
+			return fmt.Errorf("%w: percentage must be between 0 and 100 and days must not be negative", ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: unknown promotion type", ErrInvalidPromotion)
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return fmt.Errorf("%w: end must be after start", ErrInvalidPromotion)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package promotion
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Quote(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	today := domain.Today(time.UTC)
+
+	percentage := func(id int, value int64, priority int, stackable bool) domain.Promotion {
+		return domain.Promotion{Id: id, Name: "percentage", Type: domain.PromotionPercentage, Value: domain.NewDecimal(value), Priority: priority, Stackable: stackable}
+	}
+	fixed := domain.Promotion{Id: 2, Name: "1 USD off", Type: domain.PromotionFixed, Value: domain.NewDecimal(1), Currency: "USD", Priority: 5, Stackable: true}
+	nearExpiry := domain.Promotion{Id: 3, Name: "30% off near expiry", Type: domain.PromotionExpiration, Value: domain.NewDecimal(30), WithinDays: 3, Stackable: true}
+
+	tests := []struct {
+		name       string
+		promotions []domain.Promotion
+		expiration domain.Date
+		quantity   int
+		total      string
+		applied    []int
+	}{
+		{"No promotions", nil, today.AddDays(30), 2, "20", nil},
+		{"Stackable promotions apply from the highest priority down", []domain.Promotion{percentage(1, 10, 1, true), fixed}, today.AddDays(30), 2, "16.2", []int{2, 1}},
+		{"Priority ties apply the oldest first", []domain.Promotion{percentage(1, 10, 5, true), fixed}, today.AddDays(30), 2, "16", []int{1, 2}},
+		{"A first promotion that is not stackable applies alone", []domain.Promotion{percentage(1, 50, 10, false), fixed}, today.AddDays(30), 2, "10", []int{1}},
+		{"Promotions that are not stackable are left out after the first", []domain.Promotion{percentage(1, 50, 1, false), fixed}, today.AddDays(30), 2, "18", []int{2}},
+		{"Buy 3 pay 2", []domain.Promotion{{Id: 4, Name: "3x2", Type: domain.PromotionQuantity, BuyQuantity: 3, PayQuantity: 2}}, today.AddDays(30), 4, "30", []int{4}},
+		{"Not started yet", []domain.Promotion{{Id: 1, Name: "percentage", Type: domain.PromotionPercentage, Value: domain.NewDecimal(10), StartsAt: &later}}, today.AddDays(30), 2, "20", nil},
+		{"Already ended", []domain.Promotion{{Id: 1, Name: "percentage", Type: domain.PromotionPercentage, Value: domain.NewDecimal(10), StartsAt: &earlier, EndsAt: &now}}, today.AddDays(30), 2, "20", nil},
+		{"Inside its window", []domain.Promotion{{Id: 1, Name: "percentage", Type: domain.PromotionPercentage, Value: domain.NewDecimal(10), StartsAt: &earlier, EndsAt: &later}}, today.AddDays(30), 2, "18", []int{1}},
+		{"Expiring within the days", []domain.Promotion{nearExpiry}, today.AddDays(3), 2, "14", []int{3}},
+		{"Expiring today", []domain.Promotion{nearExpiry}, today, 2, "14", []int{3}},
+		{"Expiring after the days", []domain.Promotion{nearExpiry}, today.AddDays(4), 2, "20", nil},
+		{"Already expired", []domain.Promotion{nearExpiry}, today.AddDays(-1), 2, "20", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := NewRepository(test.promotions, store.NewJsonDocumentStore[[]domain.Promotion](filepath.Join(t.TempDir(), "promotions.json")))
+			service := NewService(repository, time.UTC, nil, nil)
+			product := domain.Product{Id: 1, CodeValue: "MILK1", Price: domain.NewMoney(domain.NewDecimal(10), "USD"), Expiration: test.expiration}
+
+			quote := service.Quote(product, test.quantity, now)
+			assert.Equal(t, test.total, quote.Total.Amount.String())
+			var applied []int
+			for _, promotion := range quote.Promotions {
+				applied = append(applied, promotion.Id)
+			}
+			assert.Equal(t, test.applied, applied)
+		})
+	}
+}
+
+func TestService_Create(t *testing.T) {
+	repository := NewRepository(nil, store.NewJsonDocumentStore[[]domain.Promotion](filepath.Join(t.TempDir(), "promotions.json")))
+	service := NewService(repository, time.UTC, nil, nil)
+	now := time.Now()
+
+	for _, promotion := range []domain.Promotion{
+		{Name: "percentage", Type: domain.PromotionPercentage, Value: domain.NewDecimal(101)},
+		{Name: "fixed", Type: domain.PromotionFixed},
+		{Name: "3x3", Type: domain.PromotionQuantity, BuyQuantity: 3, PayQuantity: 3},
+		{Name: "expiration", Type: domain.PromotionExpiration, Value: domain.NewDecimal(30), WithinDays: -1},
+		{Name: "empty window", Type: domain.PromotionPercentage, Value: domain.NewDecimal(10), StartsAt: &now, EndsAt: &now},
+		{Name: "unknown", Type: "gift"},
+	} {
+		_, err := service.Create(promotion)
+		assert.ErrorIs(t, err, ErrInvalidPromotion, promotion.Name)
+	}
+	assert.Empty(t, service.GetAll())
+}
@@ -0,0 +1,101 @@
+package purchase
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("purchase order not found")
+
+// Repository is the interface definition for the purchase order storage
+type Repository interface {
+	GetById(id int) (domain.PurchaseOrder, error)
+	GetAll() []domain.PurchaseOrder
+	Create(order domain.PurchaseOrder) (domain.PurchaseOrder, error)
+	Update(order domain.PurchaseOrder) (domain.PurchaseOrder, error)
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu     sync.RWMutex
+	orders []domain.PurchaseOrder
+	store  store.DocumentStore[[]domain.PurchaseOrder]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+orders and saves every change in the provided store.
+*/
+func NewRepository(orders []domain.PurchaseOrder, orderStore store.DocumentStore[[]domain.PurchaseOrder]) Repository {
+	return &RepositoryImpl{
+		orders: orders,
+		store:  orderStore,
+	}
+}
+
+// The GetById method returns a purchase order by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.PurchaseOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, order := range r.orders {
+		if order.Id == id {
+			return order, nil
+		}
+	}
+	return domain.PurchaseOrder{}, ErrNotFound
+}
+
+// The GetAll method returns all the purchase orders
+func (r *RepositoryImpl) GetAll() []domain.PurchaseOrder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.PurchaseOrder{}, r.orders...)
+}
+
+// The Create method stores a new order with the next available ID and returns it.
+func (r *RepositoryImpl) Create(order domain.PurchaseOrder) (domain.PurchaseOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order.Id = len(r.orders) + 1
+	if err := r.save(append(r.copyList(), order)); err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	return order, nil
+}
+
+// The Update method replaces a stored order with the same ID.
+func (r *RepositoryImpl) Update(order domain.PurchaseOrder) (domain.PurchaseOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.orders {
+		if r.orders[i].Id == order.Id {
+			orders := r.copyList()
+			orders[i] = order
+			if err := r.save(orders); err != nil {
+				return domain.PurchaseOrder{}, err
+			}
+			return order, nil
+		}
+	}
+	return domain.PurchaseOrder{}, ErrNotFound
+}
+
+// Auxiliary function that returns a copy of the order list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.PurchaseOrder {
+	return append([]domain.PurchaseOrder{}, r.orders...)
+}
+
+// Auxiliary function that saves the order list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(orders []domain.PurchaseOrder) error {
+	if err := r.store.Save(orders); err != nil {
+		return err
+	}
+	r.orders = orders
+	return nil
+}
@@ -0,0 +1,211 @@
+package purchase
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/internal/supplier"
+)
+
+var ErrIllegalStatus = errors.New("purchase order cannot be changed in its current status")
+
+type Service interface {
+	GetAll() []domain.PurchaseOrder
+	GetById(id int) (domain.PurchaseOrder, error)
+	Propose(now time.Time) ([]domain.PurchaseOrder, error)
+	Approve(id int) (domain.PurchaseOrder, error)
+	Receive(id int, request domain.ReceiveRequest) (domain.PurchaseOrder, error)
+	Cancel(id int) (domain.PurchaseOrder, error)
+}
+
+type ServiceImpl struct {
+	mu         sync.Mutex
+	repository Repository
+	products   product.Repository
+	catalog    product.Service
+	suppliers  supplier.Repository
+	stock      stock.Service
+}
+
+/*
+The NewService function returns a new instance of the service. Products are read straight from their
+repository, so products outside their publication window are restocked too, and the catalog tells
+how many of their units are available. Received orders are added to stock through the stock service.
+*/
+func NewService(repository Repository, products product.Repository, catalog product.Service, suppliers supplier.Repository, stockService stock.Service) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+		catalog:    catalog,
+		suppliers:  suppliers,
+		stock:      stockService,
+	}
+}
+
+// The GetAll method returns all the purchase orders
+func (s *ServiceImpl) GetAll() []domain.PurchaseOrder {
+	return s.repository.GetAll()
+}
+
+// The GetById method returns a purchase order by its ID
+func (s *ServiceImpl) GetById(id int) (domain.PurchaseOrder, error) {
+	return s.repository.GetById(id)
+}
+
+/*
+The Propose method creates a proposed purchase order per supplier for every product whose available
+units (the ones not held, such as by reservations) are below its reorder point, ordering enough
+units to reach twice the reorder point. Products without a supplier, products already in an open
+purchase order and bundles, which are restocked through their components, are left out. It returns
+the new orders.
+*/
+func (s *ServiceImpl) Propose(now time.Time) ([]domain.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Products already ordered are not ordered again
+	ordered := map[int]bool{}
+	for _, order := range s.repository.GetAll() {
+		if order.IsOpen() {
+			for _, line := range order.Lines {
+				ordered[line.ProductId] = true
+			}
+		}
+	}
+
+	lines := map[int][]domain.PurchaseOrderLine{}
+	products := s.products.GetAll()
+	for _, p := range products {
+		if p.ReorderPoint <= 0 || p.SupplierId == 0 || ordered[p.Id] || p.IsBundle() {
+			continue
+		}
+		available := s.catalog.Available(p, products)
+		if available >= p.ReorderPoint {
+			continue
+		}
+		if _, err := s.suppliers.GetById(p.SupplierId); err != nil {
+			continue
+		}
+		lines[p.SupplierId] = append(lines[p.SupplierId], domain.PurchaseOrderLine{
+			ProductId: p.Id,
+			CodeValue: p.CodeValue,
+			Quantity:  2*p.ReorderPoint - available,
+		})
+	}
+
+	// Create the orders in supplier order, so the result does not depend on map iteration
+	supplierIds := make([]int, 0, len(lines))
+	for supplierId := range lines {
+		supplierIds = append(supplierIds, supplierId)
+	}
+	sort.Ints(supplierIds)
+
+	orders := []domain.PurchaseOrder{}
+	for _, supplierId := range supplierIds {
+		order, err := s.repository.Create(domain.PurchaseOrder{
+			SupplierId: supplierId,
+			Status:     domain.PurchaseOrderProposed,
+			Lines:      lines[supplierId],
+			CreatedAt:  now.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// The Approve method approves a proposed purchase order, expecting it after the supplier lead time.
+func (s *ServiceImpl) Approve(id int) (domain.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderIn(id, domain.PurchaseOrderProposed)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	supplierData, err := s.suppliers.GetById(order.SupplierId)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+
+	now := time.Now().UTC()
+	expected := now.AddDate(0, 0, supplierData.LeadTimeDays)
+	order.Status = domain.PurchaseOrderApproved
+	order.ApprovedAt = &now
+	order.ExpectedAt = &expected
+	return s.repository.Update(order)
+}
+
+/*
+The Receive method adds the units of an approved purchase order to stock, in a single atomic
+operation, and closes the order. The request tells the location and, for lot-tracked products,
+the lot the units are received in. The units are recorded with the order reference, so if closing
+the order fails, receiving it again only closes it.
+*/
+func (s *ServiceImpl) Receive(id int, request domain.ReceiveRequest) (domain.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderIn(id, domain.PurchaseOrderApproved)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+
+	receipts := make([]domain.StockReceipt, len(order.Lines))
+	for i, line := range order.Lines {
+		receipts[i] = domain.StockReceipt{ProductId: line.ProductId, Quantity: line.Quantity, Location: request.Location}
+		for _, lot := range request.Lots {
+			if lot.ProductId == line.ProductId {
+				receipts[i].Lot = lot.Code
+				receipts[i].Expiration = lot.Expiration
+			}
+		}
+	}
+	_, err = s.stock.Receive(receipts, fmt.Sprintf("po-%d", order.Id))
+	if err != nil && !errors.Is(err, stock.ErrReferenceUsed) {
+		return domain.PurchaseOrder{}, err
+	}
+
+	now := time.Now().UTC()
+	order.Status = domain.PurchaseOrderReceived
+	order.ClosedAt = &now
+	return s.repository.Update(order)
+}
+
+// The Cancel method cancels a purchase order that has not been received.
+func (s *ServiceImpl) Cancel(id int) (domain.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderIn(id, domain.PurchaseOrderProposed, domain.PurchaseOrderApproved)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+
+	now := time.Now().UTC()
+	order.Status = domain.PurchaseOrderCancelled
+	order.ClosedAt = &now
+	return s.repository.Update(order)
+}
+
+// Auxiliary function that returns a purchase order if it is in one of the given statuses.
+func (s *ServiceImpl) orderIn(id int, statuses ...domain.PurchaseOrderStatus) (domain.PurchaseOrder, error) {
+	order, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	for _, status := range statuses {
+		if order.Status == status {
+			return order, nil
+		}
+	}
+	return domain.PurchaseOrder{}, ErrIllegalStatus
+}
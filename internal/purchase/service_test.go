@@ -0,0 +1,81 @@
+package purchase
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/internal/supplier"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createServiceForTest(t *testing.T) (Service, product.Repository) {
+	dir := t.TempDir()
+
+	// Milk below its reorder point, cheese above it, a pack of milk and bread from an unknown supplier
+	expiration := domain.NewDate(2030, time.January, 1)
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 3, ReorderPoint: 10, SupplierId: 1, Expiration: expiration},
+		{Id: 2, CodeValue: "CHEESE1", Quantity: 20, ReorderPoint: 10, SupplierId: 1, Expiration: expiration},
+		{Id: 3, CodeValue: "MILK2", ReorderPoint: 5, SupplierId: 1, Expiration: expiration, Components: []domain.BundleComponent{{ProductId: 1, Quantity: 2}}},
+		{Id: 4, CodeValue: "BREAD1", ReorderPoint: 5, SupplierId: 2, Expiration: expiration},
+	}
+	suppliers := []domain.Supplier{{Id: 1, Name: "Fresh Farms", LeadTimeDays: 7}}
+
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	productService := product.NewService(productRepository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil)
+	supplierRepository := supplier.NewRepository(suppliers, store.NewJsonDocumentStore[[]domain.Supplier](filepath.Join(dir, "suppliers.json")))
+	locationService := location.NewService(location.NewRepository(nil, store.NewJsonDocumentStore[[]domain.Location](filepath.Join(dir, "locations.json"))))
+	ledger := stock.NewLedger(nil, store.NewJsonLinesStore[domain.StockMovement](filepath.Join(dir, "stock_movements.jsonl")))
+	stockService := stock.NewService(productService, ledger, locationService, time.UTC)
+	repository := NewRepository(nil, store.NewJsonDocumentStore[[]domain.PurchaseOrder](filepath.Join(dir, "purchase_orders.json")))
+
+	return NewService(repository, productRepository, productService, supplierRepository, stockService), productRepository
+}
+
+func TestService_Propose(t *testing.T) {
+	service, _ := createServiceForTest(t)
+
+	// Only the milk is ordered, up to twice its reorder point, and only once
+	orders, err := service.Propose(time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, 1, orders[0].SupplierId)
+	assert.Equal(t, domain.PurchaseOrderProposed, orders[0].Status)
+	assert.Equal(t, []domain.PurchaseOrderLine{{ProductId: 1, CodeValue: "MILK1", Quantity: 17}}, orders[0].Lines)
+
+	orders, err = service.Propose(time.Now())
+	assert.Nil(t, err)
+	assert.Empty(t, orders)
+}
+
+func TestService_Receive(t *testing.T) {
+	service, productRepository := createServiceForTest(t)
+	orders, err := service.Propose(time.Now())
+	assert.Nil(t, err)
+	id := orders[0].Id
+
+	// Orders must be approved before they are received, and are received once
+	_, err = service.Receive(id, domain.ReceiveRequest{})
+	assert.ErrorIs(t, err, ErrIllegalStatus)
+
+	approved, err := service.Approve(id)
+	assert.Nil(t, err)
+	assert.Equal(t, approved.ApprovedAt.AddDate(0, 0, 7), *approved.ExpectedAt)
+
+	received, err := service.Receive(id, domain.ReceiveRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, domain.PurchaseOrderReceived, received.Status)
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, 20, milk.Quantity)
+
+	_, err = service.Receive(id, domain.ReceiveRequest{})
+	assert.ErrorIs(t, err, ErrIllegalStatus)
+	_, err = service.Cancel(id)
+	assert.ErrorIs(t, err, ErrIllegalStatus)
+}
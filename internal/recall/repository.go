@@ -0,0 +1,101 @@
+package recall
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("recall not found")
+
+// Repository is the interface definition for the recall storage
+type Repository interface {
+	GetById(id int) (domain.Recall, error)
+	GetAll() []domain.Recall
+	Create(recall domain.Recall) (domain.Recall, error)
+	Update(recall domain.Recall) (domain.Recall, error)
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu      sync.RWMutex
+	recalls []domain.Recall
+	store   store.DocumentStore[[]domain.Recall]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+recalls and saves every change in the provided store.
+*/
+func NewRepository(recalls []domain.Recall, recallStore store.DocumentStore[[]domain.Recall]) Repository {
+	return &RepositoryImpl{
+		recalls: recalls,
+		store:   recallStore,
+	}
+}
+
+// The GetById method returns a recall by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.Recall, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, recall := range r.recalls {
+		if recall.Id == id {
+			return recall, nil
+		}
+	}
+	return domain.Recall{}, ErrNotFound
+}
+
+// The GetAll method returns all the recalls
+func (r *RepositoryImpl) GetAll() []domain.Recall {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Recall{}, r.recalls...)
+}
+
+// The Create method stores a new recall with the next available ID and returns it.
+func (r *RepositoryImpl) Create(recall domain.Recall) (domain.Recall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recall.Id = len(r.recalls) + 1
+	if err := r.save(append(r.copyList(), recall)); err != nil {
+		return domain.Recall{}, err
+	}
+	return recall, nil
+}
+
+// The Update method replaces a stored recall with the same ID.
+func (r *RepositoryImpl) Update(recall domain.Recall) (domain.Recall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.recalls {
+		if r.recalls[i].Id == recall.Id {
+			recalls := r.copyList()
+			recalls[i] = recall
+			if err := r.save(recalls); err != nil {
+				return domain.Recall{}, err
+			}
+			return recall, nil
+		}
+	}
+	return domain.Recall{}, ErrNotFound
+}
+
+// Auxiliary function that returns a copy of the recall list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.Recall {
+	return append([]domain.Recall{}, r.recalls...)
+}
+
+// Auxiliary function that saves the recall list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(recalls []domain.Recall) error {
+	if err := r.store.Save(recalls); err != nil {
+		return err
+	}
+	r.recalls = recalls
+	return nil
+}
@@ -0,0 +1,169 @@
+package recall
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+)
+
+var (
+	ErrInvalidRange = errors.New("recall expiration range end must not be before its start")
+	ErrClosed       = errors.New("recall is already closed")
+)
+
+type Service interface {
+	GetAll() []domain.Recall
+	GetById(id int) (domain.Recall, error)
+	Create(request domain.RecallRequest) (domain.Recall, error)
+	Close(id int) (domain.Recall, error)
+	Report(id int) ([]domain.RecallItem, error)
+	HeldQuantity(product domain.Product) int
+	IsBlocked(product domain.Product, lot string) bool
+}
+
+type ServiceImpl struct {
+	repository Repository
+	products   product.Repository
+}
+
+/*
+The NewService function returns a new instance of the service. It reads the products straight from
+their repository, since the product service itself asks the recalls for the held quantity.
+*/
+func NewService(repository Repository, products product.Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+	}
+}
+
+// The GetAll method returns all the recalls
+func (s *ServiceImpl) GetAll() []domain.Recall {
+	return s.repository.GetAll()
+}
+
+// The GetById method returns a recall by its ID
+func (s *ServiceImpl) GetById(id int) (domain.Recall, error) {
+	return s.repository.GetById(id)
+}
+
+/*
+The Create method opens a new active recall. If no product has the recalled code value, or none of
+them has one of the recalled lots, it returns an error.
+*/
+func (s *ServiceImpl) Create(request domain.RecallRequest) (domain.Recall, error) {
+	if !request.ExpirationFrom.IsZero() && !request.ExpirationTo.IsZero() && request.ExpirationTo.Before(request.ExpirationFrom) {
+		return domain.Recall{}, ErrInvalidRange
+	}
+	if err := s.checkProduct(request.CodeValue, request.Lots); err != nil {
+		return domain.Recall{}, err
+	}
+
+	return s.repository.Create(domain.Recall{
+		CodeValue:      request.CodeValue,
+		Lots:           request.Lots,
+		ExpirationFrom: request.ExpirationFrom,
+		ExpirationTo:   request.ExpirationTo,
+		Reason:         request.Reason,
+		Status:         domain.RecallActive,
+		CreatedAt:      time.Now().UTC(),
+	})
+}
+
+// The Close method closes an active recall, releasing the stock it blocked.
+func (s *ServiceImpl) Close(id int) (domain.Recall, error) {
+	recall, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.Recall{}, err
+	}
+	if recall.Status != domain.RecallActive {
+		return domain.Recall{}, ErrClosed
+	}
+
+	now := time.Now().UTC()
+	recall.Status = domain.RecallClosed
+	recall.ClosedAt = &now
+	return s.repository.Update(recall)
+}
+
+// The Report method returns the current stock affected by a recall, product by product and lot by lot.
+func (s *ServiceImpl) Report(id int) ([]domain.RecallItem, error) {
+	recall, err := s.repository.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []domain.RecallItem{}
+	for _, p := range s.products.GetAll() {
+		items = append(items, recall.Items(p)...)
+	}
+	return items, nil
+}
+
+// The HeldQuantity method returns the units of a product blocked by active recalls.
+func (s *ServiceImpl) HeldQuantity(p domain.Product) int {
+	// Each unit is held once, even if several recalls cover it
+	held := map[string]int{}
+	for _, recall := range s.repository.GetAll() {
+		if recall.Status != domain.RecallActive {
+			continue
+		}
+		for _, item := range recall.Items(p) {
+			if item.Quantity > 0 {
+				held[item.Lot] = item.Quantity
+			}
+		}
+	}
+
+	total := 0
+	for _, quantity := range held {
+		total += quantity
+	}
+	return total
+}
+
+// Auxiliary function that checks that some product has the given code value, and some of them each of the given lots.
+func (s *ServiceImpl) checkProduct(codeValue string, lots []string) error {
+	found := false
+	missing := map[string]bool{}
+	for _, lot := range lots {
+		missing[lot] = true
+	}
+	for _, p := range s.products.GetAll() {
+		if p.CodeValue != codeValue {
+			continue
+		}
+		found = true
+		for _, lot := range p.Lots {
+			delete(missing, lot.Code)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w: %s", product.ErrNotFound, codeValue)
+	}
+	for _, lot := range lots {
+		if missing[lot] {
+			return fmt.Errorf("%w: %s", domain.ErrLotNotFound, lot)
+		}
+	}
+	return nil
+}
+
+// The IsBlocked method reports whether an active recall covers the given lot of a product ("" if it is not lot-tracked).
+func (s *ServiceImpl) IsBlocked(p domain.Product, lot string) bool {
+	expiration := p.Expiration
+	if i := p.FindLot(lot); i >= 0 {
+		expiration = p.Lots[i].Expiration
+	}
+
+	for _, recall := range s.repository.GetAll() {
+		if recall.Status == domain.RecallActive && recall.Covers(p, lot, expiration) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package recall
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createServiceForTest(t *testing.T) Service {
+	dir := t.TempDir()
+
+	// Milk kept in two lots, and cheese that is not lot-tracked
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 15, Expiration: domain.NewDate(2030, time.January, 1), Lots: []domain.Lot{
+			{Code: "A", Quantity: 10, Expiration: domain.NewDate(2030, time.January, 1)},
+			{Code: "B", Quantity: 5, Expiration: domain.NewDate(2030, time.June, 1)},
+		}},
+		{Id: 2, CodeValue: "CHEESE1", Quantity: 8, Expiration: domain.NewDate(2030, time.March, 1)},
+	}
+	repository := NewRepository(nil, store.NewJsonDocumentStore[[]domain.Recall](filepath.Join(dir, "recalls.json")))
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	return NewService(repository, productRepository)
+}
+
+func TestService_Create(t *testing.T) {
+	service := createServiceForTest(t)
+
+	tests := []struct {
+		name    string
+		request domain.RecallRequest
+		err     error
+	}{
+		{"Unknown product", domain.RecallRequest{CodeValue: "BREAD1"}, product.ErrNotFound},
+		{"Unknown lot", domain.RecallRequest{CodeValue: "MILK1", Lots: []string{"A", "C"}}, domain.ErrLotNotFound},
+		{"Lot of a product that is not lot-tracked", domain.RecallRequest{CodeValue: "CHEESE1", Lots: []string{"A"}}, domain.ErrLotNotFound},
+		{"Inverted range", domain.RecallRequest{CodeValue: "MILK1", ExpirationFrom: domain.NewDate(2030, time.June, 1), ExpirationTo: domain.NewDate(2030, time.January, 1)}, ErrInvalidRange},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.Create(test.request)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+	assert.Empty(t, service.GetAll())
+
+	created, err := service.Create(domain.RecallRequest{CodeValue: "MILK1", Lots: []string{"B"}, Reason: "Supplier notice"})
+	assert.Nil(t, err)
+	assert.Equal(t, domain.RecallActive, created.Status)
+	assert.Len(t, service.GetAll(), 1)
+}
+
+func TestService_HeldQuantity(t *testing.T) {
+	service := createServiceForTest(t)
+	milk := domain.Product{Id: 1, CodeValue: "MILK1", Lots: []domain.Lot{
+		{Code: "A", Quantity: 10, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Code: "B", Quantity: 5, Expiration: domain.NewDate(2030, time.June, 1)},
+	}}
+
+	// Units covered by several recalls are held once
+	lot, err := service.Create(domain.RecallRequest{CodeValue: "MILK1", Lots: []string{"B"}})
+	assert.Nil(t, err)
+	_, err = service.Create(domain.RecallRequest{CodeValue: "MILK1", ExpirationFrom: domain.NewDate(2030, time.May, 1)})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, service.HeldQuantity(milk))
+	assert.True(t, service.IsBlocked(milk, "B"))
+	assert.False(t, service.IsBlocked(milk, "A"))
+
+	report, err := service.Report(lot.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.RecallItem{{RecallId: lot.Id, ProductId: 1, CodeValue: "MILK1", Lot: "B", Expiration: domain.NewDate(2030, time.June, 1), Quantity: 5}}, report)
+
+	// Units still covered by another recall stay blocked after closing one, which closes only once
+	_, err = service.Close(lot.Id)
+	assert.Nil(t, err)
+	_, err = service.Close(lot.Id)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Equal(t, 5, service.HeldQuantity(milk))
+	assert.True(t, service.IsBlocked(milk, "B"))
+}
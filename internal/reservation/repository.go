@@ -0,0 +1,134 @@
+package reservation
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("reservation not found")
+
+// Repository is the interface definition for the reservation storage
+type Repository interface {
+	GetById(id int) (domain.Reservation, error)
+	GetAll() []domain.Reservation
+	Create(reservation domain.Reservation) (domain.Reservation, error)
+	Update(reservation domain.Reservation) (domain.Reservation, error)
+	HeldQuantity(product domain.Product) int
+	HeldQuantityAt(product domain.Product, location string) int
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu           sync.RWMutex
+	reservations []domain.Reservation
+	store        store.DocumentStore[[]domain.Reservation]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+reservations and saves every change in the provided store.
+*/
+func NewRepository(reservations []domain.Reservation, reservationStore store.DocumentStore[[]domain.Reservation]) Repository {
+	return &RepositoryImpl{
+		reservations: reservations,
+		store:        reservationStore,
+	}
+}
+
+// The GetById method returns a reservation by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.Reservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, reservation := range r.reservations {
+		if reservation.Id == id {
+			return reservation, nil
+		}
+	}
+	return domain.Reservation{}, ErrNotFound
+}
+
+// The GetAll method returns all the reservations
+func (r *RepositoryImpl) GetAll() []domain.Reservation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Reservation{}, r.reservations...)
+}
+
+// The Create method stores a new reservation with the next available ID and returns it.
+func (r *RepositoryImpl) Create(reservation domain.Reservation) (domain.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reservation.Id = len(r.reservations) + 1
+	if err := r.save(append(r.copyList(), reservation)); err != nil {
+		return domain.Reservation{}, err
+	}
+	return reservation, nil
+}
+
+// The Update method replaces a stored reservation with the same ID.
+func (r *RepositoryImpl) Update(reservation domain.Reservation) (domain.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.reservations {
+		if r.reservations[i].Id == reservation.Id {
+			reservations := r.copyList()
+			reservations[i] = reservation
+			if err := r.save(reservations); err != nil {
+				return domain.Reservation{}, err
+			}
+			return reservation, nil
+		}
+	}
+	return domain.Reservation{}, ErrNotFound
+}
+
+// The HeldQuantity method returns the units of a product held by reservations that are still active.
+func (r *RepositoryImpl) HeldQuantity(product domain.Product) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	held := 0
+	for _, reservation := range r.reservations {
+		if reservation.ProductId == product.Id && reservation.IsActiveAt(now) {
+			held += reservation.Quantity
+		}
+	}
+	return held
+}
+
+// The HeldQuantityAt method returns the units of a product held at a location by reservations that are still active.
+func (r *RepositoryImpl) HeldQuantityAt(product domain.Product, location string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	held := 0
+	for _, reservation := range r.reservations {
+		if reservation.ProductId == product.Id && reservation.Location == location && reservation.IsActiveAt(now) {
+			held += reservation.Quantity
+		}
+	}
+	return held
+}
+
+// Auxiliary function that returns a copy of the reservation list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.Reservation {
+	return append([]domain.Reservation{}, r.reservations...)
+}
+
+// Auxiliary function that saves the reservation list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(reservations []domain.Reservation) error {
+	if err := r.store.Save(reservations); err != nil {
+		return err
+	}
+	r.reservations = reservations
+	return nil
+}
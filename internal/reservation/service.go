@@ -0,0 +1,231 @@
+package reservation
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+)
+
+var (
+	ErrInvalidQuantity   = errors.New("reservation quantity must be greater than zero")
+	ErrInvalidTTL        = errors.New("invalid reservation time to live")
+	ErrInsufficientStock = errors.New("insufficient available stock")
+	ErrNotActive         = errors.New("reservation is not active")
+)
+
+// Reservations hold their units for DefaultTTL unless another time to live (up to MaxTTL) is requested.
+const (
+	DefaultTTL = 15 * time.Minute
+	MaxTTL     = 24 * time.Hour
+)
+
+type Service interface {
+	GetById(id int) (domain.Reservation, error)
+	Create(productId int, quantity int, location string, ttl time.Duration) (domain.Reservation, error)
+	Confirm(id int) (domain.Reservation, error)
+	Release(id int) (domain.Reservation, error)
+	ExpireStale(now time.Time) ([]domain.Reservation, error)
+}
+
+type ServiceImpl struct {
+	mu         sync.Mutex
+	repository Repository
+	products   product.Service
+	stock      stock.Service
+}
+
+/*
+The NewService function returns a new instance of the service. Products are used to check the
+available quantity, and confirmed reservations are taken out of stock as sales.
+*/
+func NewService(repository Repository, products product.Service, stockService stock.Service) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+		stock:      stockService,
+	}
+}
+
+// The GetById method returns a reservation by its ID
+func (s *ServiceImpl) GetById(id int) (domain.Reservation, error) {
+	return s.repository.GetById(id)
+}
+
+/*
+The Create method holds units of a product for the given time to live (DefaultTTL if zero). If the
+product does not have enough available units, it returns ErrInsufficientStock. With a location, the
+units are held at that location, which returns ErrInsufficientStockAt if it does not have enough.
+*/
+func (s *ServiceImpl) Create(productId int, quantity int, location string, ttl time.Duration) (domain.Reservation, error) {
+	if quantity <= 0 {
+		return domain.Reservation{}, ErrInvalidQuantity
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return domain.Reservation{}, ErrInvalidTTL
+	}
+
+	// The units are checked and held within a change of the products, so no sale or other hold can
+	// take them in between
+	var created domain.Reservation
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		targetProduct := findById(products, productId)
+		if targetProduct == nil {
+			return product.ErrNotFound
+		}
+		if s.available(*targetProduct, "", products) < quantity {
+			return ErrInsufficientStock
+		}
+		if location != "" && s.available(*targetProduct, location, products) < quantity {
+			return domain.ErrInsufficientStockAt
+		}
+		return nil
+	}, func() error {
+		now := time.Now().UTC()
+		var err error
+		created, err = s.repository.Create(domain.Reservation{
+			ProductId: productId,
+			Quantity:  quantity,
+			Location:  location,
+			Status:    domain.ReservationActive,
+			CreatedAt: now,
+			ExpiresAt: now.Add(ttl),
+		})
+		return err
+	})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	return created, nil
+}
+
+/*
+The Confirm method turns an active reservation into a sale, taking its units out of stock. Expired,
+released and already confirmed reservations return ErrNotActive.
+*/
+func (s *ServiceImpl) Confirm(id int) (domain.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservation, err := s.activeReservation(id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	// Sales of bundles take the units of their components
+	reference := fmt.Sprintf("reservation-%d", reservation.Id)
+	items := []domain.StockItem{{
+		ProductId: reservation.ProductId,
+		Quantity:  reservation.Quantity,
+		Location:  reservation.Location,
+		Reserved:  reservation.Quantity,
+	}}
+	// The reservation is confirmed along with the sale, so a failed update leaves the stock untouched
+	var confirmed domain.Reservation
+	_, err = s.stock.Sell(items, func([]domain.Product) (string, error) { return reference, nil }, func() error {
+		var err error
+		reservation.Status = domain.ReservationConfirmed
+		confirmed, err = s.repository.Update(reservation)
+		return err
+	})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	return confirmed, nil
+}
+
+// The Release method cancels an active reservation, making its units available again.
+func (s *ServiceImpl) Release(id int) (domain.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservation, err := s.activeReservation(id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	reservation.Status = domain.ReservationReleased
+	return s.repository.Update(reservation)
+}
+
+/*
+The ExpireStale method marks as expired every active reservation whose time to live has passed and
+returns them. Their units are already available again, this only records that they were not used.
+*/
+func (s *ServiceImpl) ExpireStale(now time.Time) ([]domain.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []domain.Reservation
+	for _, reservation := range s.repository.GetAll() {
+		if reservation.Status != domain.ReservationActive || reservation.IsActiveAt(now) {
+			continue
+		}
+
+		reservation.Status = domain.ReservationExpired
+		updatedReservation, err := s.repository.Update(reservation)
+		if err != nil {
+			return expired, err
+		}
+		expired = append(expired, updatedReservation)
+	}
+	return expired, nil
+}
+
+// Auxiliary function that returns a reservation if it is still active.
+func (s *ServiceImpl) activeReservation(id int) (domain.Reservation, error) {
+	reservation, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	if !reservation.IsActiveAt(time.Now()) {
+		return domain.Reservation{}, ErrNotActive
+	}
+	return reservation, nil
+}
+
+/*
+Auxiliary function that returns the units of a product (at a location, if one is given) that can be
+held. Bundles hold no stock of their own, so they can hold as many whole bundles as their components
+make up.
+*/
+func (s *ServiceImpl) available(p domain.Product, location string, products []domain.Product) int {
+	if !p.IsBundle() {
+		if location != "" {
+			return s.products.AvailableAt(p, location, products)
+		}
+		return s.products.Available(p, products)
+	}
+
+	available := -1
+	for _, component := range p.Components {
+		units := 0
+		if c := findById(products, component.ProductId); c != nil {
+			units = s.available(*c, location, products)
+		}
+		if sets := units / component.Quantity; available < 0 || sets < available {
+			available = sets
+		}
+	}
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// Auxiliary function that returns a pointer to the product with the given ID, or nil if there is none.
+func findById(products []domain.Product, id int) *domain.Product {
+	for i := range products {
+		if products[i].Id == id {
+			return &products[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package reservation
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var errStore = errors.New("reservation store unavailable")
+
+// failingRepository is a reservation repository that cannot update any reservation.
+type failingRepository struct {
+	Repository
+}
+
+func (failingRepository) Update(domain.Reservation) (domain.Reservation, error) {
+	return domain.Reservation{}, errStore
+}
+
+func createServiceForTest(t *testing.T, wrap func(Repository) Repository) (Service, Repository, product.Repository) {
+	dir := t.TempDir()
+
+	// Ten bottles of milk, six of them in the main warehouse, and a pack of two bottles
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 10, IsPublished: true, Expiration: domain.NewDate(2030, time.January, 1),
+			Stock: []domain.LocationStock{{Location: "main", Quantity: 6}, {Location: "back", Quantity: 4}}},
+		{Id: 2, CodeValue: "MILK2", IsPublished: true, Expiration: domain.NewDate(2030, time.January, 1),
+			Components: []domain.BundleComponent{{ProductId: 1, Quantity: 2}}},
+	}
+	locations := []domain.Location{{Code: "main", Name: "Main warehouse"}, {Code: "back", Name: "Back room"}}
+
+	repository := NewRepository(nil, store.NewJsonDocumentStore[[]domain.Reservation](filepath.Join(dir, "reservations.json")))
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	productService := product.NewService(productRepository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil, repository)
+	locationService := location.NewService(location.NewRepository(locations, store.NewJsonDocumentStore[[]domain.Location](filepath.Join(dir, "locations.json"))))
+	ledger := stock.NewLedger(nil, store.NewJsonLinesStore[domain.StockMovement](filepath.Join(dir, "stock_movements.jsonl")))
+	stockService := stock.NewService(productService, ledger, locationService, time.UTC)
+
+	serviceRepository := repository
+	if wrap != nil {
+		serviceRepository = wrap(repository)
+	}
+	return NewService(serviceRepository, productService, stockService), repository, productRepository
+}
+
+func TestService_Create(t *testing.T) {
+	service, _, _ := createServiceForTest(t, nil)
+
+	tests := []struct {
+		name      string
+		productId int
+		quantity  int
+		location  string
+		ttl       time.Duration
+		err       error
+	}{
+		{"Invalid quantity", 1, 0, "", 0, ErrInvalidQuantity},
+		{"Negative time to live", 1, 1, "", -time.Minute, ErrInvalidTTL},
+		{"Time to live too long", 1, 1, "", MaxTTL + time.Minute, ErrInvalidTTL},
+		{"Unknown product", 99, 1, "", 0, product.ErrNotFound},
+		{"More than the quantity", 1, 11, "", 0, ErrInsufficientStock},
+		{"More than the location keeps", 1, 5, "back", 0, domain.ErrInsufficientStockAt},
+		{"More bundles than the components make up", 2, 6, "", 0, ErrInsufficientStock},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.Create(test.productId, test.quantity, test.location, test.ttl)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	// Held units are no longer available, neither directly nor through the bundle
+	created, err := service.Create(1, 4, "main", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.ReservationActive, created.Status)
+	assert.Equal(t, DefaultTTL, created.ExpiresAt.Sub(created.CreatedAt))
+	_, err = service.Create(1, 3, "main", 0)
+	assert.ErrorIs(t, err, domain.ErrInsufficientStockAt)
+
+	bundle, err := service.Create(2, 3, "", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, bundle.ProductId)
+	_, err = service.Create(1, 1, "", 0)
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+}
+
+func TestService_Confirm(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		service, _, productRepository := createServiceForTest(t, nil)
+		created, err := service.Create(2, 2, "", 0)
+		assert.Nil(t, err)
+
+		// The bundle is sold from its components, and the reservation cannot be used again
+		confirmed, err := service.Confirm(created.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.ReservationConfirmed, confirmed.Status)
+		milk, _ := productRepository.GetById(1)
+		assert.Equal(t, 6, milk.Quantity)
+
+		_, err = service.Confirm(created.Id)
+		assert.ErrorIs(t, err, ErrNotActive)
+		_, err = service.Release(created.Id)
+		assert.ErrorIs(t, err, ErrNotActive)
+	})
+
+	t.Run("Failed update", func(t *testing.T) {
+		service, repository, productRepository := createServiceForTest(t, func(repository Repository) Repository {
+			return failingRepository{repository}
+		})
+		created, err := service.Create(1, 3, "", 0)
+		assert.Nil(t, err)
+
+		// The sale is undone, so the reservation still holds its units
+		_, err = service.Confirm(created.Id)
+		assert.ErrorIs(t, err, errStore)
+		milk, _ := productRepository.GetById(1)
+		assert.Equal(t, 10, milk.Quantity)
+		stored, _ := repository.GetById(created.Id)
+		assert.Equal(t, domain.ReservationActive, stored.Status)
+	})
+}
+
+func TestService_ExpireStale(t *testing.T) {
+	service, _, _ := createServiceForTest(t, nil)
+	short, err := service.Create(1, 2, "", time.Minute)
+	assert.Nil(t, err)
+	long, err := service.Create(1, 2, "", time.Hour)
+	assert.Nil(t, err)
+
+	// Only the reservations past their time to live are marked as expired
+	expired, err := service.ExpireStale(time.Now().Add(30 * time.Minute))
+	assert.Nil(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, short.Id, expired[0].Id)
+	assert.Equal(t, domain.ReservationExpired, expired[0].Status)
+
+	stored, err := service.GetById(long.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.ReservationActive, stored.Status)
+}
@@ -0,0 +1,82 @@
+package stock
+
+import (
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+// Ledger is the interface definition for the append-only stock movement ledger
+type Ledger interface {
+	Append(movements ...domain.StockMovement) ([]domain.StockMovement, error)
+	GetByProduct(productId int) []domain.StockMovement
+	GetByReference(reference string) []domain.StockMovement
+}
+
+// LedgerImpl is the implementation of the ledger interface
+type LedgerImpl struct {
+	mu        sync.RWMutex
+	movements []domain.StockMovement
+	store     store.AppendStore[domain.StockMovement]
+}
+
+/*
+The NewLedger function returns a new instance of the ledger. It starts with the given movements and
+appends every new movement to the provided store.
+*/
+func NewLedger(movements []domain.StockMovement, movementStore store.AppendStore[domain.StockMovement]) Ledger {
+	return &LedgerImpl{
+		movements: movements,
+		store:     movementStore,
+	}
+}
+
+/*
+The Append method numbers the given movements, writes them to the store in a single write and
+returns them. Movements are never modified or removed afterwards.
+*/
+func (l *LedgerImpl) Append(movements ...domain.StockMovement) ([]domain.StockMovement, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	numbered := make([]domain.StockMovement, len(movements))
+	for i, movement := range movements {
+		movement.Id = len(l.movements) + i + 1
+		numbered[i] = movement
+	}
+
+	if err := l.store.Append(numbered...); err != nil {
+		return nil, err
+	}
+	l.movements = append(l.movements, numbered...)
+	return numbered, nil
+}
+
+// The GetByProduct method returns the movements of a product, oldest first.
+func (l *LedgerImpl) GetByProduct(productId int) []domain.StockMovement {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	movements := []domain.StockMovement{}
+	for _, movement := range l.movements {
+		if movement.ProductId == productId {
+			movements = append(movements, movement)
+		}
+	}
+	return movements
+}
+
+// The GetByReference method returns the movements recorded with the given reference, oldest first.
+func (l *LedgerImpl) GetByReference(reference string) []domain.StockMovement {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	movements := []domain.StockMovement{}
+	for _, movement := range l.movements {
+		if movement.Reference == reference {
+			movements = append(movements, movement)
+		}
+	}
+	return movements
+}
@@ -0,0 +1,873 @@
+package stock
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/product"
+)
+
+var (
+	ErrInvalidReason     = errors.New("invalid stock movement reason")
+	ErrInvalidDelta      = errors.New("invalid stock movement delta for the given reason")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrLotRequired       = errors.New("restocking a lot-tracked product requires a lot code")
+	ErrInvalidLot        = errors.New("lot quantity must be greater than zero and expiration is required")
+	ErrBlocked           = errors.New("stock is blocked by a recall")
+	ErrExpiredLot        = errors.New("lot is expired and cannot be sold")
+	ErrInvalidTransfer   = errors.New("transfers move a positive quantity between two different locations")
+	ErrBundleStock       = errors.New("bundles hold no stock of their own, move the stock of their components")
+	ErrReferenceUsed     = errors.New("stock movements with this reference were already recorded")
+)
+
+// reasonSigns tells whether each reason adds (1) or removes (-1) units.
+var reasonSigns = map[domain.MovementReason]int{
+	domain.ReasonSale:      -1,
+	domain.ReasonRestock:   1,
+	domain.ReasonShrinkage: -1,
+}
+
+// Blocker tells whether the stock of a product, or one of its lots ("" if it is not lot-tracked), cannot be sold.
+type Blocker interface {
+	IsBlocked(product domain.Product, lot string) bool
+}
+
+type Service interface {
+	Adjust(productId int, delta int, reason domain.MovementReason, reference string) (domain.StockMovement, error)
+	Apply(productId int, adjustment domain.StockAdjustmentRequest) (domain.StockMovement, error)
+	Transfer(productId int, transfer domain.TransferRequest) (domain.StockMovement, error)
+	ApplyCounts(counts []domain.StockCount, location string, reference string) ([]domain.StockMovement, error)
+	Receive(receipts []domain.StockReceipt, reference string) ([]domain.StockMovement, error)
+	Sell(items []domain.StockItem, prepare func(products []domain.Product) (string, error), records ...func() error) ([]domain.StockMovement, error)
+	Revert(reference string, reason domain.MovementReason) ([]domain.StockMovement, error)
+	GetMovements(productId int) ([]domain.StockMovement, error)
+	GetMovementsByReference(reference string) []domain.StockMovement
+	ReceiveLot(productId int, lot domain.LotRequest) (domain.StockMovement, error)
+	GetLots(productId int) ([]domain.Lot, error)
+	RemoveLot(productId int, code string) error
+}
+
+type ServiceImpl struct {
+	products  product.Service
+	ledger    Ledger
+	locations location.Service
+	location  *time.Location
+	blockers  []Blocker
+}
+
+/*
+The NewService function returns a new instance of the service. Stock changes go through the product
+service, the locations tell where stock can be kept and the blockers (such as recalls) tell which
+stock cannot be sold. The time location is the catalog time zone, used to decide which lots have
+expired.
+*/
+func NewService(products product.Service, ledger Ledger, locations location.Service, timeLocation *time.Location, blockers ...Blocker) Service {
+	return &ServiceImpl{
+		products:  products,
+		ledger:    ledger,
+		locations: locations,
+		location:  timeLocation,
+		blockers:  blockers,
+	}
+}
+
+/*
+The Adjust method atomically changes the quantity of a product by a signed delta and records the
+movement in the ledger. Sales and shrinkage must be negative and restocks positive. If the quantity
+would go below zero and the product does not allow backorders, it returns ErrInsufficientStock.
+Sales do not take held units, such as reserved ones, either. Units taken from a lot-tracked product
+come out of its lots first-expired-first-out, and sales skip expired lots, or return ErrExpiredLot.
+*/
+func (s *ServiceImpl) Adjust(productId int, delta int, reason domain.MovementReason, reference string) (domain.StockMovement, error) {
+	return s.Apply(productId, domain.StockAdjustmentRequest{Delta: delta, Reason: reason, Reference: reference})
+}
+
+/*
+The Apply method works like Adjust, but can also name the lot and the location of the units.
+Restocks of a lot-tracked product must name the lot, and lot-tracked products never go below zero.
+Sales never take blocked stock: they skip blocked lots, or return ErrBlocked. Without a location,
+units are taken from the locations of the product in order, and added to DefaultLocation.
+*/
+func (s *ServiceImpl) Apply(productId int, adjustment domain.StockAdjustmentRequest) (domain.StockMovement, error) {
+	delta, reason := adjustment.Delta, adjustment.Reason
+	sign, ok := reasonSigns[reason]
+	if !ok {
+		return domain.StockMovement{}, ErrInvalidReason
+	}
+	if delta == 0 || (delta > 0) != (sign > 0) {
+		return domain.StockMovement{}, ErrInvalidDelta
+	}
+	if err := s.checkLocation(adjustment.Location); err != nil {
+		return domain.StockMovement{}, err
+	}
+
+	var pending, movements []domain.StockMovement
+	// The whole product list is changed, since the holds of the bundles count against their components
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		p := findById(products, productId)
+		if p == nil {
+			return product.ErrNotFound
+		}
+		unsellable, available := takeAny, (func(location string) int)(nil)
+		if reason == domain.ReasonSale {
+			if err := s.checkHolds(*p, -delta, 0, adjustment.Location, products); err != nil {
+				return err
+			}
+			unsellable, available = s.unsellable(p), s.availableAt(*p, products)
+		}
+
+		if adjustment.Location != "" {
+			p.TrackLocations()
+		}
+		lots, err := applyDelta(p, adjustment.Lot, delta, domain.Today(s.location), unsellable)
+		if err != nil {
+			return err
+		}
+		locations, err := applyLocation(p, adjustment.Location, delta, available)
+		if err != nil {
+			return err
+		}
+
+		pending = []domain.StockMovement{{
+			ProductId:     productId,
+			Delta:         delta,
+			QuantityAfter: p.Quantity,
+			Reason:        reason,
+			Reference:     adjustment.Reference,
+			Lots:          lots,
+			Locations:     locations,
+			Time:          time.Now().UTC(),
+		}}
+		return nil
+	}, s.record(&pending, &movements))
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+	return movements[0], nil
+}
+
+/*
+Auxiliary function that applies a delta to a product, and to its lots if it is lot-tracked. Units are
+never taken from stock for which unsellable returns an error: a named lot returns that error, and
+lots taken first-expired-first-out are skipped. Today is the day in the catalog time zone, which tells
+the lots that have expired when syncing the product expiration.
+*/
+func applyDelta(p *domain.Product, lot string, delta int, today domain.Date, unsellable func(lot string) error) ([]domain.LotMovement, error) {
+	if p.IsBundle() {
+		return nil, ErrBundleStock
+	}
+	if !p.IsLotTracked() {
+		if lot != "" {
+			return nil, domain.ErrLotNotFound
+		}
+		if delta < 0 {
+			if err := unsellable(""); err != nil {
+				return nil, err
+			}
+		}
+		quantity := p.Quantity + delta
+		if quantity < 0 && !p.AllowBackorder {
+			return nil, ErrInsufficientStock
+		}
+		p.Quantity = quantity
+		return nil, nil
+	}
+
+	// Without a lot code, units are taken first-expired-first-out
+	if lot == "" {
+		if delta > 0 {
+			return nil, ErrLotRequired
+		}
+		lots, err := p.ConsumeLots(-delta, today, func(lot domain.Lot) bool { return unsellable(lot.Code) != nil })
+		if errors.Is(err, domain.ErrInsufficientLots) {
+			return nil, ErrInsufficientStock
+		}
+		return lots, err
+	}
+
+	i := p.FindLot(lot)
+	if i < 0 {
+		return nil, domain.ErrLotNotFound
+	}
+	if delta < 0 {
+		if err := unsellable(lot); err != nil {
+			return nil, err
+		}
+	}
+	if p.Lots[i].Quantity+delta < 0 {
+		return nil, ErrInsufficientStock
+	}
+	p.Lots[i].Quantity += delta
+	p.SyncLots(today)
+	return []domain.LotMovement{{Code: lot, Delta: delta}}, nil
+}
+
+/*
+Auxiliary function that applies a delta to the locations of a location-tracked product. Without a
+location, units are taken from the locations in order, the available ones first if available is not
+nil, and added to DefaultLocation.
+*/
+func applyLocation(p *domain.Product, location string, delta int, available func(location string) int) ([]domain.LocationMovement, error) {
+	if !p.IsLocationTracked() {
+		return nil, nil
+	}
+	if location == "" {
+		if delta < 0 {
+			return p.TakeFromLocations(-delta, available), nil
+		}
+		location = domain.DefaultLocation
+	}
+	return p.AdjustAt(location, delta)
+}
+
+/*
+The Transfer method atomically moves units of a product from one location to another, and records
+the transfer in the ledger. The product quantity does not change. Blocked stock is never moved, so
+a transfer larger than the units that are not blocked returns ErrBlocked.
+*/
+func (s *ServiceImpl) Transfer(productId int, transfer domain.TransferRequest) (domain.StockMovement, error) {
+	if transfer.Quantity <= 0 || transfer.To == "" || transfer.From == transfer.To {
+		return domain.StockMovement{}, ErrInvalidTransfer
+	}
+	for _, code := range []string{transfer.From, transfer.To} {
+		if err := s.checkLocation(code); err != nil {
+			return domain.StockMovement{}, err
+		}
+	}
+
+	var pending, movements []domain.StockMovement
+	_, err := s.products.Modify(productId, func(p *domain.Product) error {
+		if p.IsBundle() {
+			return ErrBundleStock
+		}
+		// Recalled units must stay where they are until they are written off
+		if s.unblockedQuantity(*p) < transfer.Quantity {
+			return ErrBlocked
+		}
+		p.TrackLocations()
+		if p.QuantityAt(transfer.From) < transfer.Quantity {
+			return domain.ErrInsufficientStockAt
+		}
+		out, err := p.AdjustAt(transfer.From, -transfer.Quantity)
+		if err != nil {
+			return err
+		}
+		in, err := p.AdjustAt(transfer.To, transfer.Quantity)
+		if err != nil {
+			return err
+		}
+
+		pending = []domain.StockMovement{{
+			ProductId:     productId,
+			QuantityAfter: p.Quantity,
+			Reason:        domain.ReasonTransfer,
+			Reference:     transfer.Reference,
+			Locations:     append(out, in...),
+			Time:          time.Now().UTC(),
+		}}
+		return nil
+	}, s.record(&pending, &movements))
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+	return movements[0], nil
+}
+
+/*
+The ApplyCounts method adjusts the stock of several products to match their counted quantities, at a
+location or in total if it is empty, and records one ledger movement per product with a variance.
+Everything happens in a single atomic operation: if any count cannot be applied, nothing changes.
+Counts are applied only once per reference, so a reference already in the ledger returns
+ErrReferenceUsed.
+Missing units of a lot-tracked product are taken first-expired-first-out, and extra units are
+added to the lot that expires last.
+*/
+func (s *ServiceImpl) ApplyCounts(counts []domain.StockCount, location string, reference string) ([]domain.StockMovement, error) {
+	if err := s.checkLocation(location); err != nil {
+		return nil, err
+	}
+
+	var pending, movements []domain.StockMovement
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		if err := s.checkReference(reference); err != nil {
+			return err
+		}
+		now, today := time.Now().UTC(), domain.Today(s.location)
+		for _, count := range counts {
+			p := domain.FindByCode(products, count.CodeValue)
+			if p == nil {
+				return fmt.Errorf("%w: %s", product.ErrNotFound, count.CodeValue)
+			}
+
+			if location != "" {
+				p.TrackLocations()
+			}
+			delta := count.Counted - countedQuantity(*p, location)
+			if delta == 0 {
+				continue
+			}
+
+			lot := ""
+			if delta > 0 && p.IsLotTracked() {
+				lot = latestLot(*p)
+			}
+			lots, err := applyDelta(p, lot, delta, today, takeAny)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, count.CodeValue)
+			}
+			locations, err := applyLocation(p, location, delta, nil)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, count.CodeValue)
+			}
+
+			pending = append(pending, domain.StockMovement{
+				ProductId:     p.Id,
+				Delta:         delta,
+				QuantityAfter: p.Quantity,
+				Reason:        domain.ReasonCount,
+				Reference:     reference,
+				Lots:          lots,
+				Locations:     locations,
+				Time:          now,
+			})
+		}
+		return nil
+	}, s.record(&pending, &movements))
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+/*
+The Receive method adds received units to the stock of several products, and records one ledger
+movement per receipt, in a single atomic operation: if any receipt cannot be applied, nothing
+changes. Units of a lot-tracked product must be received in a lot, which is created if it does not
+exist yet. Receipts are applied only once per reference, so a reference already in the ledger
+returns ErrReferenceUsed.
+*/
+func (s *ServiceImpl) Receive(receipts []domain.StockReceipt, reference string) ([]domain.StockMovement, error) {
+	for _, receipt := range receipts {
+		if receipt.Quantity <= 0 {
+			return nil, ErrInvalidDelta
+		}
+		if err := s.checkLocation(receipt.Location); err != nil {
+			return nil, err
+		}
+	}
+
+	var pending, movements []domain.StockMovement
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		if err := s.checkReference(reference); err != nil {
+			return err
+		}
+		now, today := time.Now().UTC(), domain.Today(s.location)
+		pending = make([]domain.StockMovement, 0, len(receipts))
+		for _, receipt := range receipts {
+			p := findById(products, receipt.ProductId)
+			if p == nil {
+				return fmt.Errorf("%w: %d", product.ErrNotFound, receipt.ProductId)
+			}
+
+			if receipt.Location != "" {
+				p.TrackLocations()
+			}
+			lots, err := receiveInLot(p, receipt, now, today)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, p.CodeValue)
+			}
+			locations, err := applyLocation(p, receipt.Location, receipt.Quantity, nil)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, p.CodeValue)
+			}
+
+			pending = append(pending, domain.StockMovement{
+				ProductId:     p.Id,
+				Delta:         receipt.Quantity,
+				QuantityAfter: p.Quantity,
+				Reason:        domain.ReasonReceipt,
+				Reference:     reference,
+				Lots:          lots,
+				Locations:     locations,
+				Time:          now,
+			})
+		}
+
+		return nil
+	}, s.record(&pending, &movements))
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+/*
+The Sell method takes the units of several products out of stock as sales, and records one ledger
+movement per item, in a single atomic operation. Units are taken as in Adjust, and selling a bundle
+takes the units of its components instead, with one movement per component. Once the stock has
+been taken, prepare runs inside the same operation with the sold products: it can reject the sale
+by returning an error, or return the reference of the movements. The records persist whatever
+causes the sale once the products are saved, ahead of the movements (see Repository.ModifyAll).
+*/
+func (s *ServiceImpl) Sell(items []domain.StockItem, prepare func(products []domain.Product) (string, error), records ...func() error) ([]domain.StockMovement, error) {
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, ErrInvalidDelta
+		}
+	}
+
+	var pending, movements []domain.StockMovement
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		now := time.Now().UTC()
+		sold := make([]domain.Product, len(items))
+		pending = make([]domain.StockMovement, 0, len(items))
+		for i, item := range items {
+			p := findById(products, item.ProductId)
+			if p == nil {
+				return fmt.Errorf("%w: %d", product.ErrNotFound, item.ProductId)
+			}
+			if !p.IsBundle() {
+				movement, err := s.sell(p, item, now, products)
+				if err != nil {
+					return err
+				}
+				pending = append(pending, movement)
+				sold[i] = *p
+				continue
+			}
+
+			for _, component := range p.Components {
+				c := findById(products, component.ProductId)
+				if c == nil {
+					return fmt.Errorf("%w: %d", product.ErrNotFound, component.ProductId)
+				}
+				// The units reserved for the bundle are held from its components
+				movement, err := s.sell(c, domain.StockItem{
+					Quantity: item.Quantity * component.Quantity,
+					Reserved: item.Reserved * component.Quantity,
+					Location: item.Location,
+				}, now, products)
+				if err != nil {
+					return err
+				}
+				pending = append(pending, movement)
+			}
+			sold[i] = *p
+		}
+
+		reference, err := prepare(sold)
+		if err != nil {
+			return err
+		}
+		for i := range pending {
+			pending[i].Reference = reference
+		}
+
+		return nil
+	}, append(records, s.record(&pending, &movements))...)
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+/*
+Auxiliary function that takes the sold units of an item out of the stock of a product, one of the
+given products, and returns the movement to record. The reserved units of the item are the ones
+held for this very sale.
+*/
+func (s *ServiceImpl) sell(p *domain.Product, item domain.StockItem, now time.Time, products []domain.Product) (domain.StockMovement, error) {
+	quantity := item.Quantity
+	if err := s.checkHolds(*p, quantity, item.Reserved, item.Location, products); err != nil {
+		return domain.StockMovement{}, fmt.Errorf("%w: %s", err, p.CodeValue)
+	}
+	if item.Location != "" {
+		p.TrackLocations()
+	}
+	lots, err := applyDelta(p, "", -quantity, domain.Today(s.location), s.unsellable(p))
+	if err != nil {
+		return domain.StockMovement{}, fmt.Errorf("%w: %s", err, p.CodeValue)
+	}
+	locations, err := applyLocation(p, item.Location, -quantity, s.availableAt(*p, products))
+	if err != nil {
+		return domain.StockMovement{}, fmt.Errorf("%w: %s", err, p.CodeValue)
+	}
+
+	return domain.StockMovement{
+		ProductId:     p.Id,
+		Delta:         -quantity,
+		QuantityAfter: p.Quantity,
+		Reason:        domain.ReasonSale,
+		Lots:          lots,
+		Locations:     locations,
+		Time:          now,
+	}, nil
+}
+
+/*
+The Revert method puts back the units of the sales recorded with the given reference, in the same
+lots and locations they were taken from, and records the opposite movements with the given reason
+and the same reference, in a single atomic operation. A reference is reverted only once: reverting
+it again returns the movements of the first time.
+*/
+func (s *ServiceImpl) Revert(reference string, reason domain.MovementReason) ([]domain.StockMovement, error) {
+	if len(s.ledger.GetByReference(reference)) == 0 {
+		return nil, nil
+	}
+
+	var pending, movements []domain.StockMovement
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		var sales []domain.StockMovement
+		for _, movement := range s.ledger.GetByReference(reference) {
+			if movement.Reason != domain.ReasonSale {
+				movements = append(movements, movement)
+			} else {
+				sales = append(sales, movement)
+			}
+		}
+		if len(movements) > 0 {
+			return nil
+		}
+
+		now, today := time.Now().UTC(), domain.Today(s.location)
+		pending = make([]domain.StockMovement, 0, len(sales))
+		for _, sale := range sales {
+			p := findById(products, sale.ProductId)
+			if p == nil {
+				return fmt.Errorf("%w: %d", product.ErrNotFound, sale.ProductId)
+			}
+
+			reverted, err := revertMovement(p, sale, today)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, p.CodeValue)
+			}
+			reverted.Reason = reason
+			reverted.Reference = reference
+			reverted.Time = now
+			pending = append(pending, reverted)
+		}
+
+		return nil
+	}, s.record(&pending, &movements))
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// Auxiliary function that applies the opposite of a movement to a product, and returns it. Today is used like in applyDelta.
+func revertMovement(p *domain.Product, movement domain.StockMovement, today domain.Date) (domain.StockMovement, error) {
+	reverted := domain.StockMovement{ProductId: p.Id, Delta: -movement.Delta}
+
+	if len(movement.Lots) > 0 {
+		for _, lot := range movement.Lots {
+			i := p.FindLot(lot.Code)
+			if i < 0 {
+				return domain.StockMovement{}, domain.ErrLotNotFound
+			}
+			p.Lots[i].Quantity -= lot.Delta
+			reverted.Lots = append(reverted.Lots, domain.LotMovement{Code: lot.Code, Delta: -lot.Delta})
+		}
+		p.SyncLots(today)
+	} else {
+		p.Quantity -= movement.Delta
+	}
+
+	for _, location := range movement.Locations {
+		if _, err := p.AdjustAt(location.Location, -location.Delta); err != nil {
+			return domain.StockMovement{}, err
+		}
+		reverted.Locations = append(reverted.Locations, domain.LocationMovement{Location: location.Location, Delta: -location.Delta})
+	}
+	if len(movement.Locations) == 0 && p.IsLocationTracked() {
+		locations, err := applyLocation(p, "", -movement.Delta, nil)
+		if err != nil {
+			return domain.StockMovement{}, err
+		}
+		reverted.Locations = locations
+	}
+
+	reverted.QuantityAfter = p.Quantity
+	return reverted, nil
+}
+
+/*
+Auxiliary function that adds received units to a product, in a new or existing lot if one is given.
+New lots must not have expired by today, like the expiration of a new product.
+*/
+func receiveInLot(p *domain.Product, receipt domain.StockReceipt, now time.Time, today domain.Date) ([]domain.LotMovement, error) {
+	if p.IsBundle() {
+		return nil, ErrBundleStock
+	}
+	if receipt.Lot == "" || p.FindLot(receipt.Lot) >= 0 {
+		return applyDelta(p, receipt.Lot, receipt.Quantity, today, takeAny)
+	}
+
+	if receipt.Expiration.IsZero() {
+		return nil, ErrInvalidLot
+	}
+	if !receipt.Expiration.After(today) {
+		return nil, product.ErrExpiredDate
+	}
+	err := p.AddLot(domain.Lot{Code: receipt.Lot, Quantity: receipt.Quantity, Expiration: receipt.Expiration, ReceivedAt: now}, today)
+	if err != nil {
+		return nil, err
+	}
+	return []domain.LotMovement{{Code: receipt.Lot, Delta: receipt.Quantity}}, nil
+}
+
+// Auxiliary function that returns the product with the given ID, or nil if there is none.
+func findById(products []domain.Product, id int) *domain.Product {
+	for i := range products {
+		if products[i].Id == id {
+			return &products[i]
+		}
+	}
+	return nil
+}
+
+// Auxiliary function that returns the system quantity of a product at a location, or in total if it is empty.
+func countedQuantity(p domain.Product, location string) int {
+	if location == "" {
+		return p.Quantity
+	}
+	return p.QuantityAt(location)
+}
+
+// Auxiliary function that returns the code of the lot of a product that expires last.
+func latestLot(p domain.Product) string {
+	latest := p.Lots[0]
+	for _, lot := range p.Lots[1:] {
+		if lot.Expiration.After(latest.Expiration) {
+			latest = lot
+		}
+	}
+	return latest.Code
+}
+
+/*
+The ReceiveLot method adds a new lot to a product and records its units in the ledger as a receipt.
+The first lot received turns the current stock of the product into an "initial" lot. Like the
+expiration of a new product, the lot expiration must come after the current date.
+*/
+func (s *ServiceImpl) ReceiveLot(productId int, request domain.LotRequest) (domain.StockMovement, error) {
+	if request.Quantity <= 0 || request.Expiration.IsZero() {
+		return domain.StockMovement{}, ErrInvalidLot
+	}
+	today := domain.Today(s.location)
+	if !request.Expiration.After(today) {
+		return domain.StockMovement{}, product.ErrExpiredDate
+	}
+	if err := s.checkLocation(request.Location); err != nil {
+		return domain.StockMovement{}, err
+	}
+
+	var pending, movements []domain.StockMovement
+	_, err := s.products.Modify(productId, func(p *domain.Product) error {
+		if p.IsBundle() {
+			return ErrBundleStock
+		}
+		now := time.Now().UTC()
+		if request.Location != "" {
+			p.TrackLocations()
+		}
+		err := p.AddLot(domain.Lot{
+			Code:       request.Code,
+			Quantity:   request.Quantity,
+			Expiration: request.Expiration,
+			ReceivedAt: now,
+		}, today)
+		if err != nil {
+			return err
+		}
+		locations, err := applyLocation(p, request.Location, request.Quantity, nil)
+		if err != nil {
+			return err
+		}
+
+		pending = []domain.StockMovement{{
+			ProductId:     productId,
+			Delta:         request.Quantity,
+			QuantityAfter: p.Quantity,
+			Reason:        domain.ReasonReceipt,
+			Reference:     request.Code,
+			Lots:          []domain.LotMovement{{Code: request.Code, Delta: request.Quantity}},
+			Locations:     locations,
+			Time:          now,
+		}}
+		return nil
+	}, s.record(&pending, &movements))
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+	return movements[0], nil
+}
+
+// The GetLots method returns the lots of a product, soonest to expire first.
+func (s *ServiceImpl) GetLots(productId int) ([]domain.Lot, error) {
+	p, err := s.products.GetById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	lots := append([]domain.Lot{}, p.Lots...)
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].Expiration.Before(lots[j].Expiration)
+	})
+	return lots, nil
+}
+
+/*
+The RemoveLot method removes an empty lot from a product. Lots that still have stock return
+ErrLotNotEmpty, and the last lot returns ErrLastLot, since the product would stop being lot-tracked.
+*/
+func (s *ServiceImpl) RemoveLot(productId int, code string) error {
+	_, err := s.products.Modify(productId, func(p *domain.Product) error {
+		i := p.FindLot(code)
+		if i < 0 {
+			return domain.ErrLotNotFound
+		}
+		if p.Lots[i].Quantity > 0 {
+			return domain.ErrLotNotEmpty
+		}
+		if len(p.Lots) == 1 {
+			return domain.ErrLastLot
+		}
+		p.Lots = append(p.Lots[:i:i], p.Lots[i+1:]...)
+		return nil
+	})
+	return err
+}
+
+// The GetMovementsByReference method returns the stock movements recorded with a reference, oldest first.
+func (s *ServiceImpl) GetMovementsByReference(reference string) []domain.StockMovement {
+	return s.ledger.GetByReference(reference)
+}
+
+// The GetMovements method returns the stock movements of a product, oldest first.
+func (s *ServiceImpl) GetMovements(productId int) ([]domain.StockMovement, error) {
+	if _, err := s.products.GetById(productId); err != nil {
+		return nil, err
+	}
+	return s.ledger.GetByProduct(productId), nil
+}
+
+/*
+Auxiliary function that checks that a sale takes no units held for something else, unless the product
+allows backorders. The reserved units are the ones held for this very sale, at its location if any.
+The products are the ones being changed, where the bundles holding units of the product are found.
+*/
+func (s *ServiceImpl) checkHolds(p domain.Product, quantity int, reserved int, location string, products []domain.Product) error {
+	if p.AllowBackorder {
+		return nil
+	}
+	if quantity-reserved > s.products.Available(p, products) {
+		return ErrInsufficientStock
+	}
+	if location != "" && quantity-reserved > s.products.AvailableAt(p, location, products) {
+		return domain.ErrInsufficientStockAt
+	}
+	return nil
+}
+
+// Auxiliary function that returns the units of each location of a product that are not held.
+func (s *ServiceImpl) availableAt(p domain.Product, products []domain.Product) func(location string) int {
+	return func(location string) int {
+		return s.products.AvailableAt(p, location, products)
+	}
+}
+
+/*
+Auxiliary function that returns why the units of a lot of a product ("" if it is not lot-tracked)
+cannot be sold, if they cannot: they are blocked, or the lot has expired.
+*/
+func (s *ServiceImpl) unsellable(p *domain.Product) func(lot string) error {
+	today := domain.Today(s.location)
+	return func(lot string) error {
+		if i := p.FindLot(lot); i >= 0 && p.Lots[i].Expiration.Before(today) {
+			return ErrExpiredLot
+		}
+		if s.isBlocked(*p, lot) {
+			return ErrBlocked
+		}
+		return nil
+	}
+}
+
+// Auxiliary function that lets the movements other than sales take any stock.
+func takeAny(string) error {
+	return nil
+}
+
+/*
+Auxiliary function that returns how many units of a product are not blocked. Locations do not tell
+which lots their units come from, so a transfer is only allowed if these units can cover it.
+*/
+func (s *ServiceImpl) unblockedQuantity(p domain.Product) int {
+	if !p.IsLotTracked() {
+		if s.isBlocked(p, "") {
+			return 0
+		}
+		return p.Quantity
+	}
+	unblocked := 0
+	for _, lot := range p.Lots {
+		if !s.isBlocked(p, lot.Code) {
+			unblocked += lot.Quantity
+		}
+	}
+	return unblocked
+}
+
+/*
+Auxiliary function that checks that no movement was recorded with a reference, if given. It runs
+inside the product operations, where the ledger is appended to, so two operations can never record
+the same reference.
+*/
+func (s *ServiceImpl) checkReference(reference string) error {
+	if reference != "" && len(s.ledger.GetByReference(reference)) > 0 {
+		return ErrReferenceUsed
+	}
+	return nil
+}
+
+// Auxiliary function that checks that a location, if given, exists.
+func (s *ServiceImpl) checkLocation(code string) error {
+	if code == "" {
+		return nil
+	}
+	_, err := s.locations.GetByCode(code)
+	return err
+}
+
+// Auxiliary function that reports whether any blocker blocks the given lot of a product.
+func (s *ServiceImpl) isBlocked(p domain.Product, lot string) bool {
+	for _, blocker := range s.blockers {
+		if blocker.IsBlocked(p, lot) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Auxiliary function that returns the records function of a stock change, which writes the pending
+movements in the ledger once the products are stored and keeps the recorded ones.
+*/
+func (s *ServiceImpl) record(pending *[]domain.StockMovement, recorded *[]domain.StockMovement) func() error {
+	return func() error {
+		if len(*pending) == 0 {
+			return nil
+		}
+		movements, err := s.ledger.Append(*pending...)
+		if err != nil {
+			return err
+		}
+		*recorded = movements
+		return nil
+	}
+}
@@ -0,0 +1,136 @@
+package stock
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var errLedger = errors.New("ledger unavailable")
+
+// failingStore is a movement store that cannot append anything.
+type failingStore struct{}
+
+func (failingStore) Append(...domain.StockMovement) error {
+	return errLedger
+}
+
+func (failingStore) LoadAll() ([]domain.StockMovement, error) {
+	return nil, nil
+}
+
+func createServiceForTest(t *testing.T, movementStore store.AppendStore[domain.StockMovement]) (Service, product.Repository) {
+	dir := t.TempDir()
+
+	// Ten bottles of milk, and bread that can be sold before it is baked
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Quantity: 10, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 2, CodeValue: "BREAD1", Quantity: 1, AllowBackorder: true, Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	if movementStore == nil {
+		movementStore = store.NewJsonLinesStore[domain.StockMovement](filepath.Join(dir, "stock_movements.jsonl"))
+	}
+
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	return NewService(product.NewService(productRepository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil), NewLedger(nil, movementStore), nil, time.UTC), productRepository
+}
+
+func TestService_Adjust(t *testing.T) {
+	service, productRepository := createServiceForTest(t, nil)
+
+	tests := []struct {
+		name      string
+		productId int
+		delta     int
+		reason    domain.MovementReason
+		err       error
+	}{
+		{"Unknown reason", 1, -1, "theft", ErrInvalidReason},
+		{"Zero delta", 1, 0, domain.ReasonSale, ErrInvalidDelta},
+		{"Positive sale", 1, 1, domain.ReasonSale, ErrInvalidDelta},
+		{"Negative restock", 1, -1, domain.ReasonRestock, ErrInvalidDelta},
+		{"Positive shrinkage", 1, 1, domain.ReasonShrinkage, ErrInvalidDelta},
+		{"More than the stock", 1, -11, domain.ReasonSale, ErrInsufficientStock},
+		{"Unknown product", 99, 1, domain.ReasonRestock, product.ErrNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.Adjust(test.productId, test.delta, test.reason, "")
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	// Every movement is numbered and records the quantity it left
+	sold, err := service.Adjust(1, -4, domain.ReasonSale, "ticket-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, sold.Id)
+	assert.Equal(t, 6, sold.QuantityAfter)
+	restocked, err := service.Adjust(1, 5, domain.ReasonRestock, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, restocked.Id)
+	assert.Equal(t, 11, restocked.QuantityAfter)
+
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, 11, milk.Quantity)
+	movements, err := service.GetMovements(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{-4, 5}, []int{movements[0].Delta, movements[1].Delta})
+	assert.Equal(t, "ticket-1", movements[0].Reference)
+
+	// Products that allow backorders can go below zero
+	backordered, err := service.Adjust(2, -3, domain.ReasonSale, "")
+	assert.Nil(t, err)
+	assert.Equal(t, -2, backordered.QuantityAfter)
+}
+
+func TestService_Adjust_FailedLedger(t *testing.T) {
+	service, productRepository := createServiceForTest(t, failingStore{})
+
+	// A movement that cannot be recorded leaves the product as it was
+	_, err := service.Adjust(1, -4, domain.ReasonSale, "")
+	assert.ErrorIs(t, err, errLedger)
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, 10, milk.Quantity)
+}
+
+func TestService_ReceiveLot(t *testing.T) {
+	service, productRepository := createServiceForTest(t, nil)
+	today := domain.Today(time.UTC)
+
+	// Lots need units and an expiration after today
+	_, err := service.ReceiveLot(1, domain.LotRequest{Code: "A", Quantity: 0, Expiration: today.AddDays(30)})
+	assert.ErrorIs(t, err, ErrInvalidLot)
+	_, err = service.ReceiveLot(1, domain.LotRequest{Code: "A", Quantity: 5, Expiration: today})
+	assert.ErrorIs(t, err, product.ErrExpiredDate)
+	_, err = service.ReceiveLot(1, domain.LotRequest{Code: "A", Quantity: 5, Expiration: today.AddDays(-1)})
+	assert.ErrorIs(t, err, product.ErrExpiredDate)
+
+	// The expired milk becomes an "initial" lot, which does not make the product expired while a fresh lot has stock
+	_, err = productRepository.Modify(1, func(p *domain.Product) error {
+		p.Expiration = domain.NewDate(2021, time.January, 1)
+		return nil
+	})
+	assert.Nil(t, err)
+	received, err := service.ReceiveLot(1, domain.LotRequest{Code: "A", Quantity: 5, Expiration: today.AddDays(30)})
+	assert.Nil(t, err)
+	assert.Equal(t, 15, received.QuantityAfter)
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, today.AddDays(30), milk.Expiration)
+
+	// Only the fresh units can be sold
+	_, err = service.Adjust(1, -6, domain.ReasonSale, "")
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	sold, err := service.Adjust(1, -5, domain.ReasonSale, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.LotMovement{{Code: "A", Delta: -5}}, sold.Lots)
+
+	// Once the fresh lot runs out, the expired one is all the product has left
+	milk, _ = productRepository.GetById(1)
+	assert.Equal(t, domain.NewDate(2021, time.January, 1), milk.Expiration)
+}
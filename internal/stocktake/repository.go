@@ -0,0 +1,101 @@
+package stocktake
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("stocktake session not found")
+
+// Repository is the interface definition for the stocktake session storage
+type Repository interface {
+	GetById(id int) (domain.StocktakeSession, error)
+	GetAll() []domain.StocktakeSession
+	Create(session domain.StocktakeSession) (domain.StocktakeSession, error)
+	Update(session domain.StocktakeSession) (domain.StocktakeSession, error)
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu       sync.RWMutex
+	sessions []domain.StocktakeSession
+	store    store.DocumentStore[[]domain.StocktakeSession]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+sessions and saves every change in the provided store.
+*/
+func NewRepository(sessions []domain.StocktakeSession, sessionStore store.DocumentStore[[]domain.StocktakeSession]) Repository {
+	return &RepositoryImpl{
+		sessions: sessions,
+		store:    sessionStore,
+	}
+}
+
+// The GetById method returns a stocktake session by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.StocktakeSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, session := range r.sessions {
+		if session.Id == id {
+			return session, nil
+		}
+	}
+	return domain.StocktakeSession{}, ErrNotFound
+}
+
+// The GetAll method returns all the stocktake sessions
+func (r *RepositoryImpl) GetAll() []domain.StocktakeSession {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.StocktakeSession{}, r.sessions...)
+}
+
+// The Create method stores a new session with the next available ID and returns it.
+func (r *RepositoryImpl) Create(session domain.StocktakeSession) (domain.StocktakeSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.Id = len(r.sessions) + 1
+	if err := r.save(append(r.copyList(), session)); err != nil {
+		return domain.StocktakeSession{}, err
+	}
+	return session, nil
+}
+
+// The Update method replaces a stored session with the same ID.
+func (r *RepositoryImpl) Update(session domain.StocktakeSession) (domain.StocktakeSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.sessions {
+		if r.sessions[i].Id == session.Id {
+			sessions := r.copyList()
+			sessions[i] = session
+			if err := r.save(sessions); err != nil {
+				return domain.StocktakeSession{}, err
+			}
+			return session, nil
+		}
+	}
+	return domain.StocktakeSession{}, ErrNotFound
+}
+
+// Auxiliary function that returns a copy of the session list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.StocktakeSession {
+	return append([]domain.StocktakeSession{}, r.sessions...)
+}
+
+// Auxiliary function that saves the session list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(sessions []domain.StocktakeSession) error {
+	if err := r.store.Save(sessions); err != nil {
+		return err
+	}
+	r.sessions = sessions
+	return nil
+}
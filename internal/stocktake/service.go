@@ -0,0 +1,266 @@
+package stocktake
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+)
+
+var (
+	ErrNotOpen      = errors.New("stocktake session is not open")
+	ErrAlreadyOpen  = errors.New("there is already an open stocktake session for this location")
+	ErrUnknownCode  = errors.New("no product has the counted code value")
+	ErrInvalidCount = errors.New("counted quantity must not be negative")
+	ErrInvalidCSV   = errors.New("invalid counts CSV")
+)
+
+type Service interface {
+	GetAll() []domain.StocktakeSession
+	Open(request domain.StocktakeRequest) (domain.StocktakeSession, error)
+	Review(id int) (domain.StocktakeReview, error)
+	SubmitCounts(id int, counts []domain.StockCount) (domain.StocktakeReview, error)
+	Commit(id int) (domain.StocktakeReview, error)
+	Cancel(id int) (domain.StocktakeSession, error)
+}
+
+type ServiceImpl struct {
+	mu         sync.Mutex
+	repository Repository
+	products   product.Repository
+	stock      stock.Service
+}
+
+/*
+The NewService function returns a new instance of the service. Products are read straight from their
+repository to review the variances, since products outside their publication window are counted
+too, and committed sessions are applied through the stock service.
+*/
+func NewService(repository Repository, products product.Repository, stockService stock.Service) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+		stock:      stockService,
+	}
+}
+
+// The GetAll method returns all the stocktake sessions
+func (s *ServiceImpl) GetAll() []domain.StocktakeSession {
+	return s.repository.GetAll()
+}
+
+// The Open method opens a new stocktake session. There can only be one open session per location.
+func (s *ServiceImpl) Open(request domain.StocktakeRequest) (domain.StocktakeSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.repository.GetAll() {
+		if session.Status == domain.StocktakeOpen && session.Location == request.Location {
+			return domain.StocktakeSession{}, ErrAlreadyOpen
+		}
+	}
+
+	return s.repository.Create(domain.StocktakeSession{
+		Location:  request.Location,
+		Status:    domain.StocktakeOpen,
+		Counts:    []domain.StockCount{},
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// The Review method returns a stocktake session along with the variances of its counts.
+func (s *ServiceImpl) Review(id int) (domain.StocktakeReview, error) {
+	session, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.StocktakeReview{}, err
+	}
+	return s.review(session), nil
+}
+
+/*
+The SubmitCounts method records counted quantities in an open session. A product counted again
+keeps the last quantity submitted. If any count is invalid, none is recorded.
+*/
+func (s *ServiceImpl) SubmitCounts(id int, counts []domain.StockCount) (domain.StocktakeReview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.openSession(id)
+	if err != nil {
+		return domain.StocktakeReview{}, err
+	}
+
+	products := s.products.GetAll()
+	for _, count := range counts {
+		if count.Counted < 0 {
+			return domain.StocktakeReview{}, fmt.Errorf("%w: %s", ErrInvalidCount, count.CodeValue)
+		}
+		if domain.FindByCode(products, count.CodeValue) == nil {
+			return domain.StocktakeReview{}, fmt.Errorf("%w: %s", ErrUnknownCode, count.CodeValue)
+		}
+	}
+
+	// Replace the previous counts of the same products
+	merged := append([]domain.StockCount{}, session.Counts...)
+	for _, count := range counts {
+		replaced := false
+		for i := range merged {
+			if merged[i].CodeValue == count.CodeValue {
+				merged[i] = count
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, count)
+		}
+	}
+	session.Counts = merged
+
+	updatedSession, err := s.repository.Update(session)
+	if err != nil {
+		return domain.StocktakeReview{}, err
+	}
+	return s.review(updatedSession), nil
+}
+
+/*
+The Commit method adjusts the stock of the counted products to match their counts, recording the
+variances as ledger movements in a single atomic operation, and closes the session. The movements
+are recorded with the session reference, so if closing the session fails, committing it again only
+closes it.
+*/
+func (s *ServiceImpl) Commit(id int) (domain.StocktakeReview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.openSession(id)
+	if err != nil {
+		return domain.StocktakeReview{}, err
+	}
+
+	// The review is taken before the adjustments, so it shows the variances that were applied
+	review := s.review(session)
+	reference := fmt.Sprintf("stocktake-%d", session.Id)
+	movements, err := s.stock.ApplyCounts(session.Counts, session.Location, reference)
+	if errors.Is(err, stock.ErrReferenceUsed) {
+		movements, err = s.stock.GetMovementsByReference(reference), nil
+	}
+	if err != nil {
+		return domain.StocktakeReview{}, err
+	}
+
+	now := time.Now().UTC()
+	session.Status = domain.StocktakeCommitted
+	session.ClosedAt = &now
+	for _, movement := range movements {
+		session.Movements = append(session.Movements, movement.Id)
+	}
+	updatedSession, err := s.repository.Update(session)
+	if err != nil {
+		return domain.StocktakeReview{}, err
+	}
+
+	review.StocktakeSession = updatedSession
+	return review, nil
+}
+
+// The Cancel method closes an open session without adjusting any stock.
+func (s *ServiceImpl) Cancel(id int) (domain.StocktakeSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.openSession(id)
+	if err != nil {
+		return domain.StocktakeSession{}, err
+	}
+
+	now := time.Now().UTC()
+	session.Status = domain.StocktakeCancelled
+	session.ClosedAt = &now
+	return s.repository.Update(session)
+}
+
+/*
+The ParseCounts function reads counted quantities from CSV with a "code_value" and a "counted"
+column, in any order. Other columns are ignored.
+*/
+func ParseCounts(reader io.Reader) ([]domain.StockCount, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCSV, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidCSV)
+	}
+
+	// Find the columns in the header
+	codeColumn, countedColumn := -1, -1
+	for i, name := range records[0] {
+		switch strings.TrimSpace(strings.ToLower(name)) {
+		case "code_value":
+			codeColumn = i
+		case "counted":
+			countedColumn = i
+		}
+	}
+	if codeColumn < 0 || countedColumn < 0 {
+		return nil, fmt.Errorf("%w: header must have code_value and counted columns", ErrInvalidCSV)
+	}
+
+	counts := make([]domain.StockCount, 0, len(records)-1)
+	for line, record := range records[1:] {
+		counted, err := strconv.Atoi(strings.TrimSpace(record[countedColumn]))
+		codeValue := strings.TrimSpace(record[codeColumn])
+		if err != nil || codeValue == "" {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidCSV, line+2)
+		}
+		counts = append(counts, domain.StockCount{CodeValue: codeValue, Counted: counted})
+	}
+	return counts, nil
+}
+
+// Auxiliary function that returns a session if it is still open.
+func (s *ServiceImpl) openSession(id int) (domain.StocktakeSession, error) {
+	session, err := s.repository.GetById(id)
+	if err != nil {
+		return domain.StocktakeSession{}, err
+	}
+	if session.Status != domain.StocktakeOpen {
+		return domain.StocktakeSession{}, ErrNotOpen
+	}
+	return session, nil
+}
+
+// Auxiliary function that compares the counts of a session with the current system quantities.
+func (s *ServiceImpl) review(session domain.StocktakeSession) domain.StocktakeReview {
+	products := s.products.GetAll()
+	lines := []domain.StocktakeLine{}
+	for _, count := range session.Counts {
+		p := domain.FindByCode(products, count.CodeValue)
+		if p == nil {
+			continue
+		}
+
+		system := p.Quantity
+		if session.Location != "" {
+			system = p.QuantityAt(session.Location)
+		}
+		lines = append(lines, domain.StocktakeLine{
+			ProductId:      p.Id,
+			CodeValue:      p.CodeValue,
+			Name:           p.Name,
+			SystemQuantity: system,
+			Counted:        count.Counted,
+			Variance:       count.Counted - system,
+		})
+	}
+	return domain.StocktakeReview{StocktakeSession: session, Lines: lines}
+}
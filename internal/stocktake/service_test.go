@@ -0,0 +1,86 @@
+package stocktake
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/location"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/internal/stock"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createServiceForTest(t *testing.T) (Service, product.Repository) {
+	dir := t.TempDir()
+
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Name: "Milk", Quantity: 10, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 2, CodeValue: "CHEESE1", Name: "Cheese", Quantity: 5, Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	productService := product.NewService(productRepository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil)
+	locationService := location.NewService(location.NewRepository(nil, store.NewJsonDocumentStore[[]domain.Location](filepath.Join(dir, "locations.json"))))
+	ledger := stock.NewLedger(nil, store.NewJsonLinesStore[domain.StockMovement](filepath.Join(dir, "stock_movements.jsonl")))
+	stockService := stock.NewService(productService, ledger, locationService, time.UTC)
+	repository := NewRepository(nil, store.NewJsonDocumentStore[[]domain.StocktakeSession](filepath.Join(dir, "stocktakes.json")))
+
+	return NewService(repository, productRepository, stockService), productRepository
+}
+
+func TestService_Commit(t *testing.T) {
+	service, productRepository := createServiceForTest(t)
+
+	session, err := service.Open(domain.StocktakeRequest{})
+	assert.Nil(t, err)
+	_, err = service.Open(domain.StocktakeRequest{})
+	assert.ErrorIs(t, err, ErrAlreadyOpen)
+
+	// Invalid counts are not recorded, and later counts replace earlier ones
+	_, err = service.SubmitCounts(session.Id, []domain.StockCount{{CodeValue: "MILK1", Counted: 9}, {CodeValue: "BREAD1", Counted: 1}})
+	assert.ErrorIs(t, err, ErrUnknownCode)
+	_, err = service.SubmitCounts(session.Id, []domain.StockCount{{CodeValue: "MILK1", Counted: -1}})
+	assert.ErrorIs(t, err, ErrInvalidCount)
+	_, err = service.SubmitCounts(session.Id, []domain.StockCount{{CodeValue: "MILK1", Counted: 9}, {CodeValue: "CHEESE1", Counted: 5}})
+	assert.Nil(t, err)
+	review, err := service.SubmitCounts(session.Id, []domain.StockCount{{CodeValue: "MILK1", Counted: 7}})
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.StocktakeLine{
+		{ProductId: 1, CodeValue: "MILK1", Name: "Milk", SystemQuantity: 10, Counted: 7, Variance: -3},
+		{ProductId: 2, CodeValue: "CHEESE1", Name: "Cheese", SystemQuantity: 5, Counted: 5, Variance: 0},
+	}, review.Lines)
+
+	// Committing applies the variances once, as movements of the session
+	committed, err := service.Commit(session.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.StocktakeCommitted, committed.Status)
+	assert.Len(t, committed.Movements, 1)
+	assert.Equal(t, -3, committed.Lines[0].Variance)
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, 7, milk.Quantity)
+
+	_, err = service.Commit(session.Id)
+	assert.ErrorIs(t, err, ErrNotOpen)
+	_, err = service.Cancel(session.Id)
+	assert.ErrorIs(t, err, ErrNotOpen)
+}
+
+func TestParseCounts(t *testing.T) {
+	counts, err := ParseCounts(strings.NewReader("name,counted,code_value\nMilk, 7 ,MILK1\nCheese,5,CHEESE1\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.StockCount{{CodeValue: "MILK1", Counted: 7}, {CodeValue: "CHEESE1", Counted: 5}}, counts)
+
+	for _, document := range []string{
+		"",
+		"code_value,quantity\nMILK1,7\n",
+		"code_value,counted\nMILK1,seven\n",
+		"code_value,counted\n,7\n",
+		"code_value,counted\nMILK1\n",
+	} {
+		_, err := ParseCounts(strings.NewReader(document))
+		assert.ErrorIs(t, err, ErrInvalidCSV, document)
+	}
+}
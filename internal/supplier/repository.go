@@ -0,0 +1,101 @@
+package supplier
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+var ErrNotFound = errors.New("supplier not found")
+
+// Repository is the interface definition for the supplier storage
+type Repository interface {
+	GetById(id int) (domain.Supplier, error)
+	GetAll() []domain.Supplier
+	Create(supplier domain.Supplier) (domain.Supplier, error)
+	Update(supplier domain.Supplier) (domain.Supplier, error)
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu        sync.RWMutex
+	suppliers []domain.Supplier
+	store     store.DocumentStore[[]domain.Supplier]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given
+suppliers and saves every change in the provided store.
+*/
+func NewRepository(suppliers []domain.Supplier, supplierStore store.DocumentStore[[]domain.Supplier]) Repository {
+	return &RepositoryImpl{
+		suppliers: suppliers,
+		store:     supplierStore,
+	}
+}
+
+// The GetById method returns a supplier by its ID
+func (r *RepositoryImpl) GetById(id int) (domain.Supplier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, supplier := range r.suppliers {
+		if supplier.Id == id {
+			return supplier, nil
+		}
+	}
+	return domain.Supplier{}, ErrNotFound
+}
+
+// The GetAll method returns all the suppliers
+func (r *RepositoryImpl) GetAll() []domain.Supplier {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Supplier{}, r.suppliers...)
+}
+
+// The Create method stores a new supplier with the next available ID and returns it.
+func (r *RepositoryImpl) Create(supplier domain.Supplier) (domain.Supplier, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	supplier.Id = len(r.suppliers) + 1
+	if err := r.save(append(r.copyList(), supplier)); err != nil {
+		return domain.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+// The Update method replaces a stored supplier with the same ID.
+func (r *RepositoryImpl) Update(supplier domain.Supplier) (domain.Supplier, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.suppliers {
+		if r.suppliers[i].Id == supplier.Id {
+			suppliers := r.copyList()
+			suppliers[i] = supplier
+			if err := r.save(suppliers); err != nil {
+				return domain.Supplier{}, err
+			}
+			return supplier, nil
+		}
+	}
+	return domain.Supplier{}, ErrNotFound
+}
+
+// Auxiliary function that returns a copy of the supplier list, so changes can be discarded if saving fails.
+func (r *RepositoryImpl) copyList() []domain.Supplier {
+	return append([]domain.Supplier{}, r.suppliers...)
+}
+
+// Auxiliary function that saves the supplier list in the store and, if it succeeds, keeps it in memory.
+func (r *RepositoryImpl) save(suppliers []domain.Supplier) error {
+	if err := r.store.Save(suppliers); err != nil {
+		return err
+	}
+	r.suppliers = suppliers
+	return nil
+}
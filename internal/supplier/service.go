@@ -0,0 +1,69 @@
+package supplier
+
+import (
+	"errors"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+)
+
+var ErrInvalidLeadTime = errors.New("supplier lead time must not be negative")
+
+type Service interface {
+	GetAll() []domain.Supplier
+	GetById(id int) (domain.Supplier, error)
+	Create(supplier domain.Supplier) (domain.Supplier, error)
+	Update(id int, supplier domain.Supplier) (domain.Supplier, error)
+	LinkProduct(id int, productId int) (domain.Product, error)
+}
+
+type ServiceImpl struct {
+	repository Repository
+	products   product.Service
+}
+
+// The NewService function returns a new instance of the service. Products are linked to suppliers through the product service.
+func NewService(repository Repository, products product.Service) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+	}
+}
+
+// The GetAll method returns all the suppliers
+func (s *ServiceImpl) GetAll() []domain.Supplier {
+	return s.repository.GetAll()
+}
+
+// The GetById method returns a supplier by its ID
+func (s *ServiceImpl) GetById(id int) (domain.Supplier, error) {
+	return s.repository.GetById(id)
+}
+
+// The Create method stores a new supplier.
+func (s *ServiceImpl) Create(supplier domain.Supplier) (domain.Supplier, error) {
+	if supplier.LeadTimeDays < 0 {
+		return domain.Supplier{}, ErrInvalidLeadTime
+	}
+	return s.repository.Create(supplier)
+}
+
+// The Update method replaces the data of a supplier.
+func (s *ServiceImpl) Update(id int, supplier domain.Supplier) (domain.Supplier, error) {
+	if supplier.LeadTimeDays < 0 {
+		return domain.Supplier{}, ErrInvalidLeadTime
+	}
+	supplier.Id = id
+	return s.repository.Update(supplier)
+}
+
+// The LinkProduct method makes a supplier the supplier of a product, replacing any previous one.
+func (s *ServiceImpl) LinkProduct(id int, productId int) (domain.Product, error) {
+	if _, err := s.repository.GetById(id); err != nil {
+		return domain.Product{}, err
+	}
+	return s.products.Modify(productId, func(p *domain.Product) error {
+		p.SupplierId = id
+		return nil
+	})
+}
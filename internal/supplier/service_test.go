@@ -0,0 +1,58 @@
+package supplier
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createServiceForTest(t *testing.T) Service {
+	dir := t.TempDir()
+
+	products := []domain.Product{{Id: 1, CodeValue: "MILK1", Quantity: 10, Expiration: domain.NewDate(2030, time.January, 1)}}
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	productService := product.NewService(productRepository, time.UTC, nil, nil, nil, nil, domain.TaxTable{}, nil)
+	repository := NewRepository(nil, store.NewJsonDocumentStore[[]domain.Supplier](filepath.Join(dir, "suppliers.json")))
+	return NewService(repository, productService)
+}
+
+func TestService_CreateAndUpdate(t *testing.T) {
+	service := createServiceForTest(t)
+
+	_, err := service.Create(domain.Supplier{Name: "Fresh Farms", LeadTimeDays: -1})
+	assert.ErrorIs(t, err, ErrInvalidLeadTime)
+	created, err := service.Create(domain.Supplier{Name: "Fresh Farms", LeadTimeDays: 7})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, created.Id)
+
+	// Updates keep the ID of the supplier, whatever the data says
+	updated, err := service.Update(created.Id, domain.Supplier{Id: 5, Name: "Fresh Farms Ltd.", LeadTimeDays: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, created.Id, updated.Id)
+	stored, err := service.GetById(created.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, stored.LeadTimeDays)
+	_, err = service.Update(created.Id, domain.Supplier{Name: "Fresh Farms", LeadTimeDays: -1})
+	assert.ErrorIs(t, err, ErrInvalidLeadTime)
+	_, err = service.Update(9, domain.Supplier{Name: "Nobody"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_LinkProduct(t *testing.T) {
+	service := createServiceForTest(t)
+	created, err := service.Create(domain.Supplier{Name: "Fresh Farms", LeadTimeDays: 7})
+	assert.Nil(t, err)
+
+	linked, err := service.LinkProduct(created.Id, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, created.Id, linked.SupplierId)
+	_, err = service.LinkProduct(9, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = service.LinkProduct(created.Id, 9)
+	assert.ErrorIs(t, err, product.ErrNotFound)
+}
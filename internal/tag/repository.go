@@ -0,0 +1,51 @@
+package tag
+
+import (
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/pkg/store"
+)
+
+// Repository is the interface definition for the storage of the created tags
+type Repository interface {
+	GetAll() []domain.Tag
+	Update(tags []domain.Tag) error
+}
+
+// RepositoryImpl is the implementation of the repository interface
+type RepositoryImpl struct {
+	mu    sync.RWMutex
+	tags  []domain.Tag
+	store store.DocumentStore[[]domain.Tag]
+}
+
+/*
+The NewRepository function returns a new instance of the repository. It starts with the given tags
+and saves every update in the provided store.
+*/
+func NewRepository(tags []domain.Tag, tagStore store.DocumentStore[[]domain.Tag]) Repository {
+	return &RepositoryImpl{
+		tags:  tags,
+		store: tagStore,
+	}
+}
+
+// The GetAll method returns all the created tags
+func (r *RepositoryImpl) GetAll() []domain.Tag {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.Tag{}, r.tags...)
+}
+
+// The Update method replaces the created tags, saving them in the store first.
+func (r *RepositoryImpl) Update(tags []domain.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.store.Save(tags); err != nil {
+		return err
+	}
+	r.tags = tags
+	return nil
+}
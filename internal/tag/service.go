@@ -0,0 +1,292 @@
+package tag
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+)
+
+var (
+	ErrNotFound        = errors.New("tag not found")
+	ErrDuplicate       = errors.New("tag already exists")
+	ErrInvalidTagsMode = errors.New("invalid tags mode, expected all or any")
+)
+
+type Service interface {
+	GetAll() []domain.Tag
+	GetByName(name string) (domain.Tag, error)
+	Create(tag domain.Tag) (domain.Tag, error)
+	Rename(name string, newName string) (domain.Tag, error)
+	Merge(name string, sources []string) (domain.Tag, error)
+	Delete(name string) error
+	Tagged(products []domain.Product, tags []string, mode string) ([]domain.Product, error)
+}
+
+type ServiceImpl struct {
+	// mu makes the changes of the created tags atomic with the rewrite of the products that have them
+	mu         sync.Mutex
+	repository Repository
+	products   product.Repository
+}
+
+/*
+The NewService function returns a new instance of the service. The product repository is used to
+list and count the tags in use, and to rewrite the products when tags are renamed, merged or deleted.
+*/
+func NewService(repository Repository, products product.Repository) Service {
+	return &ServiceImpl{
+		repository: repository,
+		products:   products,
+	}
+}
+
+// The GetAll method returns the created tags and those in use by any product, sorted by name, with their product counts
+func (s *ServiceImpl) GetAll() []domain.Tag {
+	counts := map[string]int{}
+	for _, p := range s.products.GetAll() {
+		for _, tag := range p.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := s.repository.GetAll()
+	for i := range tags {
+		tags[i].ProductCount = counts[tags[i].Name]
+		delete(counts, tags[i].Name)
+	}
+	for name, count := range counts {
+		tags = append(tags, domain.Tag{Name: name, ProductCount: count})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+// The GetByName method returns a tag, created or in use, by its name
+func (s *ServiceImpl) GetByName(name string) (domain.Tag, error) {
+	name, err := domain.NormalizeTag(name)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+
+	tags := s.GetAll()
+	if i := findByName(tags, name); i >= 0 {
+		return tags[i], nil
+	}
+	return domain.Tag{}, ErrNotFound
+}
+
+// The Create method creates a tag with a description before any product has it. Tags in use cannot be created again.
+func (s *ServiceImpl) Create(tag domain.Tag) (domain.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, err := domain.NormalizeTag(tag.Name)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	if findByName(s.GetAll(), name) >= 0 {
+		return domain.Tag{}, ErrDuplicate
+	}
+
+	created := domain.Tag{Name: name, Description: tag.Description}
+	if err := s.repository.Update(append(s.repository.GetAll(), created)); err != nil {
+		return domain.Tag{}, err
+	}
+	return created, nil
+}
+
+/*
+The Rename method renames a tag on every product that has it, in a single atomic change of the
+products. The new name cannot be in use already; such tags are merged instead.
+*/
+func (s *ServiceImpl) Rename(name string, newName string) (domain.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, newName, err := s.existing(name, newName)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	if findByName(s.GetAll(), newName) >= 0 {
+		return domain.Tag{}, ErrDuplicate
+	}
+
+	if err := s.retag([]string{name}, newName); err != nil {
+		return domain.Tag{}, err
+	}
+	return s.GetByName(newName)
+}
+
+/*
+The Merge method replaces the source tags with the given one on every product, in a single atomic
+change of the products, and removes the sources. The target tag is created if it does not exist.
+*/
+func (s *ServiceImpl) Merge(name string, sources []string) (domain.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, err := domain.NormalizeTag(name)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	sources, err = domain.NormalizeTags(sources)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	tags := s.GetAll()
+	for _, source := range sources {
+		if findByName(tags, source) < 0 {
+			return domain.Tag{}, ErrNotFound
+		}
+	}
+
+	if err := s.retag(sources, name); err != nil {
+		return domain.Tag{}, err
+	}
+	return s.GetByName(name)
+}
+
+// The Delete method removes a tag from every product that has it, in a single atomic change of the products.
+func (s *ServiceImpl) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, err := domain.NormalizeTag(name)
+	if err != nil {
+		return err
+	}
+	if findByName(s.GetAll(), name) < 0 {
+		return ErrNotFound
+	}
+	return s.retag([]string{name}, "")
+}
+
+/*
+The Tagged method returns the given products that match a tag filter: those with every given tag
+in "all" mode (the default), or with at least one of them in "any" mode.
+*/
+func (s *ServiceImpl) Tagged(products []domain.Product, tags []string, mode string) ([]domain.Product, error) {
+	if mode == "" {
+		mode = domain.TagsAll
+	}
+	if mode != domain.TagsAll && mode != domain.TagsAny {
+		return nil, ErrInvalidTagsMode
+	}
+	tags, err := domain.NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []domain.Product{}
+	for _, p := range products {
+		if p.MatchesTags(tags, mode) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
+}
+
+// Auxiliary function that normalizes the name of an existing tag and a new name for it.
+func (s *ServiceImpl) existing(name string, newName string) (string, string, error) {
+	name, err := domain.NormalizeTag(name)
+	if err != nil {
+		return "", "", err
+	}
+	newName, err = domain.NormalizeTag(newName)
+	if err != nil {
+		return "", "", err
+	}
+	if findByName(s.GetAll(), name) < 0 {
+		return "", "", ErrNotFound
+	}
+	return name, newName, nil
+}
+
+/*
+Auxiliary function that replaces some tags with another one (or just removes them if it is empty)
+on every product at once, and saves the created tags along with the products, so both change or
+neither does. The replacement keeps the description of the first replaced tag that has one, unless
+it already has its own, and stays if it is among the replaced tags.
+*/
+func (s *ServiceImpl) retag(replaced []string, replacement string) error {
+	var description string
+	tags := []domain.Tag{}
+	for _, tag := range s.repository.GetAll() {
+		if contains(replaced, tag.Name) && tag.Name != replacement {
+			if description == "" {
+				description = tag.Description
+			}
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	if replacement != "" {
+		if i := findByName(tags, replacement); i < 0 {
+			tags = append(tags, domain.Tag{Name: replacement, Description: description})
+		} else if tags[i].Description == "" {
+			tags[i].Description = description
+		}
+	}
+
+	_, err := s.products.ModifyAll(func(products []domain.Product) error {
+		for i := range products {
+			products[i].Tags = replaceTags(products[i].Tags, replaced, replacement)
+		}
+		return nil
+	}, func() error {
+		return s.repository.Update(tags)
+	})
+	return err
+}
+
+// Auxiliary function that replaces some tags of a product with another one, keeping them sorted and unique.
+func replaceTags(tags []string, replaced []string, replacement string) []string {
+	result := []string{}
+	changed := false
+	for _, tag := range tags {
+		if contains(replaced, tag) {
+			changed = true
+			continue
+		}
+		result = append(result, tag)
+	}
+	if !changed {
+		return tags
+	}
+	if replacement != "" {
+		result = append(result, replacement)
+	}
+
+	// Tags are already normalized, so this only sorts them and removes a repeated replacement
+	result, _ = domain.NormalizeTags(result)
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Auxiliary function that returns the position of a tag in a list, or -1 if it is not there.
+func findByName(tags []domain.Tag, name string) int {
+	for i, tag := range tags {
+		if tag.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Auxiliary function that checks if a list of names contains the given one.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package tag
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soppibb/practica-go-web/internal/domain"
+	"github.com/soppibb/practica-go-web/internal/product"
+	"github.com/soppibb/practica-go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createServiceForTest(t *testing.T) (Service, product.Repository) {
+	dir := t.TempDir()
+
+	tags := []domain.Tag{{Name: "bio", Description: "Certified organic products"}, {Name: "seasonal"}}
+	products := []domain.Product{
+		{Id: 1, CodeValue: "MILK1", Tags: []string{"bio", "dairy"}, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 2, CodeValue: "BRIE1", Tags: []string{"dairy", "organic"}, Expiration: domain.NewDate(2030, time.January, 1)},
+		{Id: 3, CodeValue: "WATER1", Expiration: domain.NewDate(2030, time.January, 1)},
+	}
+	repository := NewRepository(tags, store.NewJsonDocumentStore[[]domain.Tag](filepath.Join(dir, "tags.json")))
+	productRepository := product.NewRepository(products, store.NewJsonStore(filepath.Join(dir, "products.json")))
+	return NewService(repository, productRepository), productRepository
+}
+
+func TestService_GetAll(t *testing.T) {
+	service, _ := createServiceForTest(t)
+
+	// Created tags and tags in use are listed together, by name
+	assert.Equal(t, []domain.Tag{
+		{Name: "bio", Description: "Certified organic products", ProductCount: 1},
+		{Name: "dairy", ProductCount: 2},
+		{Name: "organic", ProductCount: 1},
+		{Name: "seasonal"},
+	}, service.GetAll())
+
+	_, err := service.Create(domain.Tag{Name: " Dairy "})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	created, err := service.Create(domain.Tag{Name: " Vegan "})
+	assert.Nil(t, err)
+	assert.Equal(t, "vegan", created.Name)
+	_, err = service.Create(domain.Tag{Name: "a,b"})
+	assert.ErrorIs(t, err, domain.ErrInvalidTag)
+}
+
+func TestService_Rename(t *testing.T) {
+	service, productRepository := createServiceForTest(t)
+
+	_, err := service.Rename("organic", "bio")
+	assert.ErrorIs(t, err, ErrDuplicate)
+	_, err = service.Rename("frozen", "cold")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	renamed, err := service.Rename("Dairy", "milk products")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, renamed.ProductCount)
+	brie, _ := productRepository.GetById(2)
+	assert.Equal(t, []string{"milk products", "organic"}, brie.Tags)
+}
+
+func TestService_Merge(t *testing.T) {
+	service, productRepository := createServiceForTest(t)
+
+	_, err := service.Merge("bio", []string{"organic", "frozen"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The target keeps its description and stays listed when it is among the sources
+	merged, err := service.Merge("bio", []string{"organic", "bio"})
+	assert.Nil(t, err)
+	assert.Equal(t, domain.Tag{Name: "bio", Description: "Certified organic products", ProductCount: 2}, merged)
+	brie, _ := productRepository.GetById(2)
+	assert.Equal(t, []string{"bio", "dairy"}, brie.Tags)
+	_, err = service.GetByName("organic")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_Delete(t *testing.T) {
+	service, productRepository := createServiceForTest(t)
+
+	assert.Nil(t, service.Delete("bio"))
+	assert.ErrorIs(t, service.Delete("bio"), ErrNotFound)
+	milk, _ := productRepository.GetById(1)
+	assert.Equal(t, []string{"dairy"}, milk.Tags)
+}
+
+func TestService_Tagged(t *testing.T) {
+	service, productRepository := createServiceForTest(t)
+	products := productRepository.GetAll()
+
+	tests := []struct {
+		tags     []string
+		mode     string
+		expected []int
+	}{
+		{[]string{"dairy"}, "", []int{1, 2}},
+		{[]string{"Dairy", "bio"}, domain.TagsAll, []int{1}},
+		{[]string{"bio", "organic"}, domain.TagsAny, []int{1, 2}},
+		{[]string{"frozen"}, domain.TagsAny, []int{}},
+	}
+	for _, test := range tests {
+		tagged, err := service.Tagged(products, test.tags, test.mode)
+		assert.Nil(t, err)
+		ids := []int{}
+		for _, p := range tagged {
+			ids = append(ids, p.Id)
+		}
+		assert.Equal(t, test.expected, ids)
+	}
+
+	_, err := service.Tagged(products, []string{"dairy"}, "some")
+	assert.ErrorIs(t, err, ErrInvalidTagsMode)
+}
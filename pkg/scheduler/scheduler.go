@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a unit of background work. It receives the instant it was triggered at.
+type Job func(now time.Time) error
+
+// The entry struct represents a job registered in the scheduler.
+type entry struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// The Scheduler struct runs registered jobs periodically in the background.
+type Scheduler struct {
+	entries []entry
+}
+
+// The New function returns a new scheduler without jobs.
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// The Every method registers a job that runs once when the scheduler starts and then every interval.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.entries = append(s.entries, entry{
+		name:     name,
+		interval: interval,
+		job:      job,
+	})
+}
+
+/*
+The Start method runs every registered job in its own goroutine until the context is cancelled.
+Job errors are logged and do not stop the job from running again.
+*/
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, e := range s.entries {
+		go s.run(ctx, e)
+	}
+}
+
+// Auxiliary function that runs a single job on its interval.
+func (s *Scheduler) run(ctx context.Context, e entry) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	now := time.Now()
+	for {
+		if err := e.job(now); err != nil {
+			log.Printf("scheduler: job %q failed: %v\n", e.name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+	}
+}
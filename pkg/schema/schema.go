@@ -0,0 +1,454 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidSchema = errors.New("invalid JSON schema")
+	ErrMismatch      = errors.New("value does not match the JSON schema")
+)
+
+// annotations lists the keywords that describe a value without constraining it.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "format": true, "readOnly": true, "writeOnly": true,
+}
+
+// types lists the JSON Schema type names.
+var types = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+/*
+The Schema struct represents a compiled JSON Schema. Only a subset of the specification is
+supported: type, enum, const, properties, required, additionalProperties, items, minItems,
+maxItems, uniqueItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum and
+exclusiveMaximum, besides annotations such as title or description. Schemas with any other keyword
+(such as $ref or anyOf) are rejected, so they never validate less than they seem to.
+*/
+type Schema struct {
+	// rejectAll is set by the false schema, which no value matches
+	rejectAll bool
+
+	types            []string
+	enum             []interface{}
+	constant         *interface{}
+	properties       map[string]*Schema
+	required         []string
+	additional       *Schema
+	items            *Schema
+	minItems         *int
+	maxItems         *int
+	uniqueItems      bool
+	minLength        *int
+	maxLength        *int
+	pattern          *regexp.Regexp
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+}
+
+// The Compile function parses and checks a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	document, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return compile(document, "")
+}
+
+// The ValidateJSON method checks that a JSON document matches the schema.
+func (s *Schema) ValidateJSON(data []byte) error {
+	value, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMismatch, err)
+	}
+	return s.validate(value, "")
+}
+
+// Auxiliary function that decodes a JSON document, keeping numbers as written.
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Auxiliary function that compiles the schema found at the given path of a schema document.
+func compile(node interface{}, path string) (*Schema, error) {
+	if accept, ok := node.(bool); ok {
+		return &Schema{rejectAll: !accept}, nil
+	}
+	keywords, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, invalid(path, "a schema must be an object or a boolean")
+	}
+
+	s := &Schema{}
+	for keyword, value := range keywords {
+		var err error
+		switch keyword {
+		case "type":
+			s.types, err = compileTypes(value, path)
+		case "enum":
+			values, ok := value.([]interface{})
+			if !ok {
+				return nil, invalid(path, "enum must be an array")
+			}
+			s.enum = values
+		case "const":
+			constant := value
+			s.constant = &constant
+		case "properties":
+			s.properties, err = compileProperties(value, path)
+		case "required":
+			s.required, err = compileStrings(value, path, keyword)
+		case "additionalProperties":
+			s.additional, err = compile(value, path+"/additionalProperties")
+		case "items":
+			s.items, err = compile(value, path+"/items")
+		case "minItems":
+			s.minItems, err = compileCount(value, path, keyword)
+		case "maxItems":
+			s.maxItems, err = compileCount(value, path, keyword)
+		case "minLength":
+			s.minLength, err = compileCount(value, path, keyword)
+		case "maxLength":
+			s.maxLength, err = compileCount(value, path, keyword)
+		case "uniqueItems":
+			unique, ok := value.(bool)
+			if !ok {
+				return nil, invalid(path, "uniqueItems must be a boolean")
+			}
+			s.uniqueItems = unique
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				return nil, invalid(path, "pattern must be a string")
+			}
+			if s.pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, invalid(path, "pattern is not a valid regular expression")
+			}
+		case "minimum":
+			s.minimum, err = compileNumber(value, path, keyword)
+		case "maximum":
+			s.maximum, err = compileNumber(value, path, keyword)
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = compileNumber(value, path, keyword)
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = compileNumber(value, path, keyword)
+		default:
+			if !annotations[keyword] {
+				return nil, invalid(path, fmt.Sprintf("keyword %q is not supported", keyword))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Auxiliary function that compiles the type keyword, a type name or a list of them.
+func compileTypes(value interface{}, path string) ([]string, error) {
+	if name, ok := value.(string); ok {
+		value = []interface{}{name}
+	}
+	names, err := compileStrings(value, path, "type")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if !types[name] {
+			return nil, invalid(path, fmt.Sprintf("unknown type %q", name))
+		}
+	}
+	return names, nil
+}
+
+// Auxiliary function that compiles the schemas of the properties keyword.
+func compileProperties(value interface{}, path string) (map[string]*Schema, error) {
+	nodes, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, invalid(path, "properties must be an object")
+	}
+	properties := make(map[string]*Schema, len(nodes))
+	for name, node := range nodes {
+		property, err := compile(node, path+"/properties/"+name)
+		if err != nil {
+			return nil, err
+		}
+		properties[name] = property
+	}
+	return properties, nil
+}
+
+// Auxiliary function that compiles a keyword whose value is a list of strings.
+func compileStrings(value interface{}, path string, keyword string) ([]string, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, invalid(path, keyword+" must be an array of strings")
+	}
+	strs := make([]string, len(values))
+	for i, v := range values {
+		if strs[i], ok = v.(string); !ok {
+			return nil, invalid(path, keyword+" must be an array of strings")
+		}
+	}
+	return strs, nil
+}
+
+// Auxiliary function that compiles a keyword whose value is a non-negative integer.
+func compileCount(value interface{}, path string, keyword string) (*int, error) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return nil, invalid(path, keyword+" must be a non-negative integer")
+	}
+	count, err := number.Int64()
+	if err != nil || count < 0 {
+		return nil, invalid(path, keyword+" must be a non-negative integer")
+	}
+	n := int(count)
+	return &n, nil
+}
+
+// Auxiliary function that compiles a keyword whose value is a number.
+func compileNumber(value interface{}, path string, keyword string) (*float64, error) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return nil, invalid(path, keyword+" must be a number")
+	}
+	f, err := number.Float64()
+	if err != nil {
+		return nil, invalid(path, keyword+" must be a number")
+	}
+	return &f, nil
+}
+
+// Auxiliary function that validates the value found at the given path of a document.
+func (s *Schema) validate(value interface{}, path string) error {
+	if s.rejectAll {
+		return mismatch(path, "no value is allowed")
+	}
+	if len(s.types) > 0 && !s.hasType(value) {
+		return mismatch(path, "expected "+strings.Join(s.types, " or "))
+	}
+	if s.enum != nil && !containsEqual(s.enum, value) {
+		return mismatch(path, "not one of the allowed values")
+	}
+	if s.constant != nil && !equal(*s.constant, value) {
+		return mismatch(path, "not the allowed value")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.validateObject(v, path)
+	case []interface{}:
+		return s.validateArray(v, path)
+	case string:
+		return s.validateString(v, path)
+	case json.Number:
+		return s.validateNumber(v, path)
+	}
+	return nil
+}
+
+// Auxiliary function that validates the properties of an object.
+func (s *Schema) validateObject(object map[string]interface{}, path string) error {
+	for _, name := range s.required {
+		if _, ok := object[name]; !ok {
+			return mismatch(path, fmt.Sprintf("missing required property %q", name))
+		}
+	}
+
+	// Properties are checked in order, so the same document always reports the same error
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := s.properties[name]
+		if !ok {
+			property = s.additional
+		}
+		if property == nil {
+			continue
+		}
+		if err := property.validate(object[name], path+"/"+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Auxiliary function that validates the items of an array.
+func (s *Schema) validateArray(array []interface{}, path string) error {
+	if s.minItems != nil && len(array) < *s.minItems {
+		return mismatch(path, fmt.Sprintf("expected at least %d items", *s.minItems))
+	}
+	if s.maxItems != nil && len(array) > *s.maxItems {
+		return mismatch(path, fmt.Sprintf("expected at most %d items", *s.maxItems))
+	}
+	for i, item := range array {
+		if s.uniqueItems && containsEqual(array[:i], item) {
+			return mismatch(path, "items must be unique")
+		}
+		if s.items != nil {
+			if err := s.items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Auxiliary function that validates a string, whose length is counted in characters.
+func (s *Schema) validateString(str string, path string) error {
+	length := utf8.RuneCountInString(str)
+	if s.minLength != nil && length < *s.minLength {
+		return mismatch(path, fmt.Sprintf("expected at least %d characters", *s.minLength))
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		return mismatch(path, fmt.Sprintf("expected at most %d characters", *s.maxLength))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return mismatch(path, "does not match the pattern "+s.pattern.String())
+	}
+	return nil
+}
+
+// Auxiliary function that validates a number against the bounds of the schema.
+func (s *Schema) validateNumber(number json.Number, path string) error {
+	f, err := number.Float64()
+	if err != nil {
+		return mismatch(path, "not a valid number")
+	}
+	switch {
+	case s.minimum != nil && f < *s.minimum:
+		return mismatch(path, fmt.Sprintf("must be at least %v", *s.minimum))
+	case s.maximum != nil && f > *s.maximum:
+		return mismatch(path, fmt.Sprintf("must be at most %v", *s.maximum))
+	case s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum:
+		return mismatch(path, fmt.Sprintf("must be greater than %v", *s.exclusiveMinimum))
+	case s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum:
+		return mismatch(path, fmt.Sprintf("must be less than %v", *s.exclusiveMaximum))
+	}
+	return nil
+}
+
+// Auxiliary function that checks if a value has one of the types of the schema.
+func (s *Schema) hasType(value interface{}) bool {
+	for _, name := range s.types {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case json.Number:
+			if name == "number" {
+				return true
+			}
+			if f, err := v.Float64(); name == "integer" && err == nil && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Auxiliary function that checks if a list of decoded values contains one equal to the given value.
+func containsEqual(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Auxiliary function that compares two decoded values as JSON Schema does, so 1 and 1.0 are equal.
+func equal(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fa, errA := a.Float64()
+		fb, errB := b.Float64()
+		return errA == nil && errB == nil && fa == fb
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// Auxiliary function that returns an error for an invalid schema at the given path.
+func invalid(path string, message string) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidSchema, pointer(path), message)
+}
+
+// Auxiliary function that returns an error for a value that does not match at the given path.
+func mismatch(path string, message string) error {
+	return fmt.Errorf("%w: %s: %s", ErrMismatch, pointer(path), message)
+}
+
+// Auxiliary function that returns the JSON pointer of a path, which is "/" for the whole document.
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema_ValidateJSON(t *testing.T) {
+	tests := []struct {
+		keyword string
+		schema  string
+		value   string
+		valid   bool
+	}{
+		// Integers are numbers without a fractional part, however they are written
+		{"integer", `{"type":"integer"}`, `3`, true},
+		{"integer", `{"type":"integer"}`, `3.0`, true},
+		{"integer", `{"type":"integer"}`, `3.5`, false},
+		{"integer", `{"type":"integer"}`, `"3"`, false},
+		{"number", `{"type":"number"}`, `3.5`, true},
+		{"number", `{"type":"number"}`, `3`, true},
+		{"number", `{"type":"number"}`, `true`, false},
+
+		// Exclusive bounds leave the bound itself out, inclusive ones keep it
+		{"minimum", `{"minimum":0}`, `0`, true},
+		{"minimum", `{"minimum":0}`, `-0.5`, false},
+		{"exclusiveMinimum", `{"exclusiveMinimum":0}`, `0`, false},
+		{"exclusiveMinimum", `{"exclusiveMinimum":0}`, `0.1`, true},
+		{"maximum", `{"maximum":10}`, `10`, true},
+		{"exclusiveMaximum", `{"exclusiveMaximum":10}`, `10`, false},
+		{"exclusiveMaximum", `{"exclusiveMaximum":10}`, `9.99`, true},
+
+		// 1 and 1.0 are the same number, but not the same as "1"
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,2]`, true},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,1.0]`, false},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,"1"]`, true},
+		{"uniqueItems", `{"uniqueItems":true}`, `[{"a":1},{"a":1.0}]`, false},
+		{"uniqueItems", `{"uniqueItems":false}`, `[1,1]`, true},
+
+		// Properties not listed match the additionalProperties schema
+		{"additionalProperties", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1}`, true},
+		{"additionalProperties", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`, false},
+		{"additionalProperties", `{"properties":{"a":{}},"additionalProperties":{"type":"string"}}`, `{"a":1,"b":"x"}`, true},
+		{"additionalProperties", `{"properties":{"a":{}},"additionalProperties":{"type":"string"}}`, `{"a":1,"b":2}`, false},
+
+		// Patterns are not anchored unless they say so
+		{"pattern", `{"pattern":"^[A-Z]{3}$"}`, `"ABC"`, true},
+		{"pattern", `{"pattern":"^[A-Z]{3}$"}`, `"ABCD"`, false},
+		{"pattern", `{"pattern":"^[A-Z]{3}$"}`, `"abc"`, false},
+		{"pattern", `{"pattern":"[0-9]"}`, `"a1b"`, true},
+		{"pattern", `{"pattern":"[0-9]"}`, `3`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.keyword+" "+test.value, func(t *testing.T) {
+			s, err := Compile([]byte(test.schema))
+			assert.Nil(t, err)
+
+			err = s.ValidateJSON([]byte(test.value))
+			if test.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrMismatch)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	// Unsupported keywords and malformed values are rejected instead of ignored
+	for _, document := range []string{
+		`{"$ref":"#/definitions/a"}`,
+		`{"type":"text"}`,
+		`{"pattern":"["}`,
+		`{"minItems":-1}`,
+		`{"exclusiveMinimum":true}`,
+		`{"uniqueItems":"yes"}`,
+		`{"properties":{"a":1}}`,
+		`[]`,
+	} {
+		_, err := Compile([]byte(document))
+		assert.ErrorIs(t, err, ErrInvalidSchema, document)
+	}
+
+	// Annotations are accepted
+	_, err := Compile([]byte(`{"title":"Wine","description":"Attributes of a wine","type":"object"}`))
+	assert.Nil(t, err)
+}
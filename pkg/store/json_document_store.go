@@ -0,0 +1,60 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+)
+
+/*
+The DocumentStore interface defines methods for reading and writing a single JSON document,
+such as a configuration table, as a whole.
+*/
+type DocumentStore[T any] interface {
+	Load() (T, error)
+	Save(document T) error
+}
+
+// The jsonDocumentStore struct is the implementation of the DocumentStore interface.
+type jsonDocumentStore[T any] struct {
+	filepath string
+}
+
+// NewJsonDocumentStore is a constructor for a new jsonDocumentStore instance.
+func NewJsonDocumentStore[T any](filepath string) DocumentStore[T] {
+	return &jsonDocumentStore[T]{
+		filepath: filepath,
+	}
+}
+
+// The Load method reads the document from the JSON file.
+func (s *jsonDocumentStore[T]) Load() (T, error) {
+	// Read all the data from the JSON file
+	var document T
+	data, err := os.ReadFile(s.filepath)
+	if err != nil {
+		return document, err
+	}
+
+	// Unmarshal the data into the document
+	if err = json.Unmarshal(data, &document); err != nil {
+		return document, err
+	}
+
+	return document, nil
+}
+
+// The Save method writes the document to the JSON file.
+func (s *jsonDocumentStore[T]) Save(document T) error {
+	// Marshal the data into a JSON format
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write the data to a temporary file and move it over the old one, so readers never see a partial document
+	tmp := s.filepath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.filepath)
+}
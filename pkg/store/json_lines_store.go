@@ -0,0 +1,89 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+/*
+The AppendStore interface defines methods for an append-only JSON lines file, where every record
+is written on its own line and never modified afterwards.
+*/
+type AppendStore[T any] interface {
+	Append(records ...T) error
+	LoadAll() ([]T, error)
+}
+
+// The jsonLinesStore struct is the implementation of the AppendStore interface.
+type jsonLinesStore[T any] struct {
+	mu       sync.Mutex
+	filepath string
+}
+
+// NewJsonLinesStore is a constructor for a new jsonLinesStore instance.
+func NewJsonLinesStore[T any](filepath string) AppendStore[T] {
+	return &jsonLinesStore[T]{
+		filepath: filepath,
+	}
+}
+
+// The Append method writes the records at the end of the file in a single write.
+func (s *jsonLinesStore[T]) Append(records ...T) error {
+	// Marshal every record into its own line
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Append the lines to the file, creating it if needed
+	file, err := os.OpenFile(s.filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// The LoadAll method reads every record in the file. A missing file has no records.
+func (s *jsonLinesStore[T]) LoadAll() ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []T{}
+	file, err := os.Open(s.filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return records, err
+	}
+	defer file.Close()
+
+	// Unmarshal the file line by line
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}